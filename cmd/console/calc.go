@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/spf13/cobra"
+
+	"github.com/databet-cloud/callback-test-tool/cmd/console/command"
+	"github.com/databet-cloud/callback-test-tool/cmd/console/config"
+	"github.com/databet-cloud/callback-test-tool/internal/calculator"
+	"github.com/databet-cloud/callback-test-tool/internal/calculator/former"
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+)
+
+func calcCommand(cfg config.Configuration) *cobra.Command {
+	var (
+		betType string
+		sizes   []int
+		stake   string
+		odds    []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "calc",
+		Short: "Calculate settle amount of the bet without sending callbacks",
+		Example: "callback-test-tool calc --bet-type system --sizes 2 --stake 10 " +
+			"--odd 1.5:WIN --odd 2.1:HALF_WIN --odd 3:LOSS",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// flags are parsed, invalid values of the bet are reported without the usage
+			cmd.SilenceUsage = true
+
+			log := MustCreateLogger(cfg)
+
+			c := command.Calculation{Sizes: sizes, Odds: make([]*callback.Odd, len(odds))}
+
+			var err error
+
+			c.BetType, err = callback.ParseBetType(betType)
+			if err != nil {
+				return err
+			}
+
+			c.Stake, _, err = apd.NewFromString(stake)
+			if err != nil {
+				return fmt.Errorf("invalid stake %q: %w", stake, err)
+			}
+
+			for i, odd := range odds {
+				c.Odds[i], err = command.ParseOdd(odd)
+				if err != nil {
+					return err
+				}
+			}
+
+			refundCalc := calculator.NewRefundCalcWithRules(log, former.EachExpressIndexes, MustCreateSettlementRules(cfg, log))
+
+			if err := command.Calculate(calculator.NewCalculator(refundCalc, log), c); err != nil {
+				return fmt.Errorf("failed to calculate: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&betType, "bet-type", "", "single", "Bet type: single, express or system")
+	flags.IntSliceVarP(&sizes, "sizes", "", nil, "System sizes, for example 2,3")
+	flags.StringVarP(&stake, "stake", "", "", "Stake of the bet")
+	flags.StringArrayVarP(&odds, "odd", "", nil, "Odd in format <ratio>:<status>, repeat for every odd")
+
+	_ = cmd.MarkFlagRequired("stake")
+	_ = cmd.MarkFlagRequired("odd")
+
+	return cmd
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"unicode"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/spf13/cobra"
+
+	"github.com/databet-cloud/callback-test-tool/cmd/console/config"
+	"github.com/databet-cloud/callback-test-tool/internal/certify"
+)
+
+// errCertificationFailed - the failed certification is the result of the command, main exits with code 1
+var errCertificationFailed = errors.New("certification failed")
+
+func certifyCommand(ctx context.Context, cfg config.Configuration) *cobra.Command {
+	var (
+		stake       string
+		operator    string
+		signKeyFile string
+		output      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "certify",
+		Short: "Run the certification suite against the callback server and write the signed summary",
+		Example: "callback-test-tool certify --callback-url http://localhost:8080 " +
+			"--operator \"Jane Doe\" --sign-key-file key.txt --output certification.json",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := MustCreateLogger(cfg)
+
+			var key []byte
+
+			if signKeyFile != "" {
+				var err error
+
+				key, err = os.ReadFile(signKeyFile)
+				if err != nil {
+					return fmt.Errorf("failed to read sign key: %w", err)
+				}
+
+				// editors end files with the line break which is not a part of the key
+				key = bytes.TrimRightFunc(key, unicode.IsSpace)
+			}
+
+			amount, _, err := apd.NewFromString(stake)
+			if err != nil {
+				return fmt.Errorf("invalid stake %q: %w", stake, err)
+			}
+
+			sv, _, _ := MustCreateService(ctx, cfg, log)
+
+			summary, err := certify.Run(ctx, sv, amount)
+			if err != nil {
+				return fmt.Errorf("failed to run certification: %w", err)
+			}
+
+			summary.Operator = operator
+
+			if err := summary.Sign(key); err != nil {
+				return fmt.Errorf("failed to sign summary: %w", err)
+			}
+
+			if err := summary.WriteText(os.Stdout); err != nil {
+				return err
+			}
+
+			if output == "" {
+				output = fmt.Sprintf("certification-%s.json", summary.StartedAt.Format("20060102-150405"))
+			}
+
+			if err := summary.WriteFile(output); err != nil {
+				return fmt.Errorf("failed to write summary: %w", err)
+			}
+
+			if !summary.Passed {
+				// the summary is already written, so the usage is not shown
+				cmd.SilenceUsage = true
+
+				return errCertificationFailed
+			}
+
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&stake, "stake", "", "10", "Stake of bets in the currency of the player")
+	flags.StringVarP(&operator, "operator", "", "", "Name of the person who signs off the certification")
+	flags.StringVarP(&signKeyFile, "sign-key-file", "", "", "File with the HMAC key of the signature, "+
+		"trailing whitespace is trimmed, the summary is only digested by SHA-256 without the key")
+	flags.StringVarP(&output, "output", "", "", "File of the JSON summary (default: certification-<time>.json)")
+
+	return cmd
+}
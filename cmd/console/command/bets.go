@@ -24,7 +24,7 @@ func bets(sv *service.Service) *prompt.Command {
 								return []*prompt.Command{
 									{
 										Key:    "dump",
-										Action: func() { printAsJSON(d.Value) },
+										Action: func() { dumpRequest(sv, d.Value) },
 									},
 								}
 							},
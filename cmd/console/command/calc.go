@@ -0,0 +1,216 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/apd/v3"
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/calculator"
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/prompt"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
+)
+
+// Calculation - input of the payout calculation
+type Calculation struct {
+	BetType callback.BetType
+	Sizes   []int
+	Stake   *apd.Decimal
+	Odds    []*callback.Odd
+}
+
+// Validate checks that the calculation can be done
+func (c Calculation) Validate() error {
+	if len(c.Odds) == 0 {
+		return errors.New("at least one odd is required")
+	}
+
+	if c.Stake == nil || c.Stake.Sign() <= 0 {
+		return errors.New("stake must be positive")
+	}
+
+	switch c.BetType {
+	case callback.SingleBetType:
+		if len(c.Odds) != 1 {
+			return errors.New("single bet must have exactly one odd")
+		}
+	case callback.ExpressBetType:
+		if len(c.Odds) < 2 {
+			return errors.New("express bet must have at least two odds")
+		}
+	case callback.SystemBetType:
+		if len(c.Sizes) == 0 {
+			return errors.New("system bet must have sizes")
+		}
+
+		for _, size := range c.Sizes {
+			if size < 1 || size > len(c.Odds) {
+				return fmt.Errorf("system size %d is out of range [1, %d]", size, len(c.Odds))
+			}
+		}
+	default:
+		return fmt.Errorf("invalid bet type %d", c.BetType)
+	}
+
+	return nil
+}
+
+// Calculate settles the bet and prints the amount, the settle type and the breakdown
+func Calculate(calc *calculator.Calculator, c Calculation) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	amount, settleType, breakdown, err := calc.SettleWithBreakdown(c.BetType, c.Sizes, c.Stake, c.Odds)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("amount: %s\nsettle type: %s\n%s\n", amount, settleType, breakdown)
+
+	return nil
+}
+
+// ParseOdd parses odd in format <ratio>:<status>, for example 1.85:WIN
+func ParseOdd(value string) (*callback.Odd, error) {
+	ratio, status, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid odd %q, expected <ratio>:<status>", value)
+	}
+
+	oddRatio, err := parseDecimal(ratio)
+	if err != nil {
+		return nil, err
+	}
+
+	oddStatus := sportsbook.OddStatus(strings.ToUpper(status))
+	if !isOddStatus(oddStatus) {
+		return nil, fmt.Errorf("invalid odd status %q", status)
+	}
+
+	return &callback.Odd{OddRatio: oddRatio, OddStatus: oddStatus}, nil
+}
+
+func calc(settleCalc *calculator.Calculator, log *zap.Logger) *prompt.Command {
+	return &prompt.Command{
+		Key: "calc",
+		Action: func() {
+			c, err := promptCalculation()
+			if err != nil {
+				log.Error("failed to read calculation", zap.Error(err))
+				return
+			}
+
+			if err := Calculate(settleCalc, c); err != nil {
+				log.Error("failed to calculate", zap.Error(err))
+			}
+		},
+	}
+}
+
+func promptCalculation() (Calculation, error) {
+	var (
+		c   Calculation
+		err error
+	)
+
+	c.BetType, err = prompt.Select("Select bet type", callback.SingleBetType, callback.ExpressBetType, callback.SystemBetType)
+	if err != nil {
+		return c, err
+	}
+
+	if c.BetType == callback.SystemBetType {
+		sizes, err := prompt.String("Put system sizes (comma separated)", func(s string) error {
+			_, err := parseSizes(s)
+			return err
+		})
+		if err != nil {
+			return c, err
+		}
+
+		c.Sizes, _ = parseSizes(sizes)
+	}
+
+	stake, err := prompt.String("Put stake", func(s string) error {
+		_, err := parseDecimal(s)
+		return err
+	})
+	if err != nil {
+		return c, err
+	}
+
+	c.Stake, _ = parseDecimal(stake)
+
+	for {
+		ratio, err := prompt.String(fmt.Sprintf("Put ratio of odd #%d (empty to finish)", len(c.Odds)+1), func(s string) error {
+			if s == "" {
+				return nil
+			}
+
+			_, err := parseDecimal(s)
+
+			return err
+		})
+		if err != nil {
+			return c, err
+		}
+
+		if ratio == "" {
+			return c, nil
+		}
+
+		odd := &callback.Odd{OddId: strconv.Itoa(len(c.Odds) + 1)}
+		odd.OddRatio, _ = parseDecimal(ratio)
+
+		odd.OddStatus, err = selectOddStatus(odd)
+		if err != nil {
+			return c, err
+		}
+
+		c.Odds = append(c.Odds, odd)
+	}
+}
+
+func parseSizes(value string) ([]int, error) {
+	parts := strings.Split(value, ",")
+	sizes := make([]int, len(parts))
+
+	for i, part := range parts {
+		size, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid system size %q", part)
+		}
+
+		sizes[i] = size
+	}
+
+	return sizes, nil
+}
+
+func parseDecimal(value string) (*apd.Decimal, error) {
+	d, _, err := apd.NewFromString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid decimal %q", value)
+	}
+
+	return d, nil
+}
+
+func isOddStatus(status sportsbook.OddStatus) bool {
+	switch status {
+	case sportsbook.OddStatusWin,
+		sportsbook.OddStatusHalfWin,
+		sportsbook.OddStatusLoss,
+		sportsbook.OddStatusHalfLoss,
+		sportsbook.OddStatusRefunded,
+		sportsbook.OddStatusCancelled,
+		sportsbook.OddStatusNotResulted:
+		return true
+	default:
+		return false
+	}
+}
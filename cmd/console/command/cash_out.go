@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 
+	"go.uber.org/zap"
+
 	"github.com/databet-cloud/callback-test-tool/internal/callback"
 	"github.com/databet-cloud/callback-test-tool/internal/prompt"
 	"github.com/databet-cloud/callback-test-tool/internal/service"
 	"github.com/databet-cloud/callback-test-tool/internal/storage"
 )
 
-func cashOut(ctx context.Context, sv *service.Service) *prompt.Command {
+// nolint:funlen // tree of all cash-out actions
+func cashOut(ctx context.Context, sv *service.Service, log *zap.Logger) *prompt.Command {
 	return &prompt.Command{
 		Key: "cash out",
 		Tree: &prompt.Tree{
@@ -18,32 +21,50 @@ func cashOut(ctx context.Context, sv *service.Service) *prompt.Command {
 			Commands: func() []*prompt.Command {
 				return []*prompt.Command{
 					{
-						Key: "accept",
+						Key: "create order",
 						Tree: &prompt.Tree{
 							Label:             selectBetLabel,
 							ReturnAfterAction: true,
 							Commands: func() []*prompt.Command {
-								bets := sv.Bets(callback.BetAcceptRequestType, callback.BetUnSettleRequestType)
+								bets := sv.Bets(service.SettleableRequestTypes()...)
 								return convert(bets, func(d *storage.Document[*callback.Data]) *prompt.Command {
 									return &prompt.Command{
-										Key:    betDocLabel(d),
-										Action: func() { sv.AcceptBetCashOut(ctx, d.Value.BetID) },
+										Key: betDocLabel(d),
+										Action: func() {
+											fraction := prompt.Float("Put cash-out part of the available stake (0..1]")
+											sv.CreateCashOutOrder(ctx, d.Value.BetID, fraction)
+										},
 									}
 								})
 							},
 						},
 					},
 					{
-						Key: "decline",
+						Key: "accept order",
 						Tree: &prompt.Tree{
 							Label:             selectCashOutLabel,
 							ReturnAfterAction: true,
 							Commands: func() []*prompt.Command {
-								cashOuts := sv.CashOuts(callback.BetCashOutOrdersAcceptedRequestType)
-								return convert(cashOuts, func(data *storage.Document[*callback.Data]) *prompt.Command {
+								orders := sv.CashOutOrders("", service.CashOutOrderCreated)
+								return convert(orders, func(d *storage.Document[*service.CashOutOrder]) *prompt.Command {
+									return &prompt.Command{
+										Key:    cashOutDocLabel(d),
+										Action: func() { sv.AcceptCashOutOrder(ctx, d.Value.ID) },
+									}
+								})
+							},
+						},
+					},
+					{
+						Key: "decline orders",
+						Tree: &prompt.Tree{
+							Label:             selectBetLabel,
+							ReturnAfterAction: true,
+							Commands: func() []*prompt.Command {
+								return convert(sv.Bets(), func(d *storage.Document[*callback.Data]) *prompt.Command {
 									return &prompt.Command{
-										Key:    cashOutDocLabel(data),
-										Action: func() { sv.DeclineBetCashOut(ctx, data.Value.BetID, data.Value.CashOutOrderID) },
+										Key:    betDocLabel(d),
+										Action: func() { declineCashOutOrders(ctx, sv, d.Value.BetID, log) },
 									}
 								})
 							},
@@ -54,7 +75,7 @@ func cashOut(ctx context.Context, sv *service.Service) *prompt.Command {
 						Tree: &prompt.Tree{
 							Label: selectCashOutLabel,
 							Commands: func() []*prompt.Command {
-								return convert(sv.CashOuts(), func(d *storage.Document[*callback.Data]) *prompt.Command {
+								return convert(sv.CashOutOrders(""), func(d *storage.Document[*service.CashOutOrder]) *prompt.Command {
 									return &prompt.Command{
 										Key: cashOutDocLabel(d),
 										Tree: &prompt.Tree{
@@ -78,3 +99,25 @@ func cashOut(ctx context.Context, sv *service.Service) *prompt.Command {
 		},
 	}
 }
+
+func declineCashOutOrders(ctx context.Context, sv *service.Service, betID string, log *zap.Logger) {
+	docs := sv.CashOutOrders(betID, service.CashOutOrderCreated, service.CashOutOrderAccepted)
+	if len(docs) == 0 {
+		log.Warn("Bet has no cash-out orders to decline", zap.String("id", betID))
+		return
+	}
+
+	orders := convert(docs, func(d *storage.Document[*service.CashOutOrder]) *service.CashOutOrder { return d.Value })
+
+	selected, err := prompt.MultiSelect("Select cash-out orders to decline", orders...)
+	if err != nil {
+		log.Error("failed to select cash-out orders", zap.Error(err))
+		return
+	}
+
+	if len(selected) == 0 {
+		return
+	}
+
+	sv.DeclineCashOutOrders(ctx, betID, convert(selected, func(o *service.CashOutOrder) string { return o.ID }))
+}
@@ -0,0 +1,38 @@
+package command
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/prompt"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
+)
+
+func catalogCommand(ctx context.Context, catalog *sportsbook.Catalog, log *zap.Logger) *prompt.Command {
+	return &prompt.Command{
+		Key: "sport events catalog",
+		Tree: &prompt.Tree{
+			Label: "Select catalog action",
+			Commands: func() []*prompt.Command {
+				return []*prompt.Command{
+					{
+						Key:    "stats",
+						Action: func() { log.Info("Sport events catalog", zap.Any("stats", catalog.Stats())) },
+					},
+					{
+						Key: "refresh",
+						Action: func() {
+							if err := catalog.Refresh(ctx); err != nil {
+								log.Error("failed to refresh sport events catalog", zap.Error(err))
+								return
+							}
+
+							log.Info("Sport events catalog", zap.Any("stats", catalog.Stats()))
+						},
+					},
+				}
+			},
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/manifoldco/promptui"
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/coverage"
+	"github.com/databet-cloud/callback-test-tool/internal/prompt"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+)
+
+var gapStyle = promptui.Styler(promptui.FGRed, promptui.FGBold)
+
+func highlightGap(s string) string {
+	return gapStyle(s)
+}
+
+func coverageCommand(ctx context.Context, sv *service.Service, log *zap.Logger) *prompt.Command {
+	return &prompt.Command{
+		Key: "coverage",
+		Tree: &prompt.Tree{
+			Label:             "Select coverage action",
+			ReturnAfterAction: true,
+			Commands: func() []*prompt.Command {
+				return []*prompt.Command{
+					{
+						Key:    "matrix",
+						Action: func() { println(coverage.Build(sv).Format(highlightGap)) },
+					},
+					{
+						Key:    "fill gaps",
+						Action: func() { fillGaps(ctx, sv, log) },
+					},
+				}
+			},
+		},
+	}
+}
+
+func fillGaps(ctx context.Context, sv *service.Service, log *zap.Logger) {
+	m := coverage.Build(sv)
+
+	gaps := m.Gaps()
+	if len(gaps) == 0 {
+		log.Info("Coverage is complete, there are no gaps")
+		return
+	}
+
+	cur := sv.PlayerCurrency()
+
+	stake, err := prompt.Decimal(fmt.Sprintf("Put stake of generated bets (%s)", cur), cur.Parse)
+	if err != nil {
+		log.Error("failed to read stake", zap.Error(err))
+		return
+	}
+
+	log.Info("Filling coverage gaps", zap.Stringers("gaps", gaps))
+
+	if err := coverage.NewFiller(sv, stake).Fill(ctx, m); err != nil {
+		log.Error("failed to fill coverage gaps", zap.Error(err))
+	}
+
+	println(coverage.Build(sv).Format(highlightGap))
+}
@@ -2,7 +2,12 @@ package command
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
 
 	"github.com/databet-cloud/callback-test-tool/internal/callback"
 	"github.com/databet-cloud/callback-test-tool/internal/prompt"
@@ -10,7 +15,51 @@ import (
 	"github.com/databet-cloud/callback-test-tool/internal/storage"
 )
 
-func declineBet(ctx context.Context, sv *service.Service) *prompt.Command {
+type draftAction string
+
+func (a draftAction) String() string {
+	return string(a)
+}
+
+type contextKey string
+
+func (k contextKey) String() string {
+	return string(k)
+}
+
+const (
+	addDraftAction      draftAction = "add restriction"
+	removeDraftAction   draftAction = "remove restriction"
+	sendDraftAction     draftAction = "send"
+	editContextAction   draftAction = "edit context value"
+	addContextAction    draftAction = "add context value"
+	removeContextAction draftAction = "remove context value"
+	cancelDraftAction   draftAction = "cancel"
+)
+
+type legOption struct {
+	odd *callback.Odd
+}
+
+func (o legOption) String() string {
+	return fmt.Sprintf("[%s].[%s].[%s] %s", o.odd.MatchId, o.odd.MarketId, o.odd.OddId, o.odd.OddRatio.Text('f'))
+}
+
+type restrictionOption callback.Restriction
+
+func (o restrictionOption) String() string {
+	leg := make([]string, 0, 3)
+
+	for _, key := range []string{"sport_event_id", "market_id", "odd_id"} {
+		if v, ok := o.Context[key]; ok {
+			leg = append(leg, fmt.Sprintf("[%v]", v))
+		}
+	}
+
+	return fmt.Sprintf("%s %s", o.Type, strings.Join(leg, "."))
+}
+
+func declineBet(ctx context.Context, sv *service.Service, log *zap.Logger) *prompt.Command {
 	return &prompt.Command{
 		Key: "decline bet",
 		Tree: &prompt.Tree{
@@ -22,19 +71,16 @@ func declineBet(ctx context.Context, sv *service.Service) *prompt.Command {
 				return convert(requests, func(d *storage.Document[*callback.Data]) *prompt.Command {
 					return &prompt.Command{
 						Key: betDocLabel(d),
-						Tree: &prompt.Tree{
-							Label:             fmt.Sprintf("Select restriction to decline: %s", betDocLabel(d)),
-							ReturnAfterAction: true,
-							Commands: func() []*prompt.Command {
-								rr := callback.GetAllBetRestrictions()
-
-								return convert(rr, func(r callback.RestrictionType) *prompt.Command {
-									return &prompt.Command{
-										Key:    r.String(),
-										Action: func() { sv.DeclineBet(ctx, d.Value.BetID, r) },
-									}
-								})
-							},
+						Action: func() {
+							restrictions, err := buildRestrictions(sv, d.Value)
+							if err != nil {
+								log.Error("failed to build restrictions", zap.Error(err))
+								return
+							}
+
+							if len(restrictions) > 0 {
+								sv.DeclineBet(ctx, d.Value.BetID, restrictions)
+							}
 						},
 					}
 				})
@@ -42,3 +88,204 @@ func declineBet(ctx context.Context, sv *service.Service) *prompt.Command {
 		},
 	}
 }
+
+// buildRestrictions collects restrictions bound to legs of the bet, nil is returned on cancel
+func buildRestrictions(sv *service.Service, bet *callback.Data) ([]callback.Restriction, error) {
+	restrictions := make([]callback.Restriction, 0, 1)
+
+	for {
+		printAsJSON(restrictions)
+
+		actions := []draftAction{addDraftAction}
+		if len(restrictions) > 0 {
+			actions = append(actions, sendDraftAction, removeDraftAction)
+		}
+
+		action, err := prompt.Select(
+			fmt.Sprintf("Restrictions of the bet %s (%d)", bet.BetID, len(restrictions)),
+			append(actions, cancelDraftAction)...,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		switch action {
+		case sendDraftAction:
+			return restrictions, nil
+		case cancelDraftAction:
+			return nil, nil
+		case removeDraftAction:
+			options := convert(restrictions, func(r callback.Restriction) restrictionOption { return restrictionOption(r) })
+
+			removed, err := prompt.Select("Select restriction to remove", options...)
+			if err != nil {
+				return nil, err
+			}
+
+			i := slices.IndexFunc(restrictions, func(r callback.Restriction) bool {
+				return restrictionOption(r).String() == removed.String()
+			})
+			restrictions = slices.Delete(restrictions, i, i+1)
+		case addDraftAction:
+			draft, err := draftRestriction(sv, bet)
+			if err != nil {
+				return nil, err
+			}
+
+			if draft != nil {
+				restrictions = append(restrictions, *draft)
+			}
+		}
+	}
+}
+
+// draftRestriction renders the restriction template for the selected leg
+// and lets to edit the context, nil is returned on cancel
+func draftRestriction(sv *service.Service, bet *callback.Data) (*callback.Restriction, error) {
+	t, err := prompt.Select("Select restriction type", sv.Restrictions().Types()...)
+	if err != nil {
+		return nil, err
+	}
+
+	variants := sv.Restrictions().Variants(t)
+
+	tmpl := variants[0]
+	if len(variants) > 1 {
+		tmpl, err = prompt.Select("Select restriction variant", variants...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	legs := convert(bet.PrivateOdds, func(o *callback.Odd) legOption { return legOption{o} })
+
+	leg, err := prompt.Select("Select restricted leg", legs...)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := sv.DraftRestriction(bet.BetID, tmpl, leg.odd)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		printAsJSON(draft)
+
+		action, err := prompt.Select(
+			"Select action",
+			addDraftAction,
+			editContextAction,
+			addContextAction,
+			removeContextAction,
+			cancelDraftAction,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		switch action {
+		case addDraftAction:
+			return &draft, nil
+		case cancelDraftAction:
+			return nil, nil
+		case editContextAction:
+			err = editContextValue(draft.Context)
+		case addContextAction:
+			err = addContextValue(draft.Context)
+		case removeContextAction:
+			err = removeContextValue(draft.Context)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func editContextValue(context map[string]interface{}) error {
+	key, err := selectContextKey(context)
+	if err != nil || key == "" {
+		return err
+	}
+
+	current := context[key]
+
+	text, ok := current.(string)
+	if !ok {
+		raw, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+
+		text = string(raw)
+	}
+
+	edited, err := prompt.Edit(key, text)
+	if err != nil {
+		return err
+	}
+
+	// strings stay strings, other values are parsed as JSON
+	if ok {
+		context[key] = edited
+	} else {
+		context[key] = parseContextValue(edited)
+	}
+
+	return nil
+}
+
+func addContextValue(context map[string]interface{}) error {
+	key, err := prompt.String("Put context key", nil)
+	if err != nil || key == "" {
+		return err
+	}
+
+	value, err := prompt.String(fmt.Sprintf("Put %s value (JSON or string)", key), nil)
+	if err != nil {
+		return err
+	}
+
+	context[key] = parseContextValue(value)
+
+	return nil
+}
+
+func removeContextValue(context map[string]interface{}) error {
+	key, err := selectContextKey(context)
+	if err != nil || key == "" {
+		return err
+	}
+
+	delete(context, key)
+
+	return nil
+}
+
+func selectContextKey(context map[string]interface{}) (string, error) {
+	if len(context) == 0 {
+		return "", nil
+	}
+
+	keys := make([]contextKey, 0, len(context))
+	for key := range context {
+		keys = append(keys, contextKey(key))
+	}
+
+	slices.Sort(keys)
+
+	key, err := prompt.Select("Select context key", keys...)
+
+	return key.String(), err
+}
+
+// parseContextValue parses JSON value, invalid JSON is used as a string
+func parseContextValue(value string) any {
+	var v any
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		return value
+	}
+
+	return v
+}
@@ -2,13 +2,16 @@ package command
 
 import (
 	"context"
+	"fmt"
+
+	"go.uber.org/zap"
 
 	"github.com/databet-cloud/callback-test-tool/internal/callback"
 	"github.com/databet-cloud/callback-test-tool/internal/prompt"
 	"github.com/databet-cloud/callback-test-tool/internal/service"
 )
 
-func placeBet(ctx context.Context, sv *service.Service) *prompt.Command {
+func placeBet(ctx context.Context, sv *service.Service, log *zap.Logger) *prompt.Command {
 	return &prompt.Command{
 		Key: "place bet",
 		Tree: &prompt.Tree{
@@ -17,15 +20,15 @@ func placeBet(ctx context.Context, sv *service.Service) *prompt.Command {
 				return []*prompt.Command{
 					{
 						Key:    "single",
-						Action: func() { sv.PlaceBet(ctx, callback.SingleBetType, prompt.Float("Put bet amount")) },
+						Action: func() { placeBetWithStake(ctx, sv, callback.SingleBetType, log) },
 					},
 					{
 						Key:    "express",
-						Action: func() { sv.PlaceBet(ctx, callback.ExpressBetType, prompt.Float("Put bet amount")) },
+						Action: func() { placeBetWithStake(ctx, sv, callback.ExpressBetType, log) },
 					},
 					{
 						Key:    "system",
-						Action: func() { sv.PlaceBet(ctx, callback.SystemBetType, prompt.Float("Put bet amount")) },
+						Action: func() { placeBetWithStake(ctx, sv, callback.SystemBetType, log) },
 					},
 				}
 			},
@@ -33,3 +36,15 @@ func placeBet(ctx context.Context, sv *service.Service) *prompt.Command {
 		},
 	}
 }
+
+func placeBetWithStake(ctx context.Context, sv *service.Service, betType callback.BetType, log *zap.Logger) {
+	cur := sv.PlayerCurrency()
+
+	stake, err := prompt.Decimal(fmt.Sprintf("Put bet amount (%s)", cur), cur.Parse)
+	if err != nil {
+		log.Error("failed to read bet amount", zap.Error(err))
+		return
+	}
+
+	sv.PlaceBet(ctx, betType, stake)
+}
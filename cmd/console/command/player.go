@@ -1,6 +1,9 @@
 package command
 
 import (
+	"context"
+	"time"
+
 	"go.uber.org/zap"
 
 	"github.com/databet-cloud/callback-test-tool/internal/prompt"
@@ -11,12 +14,31 @@ func player(sv *service.Service, log *zap.Logger) *prompt.Command {
 	return &prompt.Command{
 		Key: "player",
 		Action: func() {
+			token := sv.PlayerToken()
+
 			log.Info(
 				"Player info",
 				zap.String("id", sv.PlayerID()),
 				zap.Any("balance", sv.PlayerBalance()),
-				zap.String("token", sv.PlayerToken()),
+				zap.String("token", token.Value),
+				zap.Time("token_expires_at", token.ExpiresAt),
+				zap.Duration("token_expires_in", time.Until(token.ExpiresAt).Round(time.Second)),
 			)
 		},
 	}
 }
+
+func refreshToken(ctx context.Context, sv *service.Service, log *zap.Logger) *prompt.Command {
+	return &prompt.Command{
+		Key: "refresh token",
+		Action: func() {
+			token, err := sv.RefreshPlayerToken(ctx)
+			if err != nil {
+				log.Error("failed to refresh token", zap.Error(err))
+				return
+			}
+
+			log.Info("Token refreshed", zap.String("token", token.Value), zap.Time("token_expires_at", token.ExpiresAt))
+		},
+	}
+}
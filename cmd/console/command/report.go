@@ -0,0 +1,57 @@
+package command
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/prompt"
+	"github.com/databet-cloud/callback-test-tool/internal/report"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+)
+
+func reportCommand(sv *service.Service, log *zap.Logger) *prompt.Command {
+	return &prompt.Command{
+		Key: "report",
+		Tree: &prompt.Tree{
+			Label:             "Select report format",
+			ReturnAfterAction: true,
+			Commands: func() []*prompt.Command {
+				return convert(report.Formats(), func(format report.Format) *prompt.Command {
+					return &prompt.Command{
+						Key:    format.String(),
+						Action: func() { writeReport(sv, format, log) },
+					}
+				})
+			},
+		},
+	}
+}
+
+func writeReport(sv *service.Service, format report.Format, log *zap.Logger) {
+	generatedAt := time.Now().UTC()
+
+	r, err := report.Build(sv, generatedAt)
+	if err != nil {
+		log.Error("failed to build report", zap.Error(err))
+		return
+	}
+
+	path, err := prompt.Edit("Report file", fmt.Sprintf("report-%s%s", generatedAt.Format("20060102-150405"), format.Extension()))
+	if err != nil {
+		return
+	}
+
+	if err := r.WriteFile(path, format); err != nil {
+		log.Error("failed to write report", zap.String("path", path), zap.Error(err))
+		return
+	}
+
+	log.Info(
+		"Report is written",
+		zap.String("path", path),
+		zap.Bool("passed", r.Passed()),
+		zap.Int("violations", len(r.Violations())),
+	)
+}
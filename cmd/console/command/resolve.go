@@ -0,0 +1,109 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/prompt"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+)
+
+const allMarketsKey = "all markets"
+
+func resolveSportEvent(ctx context.Context, sv *service.Service, log *zap.Logger) *prompt.Command {
+	return &prompt.Command{
+		Key: "resolve sport event",
+		Tree: &prompt.Tree{
+			Label:             "Select sport event (<match_id> (<open legs>))",
+			ReturnAfterAction: true,
+			Commands: func() []*prompt.Command {
+				selections := sv.OpenSelections()
+
+				return convert(distinct(selections, matchID), func(id string) *prompt.Command {
+					matchOdds := filter(selections, func(o *callback.Odd) bool { return o.MatchId == id })
+
+					return &prompt.Command{
+						Key: fmt.Sprintf("%s (%d)", id, len(matchOdds)),
+						Tree: &prompt.Tree{
+							Label:             fmt.Sprintf("Select market of %s", id),
+							ReturnAfterAction: true,
+							Commands: func() []*prompt.Command {
+								commands := []*prompt.Command{
+									{Key: allMarketsKey, Action: func() { resolveOdds(ctx, sv, matchOdds, log) }},
+								}
+
+								markets := convert(distinct(matchOdds, marketID), func(id string) *prompt.Command {
+									marketOdds := filter(matchOdds, func(o *callback.Odd) bool { return o.MarketId == id })
+
+									return &prompt.Command{Key: id, Action: func() { resolveOdds(ctx, sv, marketOdds, log) }}
+								})
+
+								return append(commands, markets...)
+							},
+						},
+					}
+				})
+			},
+		},
+	}
+}
+
+func resolveOdds(ctx context.Context, sv *service.Service, odds []*callback.Odd, log *zap.Logger) {
+	results := make([]*service.OddResult, len(odds))
+
+	for i, odd := range odds {
+		status, err := selectOddStatus(odd)
+		if err != nil {
+			log.Error("failed to set odd status", zap.Error(err))
+
+			return
+		}
+
+		results[i] = &service.OddResult{
+			MatchID:  odd.MatchId,
+			MarketID: odd.MarketId,
+			OddID:    odd.OddId,
+			Status:   status,
+		}
+	}
+
+	if err := sv.ResolveSportEvent(ctx, results); err != nil {
+		log.Error("failed to resolve sport event", zap.Error(err))
+	}
+}
+
+func matchID(o *callback.Odd) string {
+	return o.MatchId
+}
+
+func marketID(o *callback.Odd) string {
+	return o.MarketId
+}
+
+func distinct[V any](values []V, key func(V) string) []string {
+	keys := make([]string, 0, len(values))
+
+	for _, v := range values {
+		if k := key(v); !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+func filter[V any](values []V, f func(V) bool) []V {
+	result := make([]V, 0, len(values))
+
+	for _, v := range values {
+		if f(v) {
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
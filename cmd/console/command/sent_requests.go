@@ -30,7 +30,7 @@ func sentRequests(ctx context.Context, sv *service.Service) *prompt.Command {
 									},
 									{
 										Key:    "dump",
-										Action: func() { printAsJSON(d.Value) },
+										Action: func() { dumpRequest(sv, d.Value) },
 									},
 								}
 							},
@@ -33,22 +33,21 @@ func settleBet(ctx context.Context, sv *service.Service, log *zap.Logger) *promp
 						Action: func() {
 							odds := make([]*callback.Odd, len(d.Value.PrivateOdds))
 							for i, odd := range d.Value.PrivateOdds {
-								label := fmt.Sprintf("Select status for [%s].[%s].[%s]", odd.MatchId, odd.MarketId, odd.OddId)
-								status, err := prompt.Select(
-									label,
-									sportsbook.OddStatusWin,
-									sportsbook.OddStatusHalfWin,
-									sportsbook.OddStatusLoss,
-									sportsbook.OddStatusHalfLoss,
-									sportsbook.OddStatusRefunded,
-								)
+								status, err := selectOddStatus(odd)
 								if err != nil {
 									log.Error("failed to set odd status", zap.Error(err))
 
 									return
 								}
 
-								odds[i] = odd.WithStatus(status)
+								odds[i] = odd.WithStatus(status, sv.Now())
+							}
+
+							breakdown, err := sv.ExplainSettlement(d.Value.BetID, odds)
+							if err != nil {
+								log.Warn("failed to explain settlement", zap.Error(err))
+							} else {
+								println(breakdown.String())
 							}
 
 							sv.SettleBet(ctx, d.Value.BetID, odds)
@@ -74,3 +73,34 @@ func unSettleBet(ctx context.Context, sv *service.Service) *prompt.Command {
 		},
 	}
 }
+
+func selectOddStatus(odd *callback.Odd) (sportsbook.OddStatus, error) {
+	return prompt.Select(
+		fmt.Sprintf("Select status for [%s].[%s].[%s]", odd.MatchId, odd.MarketId, odd.OddId),
+		sportsbook.OddStatusWin,
+		sportsbook.OddStatusHalfWin,
+		sportsbook.OddStatusLoss,
+		sportsbook.OddStatusHalfLoss,
+		sportsbook.OddStatusRefunded,
+		sportsbook.OddStatusCancelled,
+		sportsbook.OddStatusNotResulted,
+	)
+}
+
+// dumpRequest prints the request with breakdown of the settle amount
+func dumpRequest(sv *service.Service, data *callback.Data) {
+	printAsJSON(data)
+
+	if data.RequestType != callback.BetSettleRequestType {
+		return
+	}
+
+	breakdown, ok, err := sv.SettlementBreakdown(data.RequestID)
+
+	switch {
+	case err != nil:
+		println("failed to explain settlement: " + err.Error())
+	case ok:
+		println(breakdown.String())
+	}
+}
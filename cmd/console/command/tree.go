@@ -9,33 +9,53 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/databet-cloud/callback-test-tool/cmd/console/config"
+	"github.com/databet-cloud/callback-test-tool/internal/calculator"
 	"github.com/databet-cloud/callback-test-tool/internal/callback"
 	"github.com/databet-cloud/callback-test-tool/internal/prompt"
 	"github.com/databet-cloud/callback-test-tool/internal/service"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
 	"github.com/databet-cloud/callback-test-tool/internal/storage"
 )
 
 const (
 	selectBetLabel     = "Select bet (<id>:<state>_[<created>]:[<updated>])"
-	selectCashOutLabel = "Select cash-out (<id>:<state>_[<created>]:[<updated>])"
+	selectCashOutLabel = "Select cash-out (<bet>:<order>:<state>_[<created>]:[<updated>])"
 )
 
-func Tree(ctx context.Context, sv *service.Service, cfg config.Configuration, log *zap.Logger) *prompt.Tree {
+func Tree(
+	ctx context.Context,
+	sv *service.Service,
+	catalog *sportsbook.Catalog,
+	calculator *calculator.Calculator,
+	cfg config.Configuration,
+	log *zap.Logger,
+) *prompt.Tree {
 	return &prompt.Tree{
 		Label: "Select command",
 		Commands: func() []*prompt.Command {
-			return []*prompt.Command{
+			commands := []*prompt.Command{
 				player(sv, log),
+				refreshToken(ctx, sv, log),
 				configCommand(cfg, log),
-				placeBet(ctx, sv),
+				placeBet(ctx, sv, log),
 				acceptBet(ctx, sv),
-				declineBet(ctx, sv),
+				declineBet(ctx, sv, log),
 				settleBet(ctx, sv, log),
 				unSettleBet(ctx, sv),
-				cashOut(ctx, sv),
+				resolveSportEvent(ctx, sv, log),
+				cashOut(ctx, sv, log),
 				bets(sv),
 				sentRequests(ctx, sv),
+				reportCommand(sv, log),
+				coverageCommand(ctx, sv, log),
+				calc(calculator, log),
 			}
+
+			if catalog != nil {
+				commands = append(commands, catalogCommand(ctx, catalog, log))
+			}
+
+			return commands
 		},
 	}
 }
@@ -59,12 +79,12 @@ func betDocLabel(doc *storage.Document[*callback.Data]) string {
 	)
 }
 
-func cashOutDocLabel(doc *storage.Document[*callback.Data]) string {
+func cashOutDocLabel(doc *storage.Document[*service.CashOutOrder]) string {
 	return fmt.Sprintf(
 		"%s:%s:%s_[%s]:[%s]",
 		doc.Value.BetID,
-		doc.Value.CashOutOrderID,
-		doc.Value.RequestType,
+		doc.Value.ID,
+		doc.Value.State,
 		doc.CreatedAt.Format(time.RFC3339),
 		doc.UpdatedAt.Format(time.RFC3339),
 	)
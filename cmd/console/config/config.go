@@ -1,9 +1,12 @@
 package config
 
 import (
+	"errors"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 type Certificate struct {
@@ -16,10 +19,83 @@ type Configuration struct {
 	Betting struct {
 		URL         string
 		Certificate Certificate
+		// TokenTTL - lifetime of the auth token, used when the token does not contain its expiry
+		TokenTTL time.Duration
 	}
-	Balance           float64
+	Balance string
+	// Currency - currency of the player, the currency of the token create request is used if empty
+	Currency          string
+	Currencies        Currencies
 	CallbackServerURL string
 	DataBetGQLURL     string
+	SportEvents       SportEvents
+	// CashOutMargin - share of the cash-out value kept by the operator
+	CashOutMargin string
+	// SettlementRules - name of the settlement rule set
+	SettlementRules string
+	// MaxPayout - payout cap which overrides the cap of the rule set
+	MaxPayout string
+	// RestrictionsFile - path to the restriction templates which extend embedded templates
+	RestrictionsFile string
+	Risk             Risk
+	Policy           Policy
+	// Seed - seed of random values and IDs, a random seed is used if zero
+	Seed int64
+	// FixedClock - RFC3339 start time of the stepping clock, the system clock is used if empty
+	FixedClock     string
+	FixedClockStep time.Duration
+}
+
+// Policy - automatic answer to placed bets
+type Policy struct {
+	Enabled           bool
+	AcceptProbability float64
+	MinDelay          time.Duration
+	MaxDelay          time.Duration
+	// DeclineWeights - weights of restriction types of declines
+	DeclineWeights map[string]int
+	// MatchDuration - delay between accept and settlement, zero disables auto-settlement
+	MatchDuration time.Duration
+	// ResultWeights - weights of odd statuses of auto-settlement
+	ResultWeights map[string]int
+}
+
+// Risk - limits of the risk engine which accepts or declines placed bets, empty limits are not checked
+type Risk struct {
+	Enabled        bool
+	MinStake       string
+	MaxStake       string
+	PlayerLimit    string
+	MaxEventPayout string
+	BetInterval    time.Duration
+	MinOdd         string
+	MaxOdd         string
+	BetTypes       []string
+}
+
+type Currencies struct {
+	// File - path to the list of currencies with exchange rates, embedded list is used if empty
+	File string
+	// Freebet, Insurance - currencies of the player bonuses, the player currency is used if empty
+	Freebet   string
+	Insurance string
+}
+
+type SportEvents struct {
+	SportIDs      []string
+	TournamentIDs []string
+	// MatchState - all, live or prematch
+	MatchState string
+	// StartTimeFrom, StartTimeTo - RFC3339 start time window
+	StartTimeFrom string
+	StartTimeTo   string
+	MarketTypeIDs []int
+	MinOdd        string
+	MaxOdd        string
+	PageSize      int
+	// CatalogRefreshInterval - interval of the sport events catalog refresh, zero disables the catalog
+	CatalogRefreshInterval time.Duration
+	CatalogSize            int
 }
 
 // nolint:lll // configuration flags
@@ -28,7 +104,11 @@ func LoadConfig(cmd *cobra.Command) Configuration {
 	flags := cmd.PersistentFlags()
 
 	flags.BoolVarP(&cfg.Debug, "debug", "d", false, "enable debug mode")
-	flags.Float64VarP(&cfg.Balance, "balance", "b", 1000, "Balance of the player")
+	flags.StringVarP(&cfg.Balance, "balance", "b", "1000", "Balance of the player in the currency of the player")
+	flags.StringVarP(&cfg.Currency, "currency", "", "", "Currency of the player, the currency of the token create request is used by default")
+	flags.StringVarP(&cfg.Currencies.File, "currencies-file", "", "", "Path to the JSON list of currencies with exchange rates, embedded list is used by default")
+	flags.StringVarP(&cfg.Currencies.Freebet, "freebet-currency", "", "", "Currency of the player freebets, the player currency is used by default")
+	flags.StringVarP(&cfg.Currencies.Insurance, "insurance-currency", "", "", "Currency of the player insurances, the player currency is used by default")
 
 	flags.StringVarP(&cfg.CallbackServerURL, "callback-url", "u", "http://127.0.0.1:3000/databet", "Callback server URL")
 
@@ -37,9 +117,54 @@ func LoadConfig(cmd *cobra.Command) Configuration {
 	flags.StringVarP(&cfg.Betting.URL, "betting-url", "", "https://betting-public-stage-betting.ginsp.net", "Betting server URL")
 	flags.StringVarP(&cfg.Betting.Certificate.Path, "betting-certificate-path", "", "./databetstage.crt", "Path to the betting .crt file")
 	flags.StringVarP(&cfg.Betting.Certificate.KeyPath, "betting-certificate-key", "", "./databetstage.key", "Path to the betting .key file")
+	flags.DurationVarP(&cfg.Betting.TokenTTL, "betting-token-ttl", "", time.Hour, "Lifetime of the auth token if it can not be extracted from the token")
+
+	flags.StringVarP(&cfg.RestrictionsFile, "restrictions-file", "", "", "Path to the JSON restriction templates which extend embedded templates")
+	flags.BoolVarP(&cfg.Risk.Enabled, "risk-engine", "", false, "Accept or decline placed bets automatically by the risk engine")
+	flags.StringVarP(&cfg.Risk.MinStake, "risk-min-stake", "", "", "Minimal stake of the bet, min_bet restriction")
+	flags.StringVarP(&cfg.Risk.MaxStake, "risk-max-stake", "", "", "Maximal stake of the bet, max_bet restriction")
+	flags.StringVarP(&cfg.Risk.PlayerLimit, "risk-player-limit", "", "", "Maximal sum of stakes of open bets of the player, player_limit restriction")
+	flags.StringVarP(&cfg.Risk.MaxEventPayout, "risk-max-event-payout", "", "", "Maximal potential payout of open bets on the sport event, max_bet restriction")
+	flags.DurationVarP(&cfg.Risk.BetInterval, "risk-bet-interval", "", 0, "Minimal interval between bets of the player, bet_interval restriction")
+	flags.StringVarP(&cfg.Risk.MinOdd, "risk-min-odd", "", "", "Minimal odd value of the selection, selection_value restriction")
+	flags.StringVarP(&cfg.Risk.MaxOdd, "risk-max-odd", "", "", "Maximal odd value of the selection, selection_value restriction")
+	flags.StringSliceVarP(&cfg.Risk.BetTypes, "risk-bet-types", "", nil, "Allowed bet types: single, express or system, bet_type restriction")
+
+	flags.Int64VarP(&cfg.Seed, "seed", "", 0, "Seed of random values and IDs to reproduce the session, a random seed is used by default")
+	flags.StringVarP(&cfg.FixedClock, "fixed-clock", "", "", "Start time of the fixed clock (RFC3339), the clock moves forward by the step on every reading")
+	flags.DurationVarP(&cfg.FixedClockStep, "fixed-clock-step", "", time.Second, "Step of the fixed clock")
+
+	flags.BoolVarP(&cfg.Policy.Enabled, "policy", "", false, "Accept or decline placed bets automatically after a random delay")
+	flags.Float64VarP(&cfg.Policy.AcceptProbability, "policy-accept-probability", "", 0.9, "Probability of the accept of the placed bet")
+	flags.DurationVarP(&cfg.Policy.MinDelay, "policy-min-delay", "", time.Second, "Minimal delay between place and accept or decline")
+	flags.DurationVarP(&cfg.Policy.MaxDelay, "policy-max-delay", "", 5*time.Second, "Maximal delay between place and accept or decline")
+	flags.StringToIntVarP(&cfg.Policy.DeclineWeights, "policy-decline-weights", "", map[string]int{"max_bet": 5, "bet_interval": 2, "odd_status": 2, "internal_error": 1}, "Weights of restriction types of declines")
+	flags.DurationVarP(&cfg.Policy.MatchDuration, "policy-match-duration", "", 0, "Delay between accept and settlement, 0 disables auto-settlement")
+	flags.StringToIntVarP(&cfg.Policy.ResultWeights, "policy-result-weights", "", map[string]int{"WIN": 45, "LOSS": 45, "REFUNDED": 10}, "Weights of odd statuses declared on auto-settlement")
+
+	flags.StringVarP(&cfg.SettlementRules, "settlement-rules", "", "databet-default", "Settlement rule set: databet-default, asian-handicap-strict or capped-payout")
+	flags.StringVarP(&cfg.MaxPayout, "max-payout", "", "", "Payout cap of the bet, overrides the cap of the settlement rule set")
+	flags.StringVarP(&cfg.CashOutMargin, "cash-out-margin", "", "0.05", "Share of the cash-out value kept by the operator")
+
+	flags.StringSliceVarP(&cfg.SportEvents.SportIDs, "sport-ids", "", nil, "Sport IDs to place bets on")
+	flags.StringSliceVarP(&cfg.SportEvents.TournamentIDs, "tournament-ids", "", nil, "Tournament IDs to place bets on")
+	flags.StringVarP(&cfg.SportEvents.MatchState, "match-state", "", "all", "State of sport events to place bets on: all, live or prematch")
+	flags.StringVarP(&cfg.SportEvents.StartTimeFrom, "start-time-from", "", "", "Minimal start time of sport events (RFC3339)")
+	flags.StringVarP(&cfg.SportEvents.StartTimeTo, "start-time-to", "", "", "Maximal start time of sport events (RFC3339)")
+	flags.IntSliceVarP(&cfg.SportEvents.MarketTypeIDs, "market-type-ids", "", nil, "Market type IDs to place bets on")
+	flags.StringVarP(&cfg.SportEvents.MinOdd, "min-odd", "", "", "Minimal odd value to place bets on")
+	flags.StringVarP(&cfg.SportEvents.MaxOdd, "max-odd", "", "", "Maximal odd value to place bets on")
+	flags.IntVarP(&cfg.SportEvents.PageSize, "sport-events-page-size", "", 50, "Count of sport events requested from the sportsbook at once")
+	flags.DurationVarP(&cfg.SportEvents.CatalogRefreshInterval, "catalog-refresh-interval", "", time.Minute, "Refresh interval of the sport events catalog, 0 disables the catalog")
+	flags.IntVarP(&cfg.SportEvents.CatalogSize, "catalog-size", "", 500, "Maximal count of sport events in the catalog")
+
+	// flags of subcommands are unknown to the root command, they are parsed on the subcommand execution
+	cmd.FParseErrWhitelist.UnknownFlags = true
+	defer func() { cmd.FParseErrWhitelist.UnknownFlags = false }()
 
+	// help is printed on the command execution
 	err := cmd.ParseFlags(os.Args)
-	if err != nil {
+	if err != nil && !errors.Is(err, pflag.ErrHelp) {
 		panic(err)
 	}
 
@@ -4,12 +4,23 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
-	"github.com/machinebox/graphql"
+	"github.com/cockroachdb/apd/v3"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	"github.com/databet-cloud/callback-test-tool/cmd/console/config"
 	"github.com/databet-cloud/callback-test-tool/internal/betting"
+	"github.com/databet-cloud/callback-test-tool/internal/calculator"
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/clock"
+	"github.com/databet-cloud/callback-test-tool/internal/currency"
+	"github.com/databet-cloud/callback-test-tool/internal/random"
+	"github.com/databet-cloud/callback-test-tool/internal/restriction"
+	"github.com/databet-cloud/callback-test-tool/internal/risk"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
 	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
 )
 
@@ -24,18 +35,269 @@ func MustCreateBettingClient(cfg config.Configuration, logger *zap.Logger) *bett
 	return betting.NewClient(cfg.Betting.URL, httpClient, logger)
 }
 
-func MustCreateSportsBookClient(cfg config.Configuration, token string, logger *zap.Logger) *sportsbook.Client {
+func MustCreateSportsBookClient(
+	cfg config.Configuration,
+	tokens sportsbook.TokenSource,
+	logger *zap.Logger,
+) *sportsbook.Client {
 	return sportsbook.NewSportsBookClient(
-		graphql.NewClient(cfg.DataBetGQLURL, func(client *graphql.Client) {
-			client.Log = func(s string) {
-				logger.Sugar().Debug(s)
-			}
-		}),
-		token,
+		sportsbook.NewGraphQLClient(cfg.DataBetGQLURL, http.DefaultClient, logger),
+		tokens,
 		logger,
 	)
 }
 
+func MustCreateSportEventFilter(cfg config.SportEvents, logger *zap.Logger) sportsbook.Filter {
+	filter := sportsbook.DefaultFilter()
+	filter.SportIDs = cfg.SportIDs
+	filter.TournamentIDs = cfg.TournamentIDs
+	filter.MarketTypeIDs = cfg.MarketTypeIDs
+
+	switch cfg.MatchState {
+	case "", "all":
+	case "live":
+		filter.MatchStatuses = []sportsbook.MatchStatus{sportsbook.MatchStatusLive}
+	case "prematch":
+		filter.MatchStatuses = []sportsbook.MatchStatus{sportsbook.MatchStatusNotStarted}
+	default:
+		logger.Fatal("invalid match state", zap.String("match_state", cfg.MatchState))
+	}
+
+	var err error
+
+	if filter.StartTimeFrom, err = parseOptionalTime(cfg.StartTimeFrom); err != nil {
+		logger.Fatal("invalid start time from", zap.Error(err))
+	}
+
+	if filter.StartTimeTo, err = parseOptionalTime(cfg.StartTimeTo); err != nil {
+		logger.Fatal("invalid start time to", zap.Error(err))
+	}
+
+	if filter.MinOdd, err = parseOptionalDecimal(cfg.MinOdd); err != nil {
+		logger.Fatal("invalid min odd", zap.Error(err))
+	}
+
+	if filter.MaxOdd, err = parseOptionalDecimal(cfg.MaxOdd); err != nil {
+		logger.Fatal("invalid max odd", zap.Error(err))
+	}
+
+	return filter
+}
+
+func MustCreateSettlementRules(cfg config.Configuration, logger *zap.Logger) calculator.Rules {
+	rules, err := calculator.RulesByName(cfg.SettlementRules)
+	if err != nil {
+		logger.Fatal("failed to find settlement rules", zap.Error(err))
+	}
+
+	if cfg.MaxPayout != "" {
+		rules.MaxPayout = MustParseDecimal(cfg.MaxPayout, logger)
+	}
+
+	return rules
+}
+
+func MustCreateCurrencyRegistry(cfg config.Configuration, logger *zap.Logger) *currency.Registry {
+	var (
+		registry *currency.Registry
+		err      error
+	)
+
+	if cfg.Currencies.File == "" {
+		registry, err = currency.DefaultRegistry()
+	} else {
+		registry, err = currency.LoadRegistry(cfg.Currencies.File)
+	}
+
+	if err != nil {
+		logger.Fatal("failed to load currencies", zap.Error(err))
+	}
+
+	return registry
+}
+
+// MustCreateCurrency returns currency of the flag or currency of the token create request
+func MustCreateCurrency(
+	cfg config.Configuration,
+	tokenCreateReq map[string]any,
+	registry *currency.Registry,
+	logger *zap.Logger,
+) currency.Currency {
+	code := cfg.Currency
+	if code == "" {
+		code, _ = tokenCreateReq["currency"].(string)
+	}
+
+	return MustFindCurrency(registry, code, logger)
+}
+
+// MustCreateCurrencies returns currencies of the player bonuses, player currency is used by default
+func MustCreateCurrencies(
+	cfg config.Configuration,
+	registry *currency.Registry,
+	playerCurrency currency.Currency,
+	logger *zap.Logger,
+) service.Currencies {
+	currencies := service.Currencies{
+		Rates:     registry,
+		Freebet:   playerCurrency,
+		Insurance: playerCurrency,
+	}
+
+	if cfg.Currencies.Freebet != "" {
+		currencies.Freebet = MustFindCurrency(registry, cfg.Currencies.Freebet, logger)
+	}
+
+	if cfg.Currencies.Insurance != "" {
+		currencies.Insurance = MustFindCurrency(registry, cfg.Currencies.Insurance, logger)
+	}
+
+	return currencies
+}
+
+// MustCreateRestrictionCatalog returns embedded restriction templates extended by the file of the flag
+func MustCreateRestrictionCatalog(cfg config.Configuration, logger *zap.Logger) *restriction.Catalog {
+	var (
+		catalog *restriction.Catalog
+		err     error
+	)
+
+	if cfg.RestrictionsFile == "" {
+		catalog, err = restriction.DefaultCatalog()
+	} else {
+		catalog, err = restriction.LoadCatalog(cfg.RestrictionsFile)
+	}
+
+	if err != nil {
+		logger.Fatal("failed to load restrictions", zap.Error(err))
+	}
+
+	return catalog
+}
+
+// MustCreateRiskEngine returns risk engine of the flags, nil if the engine is disabled
+func MustCreateRiskEngine(cfg config.Risk, playerCurrency currency.Currency, logger *zap.Logger) *risk.Engine {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var (
+		limits = risk.Limits{BetInterval: cfg.BetInterval}
+		err    error
+	)
+
+	parseAmount := func(name, value string) *apd.Decimal {
+		if value == "" {
+			return nil
+		}
+
+		amount, err := playerCurrency.Parse(value)
+		if err != nil {
+			logger.Fatal("invalid risk limit", zap.String("limit", name), zap.Error(err))
+		}
+
+		return amount
+	}
+
+	limits.MinStake = parseAmount("min stake", cfg.MinStake)
+	limits.MaxStake = parseAmount("max stake", cfg.MaxStake)
+	limits.PlayerLimit = parseAmount("player limit", cfg.PlayerLimit)
+	limits.MaxEventPayout = parseAmount("max event payout", cfg.MaxEventPayout)
+
+	if limits.MinOdd, err = parseOptionalDecimal(cfg.MinOdd); err != nil {
+		logger.Fatal("invalid risk min odd", zap.Error(err))
+	}
+
+	if limits.MaxOdd, err = parseOptionalDecimal(cfg.MaxOdd); err != nil {
+		logger.Fatal("invalid risk max odd", zap.Error(err))
+	}
+
+	for _, name := range cfg.BetTypes {
+		betType, err := callback.ParseBetType(name)
+		if err != nil {
+			logger.Fatal("invalid risk bet type", zap.Error(err))
+		}
+
+		limits.BetTypes = append(limits.BetTypes, betType)
+	}
+
+	return risk.NewEngine(limits, playerCurrency)
+}
+
+// MustCreatePolicy returns policy of the flags, nil if the policy is disabled
+func MustCreatePolicy(cfg config.Policy, restrictions *restriction.Catalog, logger *zap.Logger) *service.Policy {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	policy := &service.Policy{
+		AcceptProbability: cfg.AcceptProbability,
+		MinDelay:          cfg.MinDelay,
+		MaxDelay:          cfg.MaxDelay,
+		DeclineWeights:    make(map[callback.RestrictionType]int, len(cfg.DeclineWeights)),
+		MatchDuration:     cfg.MatchDuration,
+		ResultWeights:     make(map[sportsbook.OddStatus]int, len(cfg.ResultWeights)),
+	}
+
+	for restrictionType, weight := range cfg.DeclineWeights {
+		policy.DeclineWeights[callback.RestrictionType(restrictionType)] = weight
+	}
+
+	for status, weight := range cfg.ResultWeights {
+		policy.ResultWeights[sportsbook.OddStatus(strings.ToUpper(status))] = weight
+	}
+
+	if err := policy.Validate(restrictions); err != nil {
+		logger.Fatal("invalid policy", zap.Error(err))
+	}
+
+	return policy
+}
+
+// MustCreateRand returns random source of the seed flag, the generated seed is logged to reproduce the session
+func MustCreateRand(cfg config.Configuration, logger *zap.Logger) *random.Rand {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = random.NewSeed()
+	}
+
+	logger.Info("random seed, pass --seed to reproduce the session", zap.Int64("seed", seed))
+
+	return random.New(seed)
+}
+
+// MustCreateClock returns the stepping clock of the fixed clock flag or the system clock
+func MustCreateClock(cfg config.Configuration, logger *zap.Logger) clock.Clock {
+	if cfg.FixedClock == "" {
+		return clock.System()
+	}
+
+	start, err := time.Parse(time.RFC3339, cfg.FixedClock)
+	if err != nil {
+		logger.Fatal("invalid fixed clock", zap.Error(err))
+	}
+
+	return clock.NewStepping(start, cfg.FixedClockStep)
+}
+
+func MustFindCurrency(registry *currency.Registry, code string, logger *zap.Logger) currency.Currency {
+	cur, err := registry.Currency(code)
+	if err != nil {
+		logger.Fatal("invalid currency", zap.Error(err))
+	}
+
+	return cur
+}
+
+func MustParseDecimal(value string, logger *zap.Logger) *apd.Decimal {
+	d, _, err := apd.NewFromString(value)
+	if err != nil {
+		logger.Fatal("invalid decimal value", zap.String("value", value), zap.Error(err))
+	}
+
+	return d
+}
+
 func MustCreateLogger(cfg config.Configuration) *zap.Logger {
 	if cfg.Debug {
 		return zap.Must(zap.NewDevelopment())
@@ -44,6 +306,16 @@ func MustCreateLogger(cfg config.Configuration) *zap.Logger {
 	return zap.Must(zap.NewProduction())
 }
 
+// CreateWriterLogger creates the logger of the console format which writes to w, e.g. to the pane of the UI
+func CreateWriterLogger(cfg config.Configuration, w zapcore.WriteSyncer) *zap.Logger {
+	level := zap.InfoLevel
+	if cfg.Debug {
+		level = zap.DebugLevel
+	}
+
+	return zap.New(zapcore.NewCore(zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig()), w, level))
+}
+
 func makeHttpClientWithTLSCertificate(cert config.Certificate) (*http.Client, error) {
 	certificate, err := tls.LoadX509KeyPair(cert.Path, cert.KeyPath)
 	if err != nil {
@@ -56,3 +328,21 @@ func makeHttpClientWithTLSCertificate(cert config.Certificate) (*http.Client, er
 
 	return client, nil
 }
+
+func parseOptionalTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+func parseOptionalDecimal(value string) (*apd.Decimal, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	d, _, err := apd.NewFromString(value)
+
+	return d, err
+}
@@ -5,6 +5,7 @@ import (
 	_ "embed"
 	"encoding/json"
 	"net/http"
+	"os"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
@@ -12,11 +13,13 @@ import (
 	"github.com/databet-cloud/callback-test-tool/cmd/console/command"
 	"github.com/databet-cloud/callback-test-tool/cmd/console/config"
 	"github.com/databet-cloud/callback-test-tool/internal/balance"
+	"github.com/databet-cloud/callback-test-tool/internal/betting"
 	"github.com/databet-cloud/callback-test-tool/internal/calculator"
 	"github.com/databet-cloud/callback-test-tool/internal/calculator/former"
 	"github.com/databet-cloud/callback-test-tool/internal/callback"
 	"github.com/databet-cloud/callback-test-tool/internal/prompt"
 	"github.com/databet-cloud/callback-test-tool/internal/service"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
 )
 
 func main() {
@@ -33,8 +36,14 @@ func main() {
 		run(ctx, cfg)
 	}
 
+	rootCmd.AddCommand(calcCommand(cfg))
+	rootCmd.AddCommand(certifyCommand(ctx, cfg))
+	rootCmd.AddCommand(serveCommand(ctx, cfg))
+	rootCmd.AddCommand(tuiCommand(ctx, cfg))
+
+	// the error is printed by cobra
 	if err := rootCmd.Execute(); err != nil {
-		panic(err)
+		os.Exit(1)
 	}
 }
 
@@ -42,11 +51,24 @@ func main() {
 var rawTokenCreateRequest []byte
 
 func run(ctx context.Context, cfg config.Configuration) {
+	log := MustCreateLogger(cfg)
+	userSv, catalog, calc := MustCreateService(ctx, cfg, log)
+
+	prompt.ProcessCommands(command.Tree(ctx, userSv, catalog, calc, cfg, log))
+}
+
+// MustCreateService authenticates the player and creates the service with the deposited balance,
+// the catalog is nil if it is disabled
+func MustCreateService(
+	ctx context.Context,
+	cfg config.Configuration,
+	log *zap.Logger,
+) (*service.Service, *sportsbook.Catalog, *calculator.Calculator) {
 	var (
 		tokenCreateReq = map[string]any{}
-		log            = MustCreateLogger(cfg)
-		playerBalance  = balance.NewService(log)
 		bettingClient  = MustCreateBettingClient(cfg, log.Named("betting"))
+		rnd            = MustCreateRand(cfg, log)
+		clk            = MustCreateClock(cfg, log)
 	)
 
 	err := json.Unmarshal(rawTokenCreateRequest, &tokenCreateReq)
@@ -54,27 +76,83 @@ func run(ctx context.Context, cfg config.Configuration) {
 		panic(err)
 	}
 
-	authToken, err := bettingClient.GetToken(ctx, tokenCreateReq)
+	var (
+		currencies     = MustCreateCurrencyRegistry(cfg, log)
+		playerCurrency = MustCreateCurrency(cfg, tokenCreateReq, currencies, log)
+	)
+
+	tokenCreateReq["currency"] = playerCurrency.Code
+
+	playerBalance := balance.NewService(playerCurrency, log)
+
+	tokens := betting.NewTokenSource(bettingClient, tokenCreateReq, cfg.Betting.TokenTTL, log.Named("betting"))
+
+	authToken, err := tokens.Token(ctx)
 	if err != nil {
 		log.Fatal("failed to get auth token", zap.Error(err))
 	}
 
-	log.Info("authenticated", zap.String("token", authToken))
+	log.Info("authenticated", zap.String("token", authToken.Value), zap.Time("expires_at", authToken.ExpiresAt))
+
+	var (
+		sportsBookClient = MustCreateSportsBookClient(cfg, tokens, log.Named("sports_book"))
+		sportEventFilter = MustCreateSportEventFilter(cfg.SportEvents, log)
+		sportEvents      service.SportEventSource
+		catalog          *sportsbook.Catalog
+	)
+
+	if cfg.SportEvents.CatalogRefreshInterval > 0 {
+		catalog = sportsbook.NewCatalog(
+			sportsBookClient,
+			sportEventFilter,
+			cfg.SportEvents.PageSize,
+			cfg.SportEvents.CatalogSize,
+			rnd.Fork(),
+			log.Named("catalog"),
+		)
+
+		if err := catalog.Refresh(ctx); err != nil {
+			log.Warn("failed to prefetch sport events catalog", zap.Error(err))
+		}
+
+		go catalog.Run(ctx, cfg.SportEvents.CatalogRefreshInterval)
+
+		sportEvents = catalog
+	} else {
+		sportEvents = sportsbook.NewFeed(sportsBookClient, sportEventFilter, cfg.SportEvents.PageSize)
+	}
+
+	var (
+		rules      = MustCreateSettlementRules(cfg, log)
+		refundCalc = calculator.NewRefundCalcWithRules(log, former.EachExpressIndexes, rules).WithWorkers(0)
+		calc       = calculator.NewCalculator(refundCalc, log)
+	)
+
+	restrictions := MustCreateRestrictionCatalog(cfg, log)
 
 	userSv := service.NewService(
 		tokenCreateReq["player_id"].(string),
 		playerBalance,
-		MustCreateSportsBookClient(cfg, authToken, log.Named("sports_book")),
+		tokens,
+		sportEvents,
+		sportsBookClient,
 		callback.NewClient(cfg.CallbackServerURL, extractForeignParams(tokenCreateReq), http.DefaultClient, log),
-		calculator.NewCalculator(calculator.NewRefundCalc(log, former.FormExpresses), log),
+		calc,
+		calculator.NewCashOutCalc(refundCalc, MustParseDecimal(cfg.CashOutMargin, log), log),
+		MustCreateCurrencies(cfg, currencies, playerCurrency, log),
+		restrictions,
+		MustCreateRiskEngine(cfg.Risk, playerCurrency, log),
+		MustCreatePolicy(cfg.Policy, restrictions, log),
+		rnd,
+		clk,
 		log,
 	)
 
-	if err := playerBalance.DepositFloat(cfg.Balance); err != nil {
-		log.Fatal("failed to deposit user balance", zap.Float64("amount", cfg.Balance), zap.Error(err))
+	if err := playerBalance.DepositString(cfg.Balance); err != nil {
+		log.Fatal("failed to deposit user balance", zap.String("amount", cfg.Balance), zap.Error(err))
 	}
 
-	prompt.ProcessCommands(command.Tree(ctx, userSv, cfg, log))
+	return userSv, catalog, calc
 }
 
 func extractForeignParams(tokenCreateReq map[string]any) map[string]any {
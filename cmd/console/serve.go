@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/cmd/console/config"
+	"github.com/databet-cloud/callback-test-tool/internal/api"
+	"github.com/databet-cloud/callback-test-tool/internal/dashboard"
+)
+
+// readHeaderTimeout - timeout of headers of API requests, bodies are small and read by handlers
+const readHeaderTimeout = 10 * time.Second
+
+func serveCommand(ctx context.Context, cfg config.Configuration) *cobra.Command {
+	var listen string
+
+	cmd := &cobra.Command{
+		Use:     "serve",
+		Short:   "Serve the REST API and the web dashboard which drive the session instead of the console",
+		Example: "callback-test-tool serve --callback-url http://localhost:8080 --listen 127.0.0.1:8090",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log := MustCreateLogger(cfg)
+			sv, _, _ := MustCreateService(ctx, cfg, log)
+
+			mux := http.NewServeMux()
+			mux.Handle(dashboard.Prefix, dashboard.Handler())
+			mux.Handle("/", api.NewServer(sv, log.Named("api")))
+
+			server := &http.Server{
+				Addr:              listen,
+				Handler:           mux,
+				ReadHeaderTimeout: readHeaderTimeout,
+			}
+
+			log.Info(
+				"serving API",
+				zap.String("listen", listen),
+				zap.String("dashboard", "http://"+listen+dashboard.Prefix),
+				zap.String("player_id", sv.PlayerID()),
+			)
+
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("failed to serve API: %w", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&listen, "listen", "", "127.0.0.1:8090", "Address of the API")
+
+	return cmd
+}
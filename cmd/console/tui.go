@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/databet-cloud/callback-test-tool/cmd/console/config"
+	"github.com/databet-cloud/callback-test-tool/internal/tui"
+)
+
+func tuiCommand(ctx context.Context, cfg config.Configuration) *cobra.Command {
+	var stake string
+
+	cmd := &cobra.Command{
+		Use:     "tui",
+		Short:   "Run the full-screen terminal UI with live panes of bets, the balance and callbacks",
+		Example: "callback-test-tool tui --callback-url http://localhost:8080 --stake 5",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// logs are written to the pane of the UI while the screen is shown, errors of the startup
+			// are written to the terminal
+			log := tui.NewLog(tui.LogLimit, os.Stderr)
+			sv, _, _ := MustCreateService(ctx, cfg, CreateWriterLogger(cfg, log))
+
+			if _, err := sv.PlayerCurrency().Parse(stake); err != nil {
+				return fmt.Errorf("invalid stake: %w", err)
+			}
+
+			screen, err := tcell.NewScreen()
+			if err != nil {
+				return fmt.Errorf("failed to create screen: %w", err)
+			}
+
+			return tui.New(sv, log, screen, stake).Run(ctx)
+		},
+	}
+
+	cmd.Flags().StringVarP(&stake, "stake", "", "10", "Initial stake of placed bets in the currency of the player")
+
+	return cmd
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// feedInterval - interval of checks for new exchanges of the feed
+const feedInterval = 500 * time.Millisecond
+
+// feed streams sent callbacks as server-sent events in the order of sending. The ID of the event is the count
+// of streamed exchanges, the stream continues after the Last-Event-ID header of reconnected clients
+// or after the query parameter from, all exchanges are streamed without them. Cursors which are not numbers
+// are rejected, cursors out of the range of sent exchanges are clamped to it.
+func (s *Server) feed(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	from := r.Header.Get("Last-Event-ID")
+	if from == "" {
+		from = r.URL.Query().Get("from")
+	}
+
+	sent, err := feedCursor(from)
+	if err != nil {
+		s.write(w, r, http.StatusBadRequest, Error{Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(feedInterval)
+	defer ticker.Stop()
+
+	for {
+		// exchanges are read between actions, so expected balances of the last exchange are recorded
+		s.mu.Lock()
+		exchanges := s.sv.Exchanges()
+		s.mu.Unlock()
+
+		// the cursor of another session can be ahead of exchanges of this one
+		sent = min(sent, len(exchanges))
+
+		for ; sent < len(exchanges); sent++ {
+			data, err := json.Marshal(newExchange(exchanges[sent]))
+			if err != nil {
+				s.log.Error("failed to marshal exchange", zap.Error(err))
+				return
+			}
+
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: exchange\ndata: %s\n\n", sent+1, data); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// feedCursor parses the count of streamed exchanges, negative counts stream all exchanges
+func feedCursor(from string) (int, error) {
+	if from == "" {
+		return 0, nil
+	}
+
+	sent, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid cursor %q", errBadRequest, from)
+	}
+
+	return max(0, sent), nil
+}
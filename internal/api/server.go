@@ -0,0 +1,604 @@
+// Package api exposes actions and state of the service by the REST API with JSON bodies,
+// so other tools can drive the session without the console
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/calculator"
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/report"
+	"github.com/databet-cloud/callback-test-tool/internal/restriction"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
+	"github.com/databet-cloud/callback-test-tool/internal/storage"
+)
+
+var (
+	errBadRequest           = errors.New("bad request")
+	errNotFound             = errors.New("not found")
+	errForbidden            = errors.New("forbidden")
+	errUnsupportedMediaType = errors.New("unsupported media type")
+)
+
+// oddStatuses - statuses which can be declared on settlement, not resulted odds block the settlement
+var oddStatuses = []sportsbook.OddStatus{
+	sportsbook.OddStatusNotResulted,
+	sportsbook.OddStatusWin,
+	sportsbook.OddStatusLoss,
+	sportsbook.OddStatusHalfWin,
+	sportsbook.OddStatusHalfLoss,
+	sportsbook.OddStatusRefunded,
+	sportsbook.OddStatusCancelled,
+}
+
+// Server - handler of the API, actions answer with the state after the action
+type Server struct {
+	sv  *service.Service
+	mux *http.ServeMux
+	log *zap.Logger
+	// mu - the service is driven by one client at a time like in the console, so requests are serialized
+	mu sync.Mutex
+}
+
+func NewServer(sv *service.Service, log *zap.Logger) *Server {
+	s := &Server{sv: sv, mux: http.NewServeMux(), log: log}
+
+	s.handle("GET /player", s.player)
+	s.handle("POST /player/token", s.refreshToken)
+	s.handle("GET /restrictions", s.restrictions)
+	s.handle("GET /bets", s.bets)
+	s.handle("POST /bets", s.placeBet)
+	s.handle("GET /bets/{id}", s.bet)
+	s.handle("GET /bets/{id}/timeline", s.timeline)
+	s.handle("POST /bets/{id}/accept", s.acceptBet)
+	s.handle("POST /bets/{id}/decline", s.declineBet)
+	s.handle("POST /bets/{id}/settle", s.settleBet)
+	s.handle("POST /bets/{id}/unsettle", s.unSettleBet)
+	s.handle("GET /bets/{id}/cash-outs", s.betCashOuts)
+	s.handle("POST /bets/{id}/cash-outs", s.createCashOut)
+	s.handle("POST /bets/{id}/cash-outs/decline", s.declineCashOuts)
+	s.handle("GET /cash-outs", s.cashOuts)
+	s.handle("POST /cash-outs/{id}/accept", s.acceptCashOut)
+	s.handle("GET /requests", s.requests)
+	s.handle("POST /requests/{id}/replay", s.replay)
+	s.handle("GET /selections", s.selections)
+	s.handle("POST /selections/resolve", s.resolve)
+	s.handle("GET /exchanges", s.exchanges)
+	s.mux.HandleFunc("GET /exchanges/feed", s.feed)
+
+	return s
+}
+
+// ServeHTTP rejects cross-origin requests and POST requests without JSON bodies,
+// so pages of other sites can not drive the session through the browser of the user
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := checkRequest(r); err != nil {
+		s.write(w, r, statusOf(err), Error{Error: err.Error()})
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// checkRequest allows requests without the Origin header, browsers send it on cross-origin requests
+func checkRequest(r *http.Request) error {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != r.Host {
+			return fmt.Errorf("%w: cross-origin request from %s", errForbidden, origin)
+		}
+	}
+
+	if r.Method == http.MethodPost {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			return fmt.Errorf("%w: content type of POST requests must be application/json", errUnsupportedMediaType)
+		}
+	}
+
+	return nil
+}
+
+// handlerFunc returns the status code and the body of the successful response
+type handlerFunc func(r *http.Request) (int, any, error)
+
+func (s *Server) handle(pattern string, h handlerFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		status, body, err := h(r)
+		s.mu.Unlock()
+		if err != nil {
+			status, body = statusOf(err), Error{Error: err.Error()}
+		}
+
+		s.write(w, r, status, body)
+	})
+}
+
+// write answers with the JSON body
+func (s *Server) write(w http.ResponseWriter, r *http.Request, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.log.Error("failed to write response", zap.String("path", r.URL.Path), zap.Error(err))
+	}
+}
+
+// statusOf maps errors of actions to status codes, failed callbacks are reported as the bad gateway
+func statusOf(err error) int {
+	var statusErr *callback.StatusError
+
+	switch {
+	case errors.Is(err, errBadRequest), errors.Is(err, service.ErrInvalidBetType),
+		errors.Is(err, service.ErrInvalidAmount):
+		return http.StatusBadRequest
+	case errors.Is(err, errForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, errUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, errNotFound), errors.Is(err, service.ErrBetNotFound),
+		errors.Is(err, service.ErrCashOutOrderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrBetSettled), errors.Is(err, calculator.ErrNotResulted):
+		return http.StatusConflict
+	case errors.As(err, &statusErr):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func decode(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return fmt.Errorf("%w: invalid body: %w", errBadRequest, err)
+	}
+
+	return nil
+}
+
+func (s *Server) player(_ *http.Request) (int, any, error) {
+	return http.StatusOK, Player{
+		ID:             s.sv.PlayerID(),
+		Currency:       s.sv.PlayerCurrency().Code,
+		Balance:        s.sv.PlayerBalance(),
+		TokenExpiresAt: s.sv.PlayerToken().ExpiresAt,
+	}, nil
+}
+
+func (s *Server) refreshToken(r *http.Request) (int, any, error) {
+	if _, err := s.sv.RefreshPlayerToken(r.Context()); err != nil {
+		return 0, nil, err
+	}
+
+	return s.player(r)
+}
+
+// restrictions returns restriction types of the catalog with names of their templates
+func (s *Server) restrictions(_ *http.Request) (int, any, error) {
+	catalog := s.sv.Restrictions()
+
+	return http.StatusOK, convert(catalog.Types(), func(t callback.RestrictionType) Restriction {
+		return Restriction{Type: t, Variants: convert(catalog.Variants(t), func(tmpl *restriction.Template) string {
+			return tmpl.Name
+		})}
+	}), nil
+}
+
+// bets returns bets in states of the query parameter state, all bets without the parameter
+func (s *Server) bets(r *http.Request) (int, any, error) {
+	states := convert(r.URL.Query()["state"], func(v string) callback.RequestType { return callback.RequestType(v) })
+	cur := s.sv.PlayerCurrency()
+
+	return http.StatusOK, convert(s.sv.Bets(states...), func(d *storage.Document[*callback.Data]) Bet {
+		return newBet(d, cur)
+	}), nil
+}
+
+func (s *Server) bet(r *http.Request) (int, any, error) {
+	return s.betState(r.PathValue("id"), http.StatusOK)
+}
+
+// betState answers with the current state of the bet
+func (s *Server) betState(betID string, status int) (int, any, error) {
+	doc, err := s.findBet(betID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return status, newBet(doc, s.sv.PlayerCurrency()), nil
+}
+
+func (s *Server) findBet(betID string) (*storage.Document[*callback.Data], error) {
+	bets := s.sv.Bets()
+
+	i := slices.IndexFunc(bets, func(d *storage.Document[*callback.Data]) bool { return d.Value.BetID == betID })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", service.ErrBetNotFound, betID)
+	}
+
+	return bets[i], nil
+}
+
+type placeBetRequest struct {
+	BetType string `json:"bet_type"`
+	Stake   string `json:"stake"`
+}
+
+func (s *Server) placeBet(r *http.Request) (int, any, error) {
+	var req placeBetRequest
+	if err := decode(r, &req); err != nil {
+		return 0, nil, err
+	}
+
+	betType, err := callback.ParseBetType(req.BetType)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %w", errBadRequest, err)
+	}
+
+	stake, err := s.sv.PlayerCurrency().Parse(req.Stake)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: invalid stake: %w", errBadRequest, err)
+	}
+
+	bet, err := s.sv.PlaceBet(r.Context(), betType, stake)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return s.betState(bet.BetID, http.StatusCreated)
+}
+
+func (s *Server) acceptBet(r *http.Request) (int, any, error) {
+	if err := s.sv.AcceptBet(r.Context(), r.PathValue("id")); err != nil {
+		return 0, nil, err
+	}
+
+	return s.betState(r.PathValue("id"), http.StatusOK)
+}
+
+type declineRequest struct {
+	Restrictions []restrictionRequest `json:"restrictions"`
+}
+
+// restrictionRequest - restriction rendered from the template of the catalog for the leg of the bet
+type restrictionRequest struct {
+	Type callback.RestrictionType `json:"type"`
+	// Variant - name of the template, the default template is used if empty
+	Variant string `json:"variant,omitempty"`
+	// Leg - index of the restricted odd of the bet
+	Leg int `json:"leg"`
+	// Context - values which replace rendered values of the template
+	Context map[string]any `json:"context,omitempty"`
+}
+
+func (s *Server) declineBet(r *http.Request) (int, any, error) {
+	betID := r.PathValue("id")
+
+	var req declineRequest
+	if err := decode(r, &req); err != nil {
+		return 0, nil, err
+	}
+
+	if len(req.Restrictions) == 0 {
+		return 0, nil, fmt.Errorf("%w: restrictions are required", errBadRequest)
+	}
+
+	bet, err := s.findBet(betID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	odds := bet.Value.PrivateOdds
+	restrictions := make([]callback.Restriction, len(req.Restrictions))
+
+	for i, rr := range req.Restrictions {
+		restrictions[i], err = s.draftRestriction(betID, odds, rr)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if err := s.sv.DeclineBet(r.Context(), betID, restrictions); err != nil {
+		return 0, nil, err
+	}
+
+	return s.betState(betID, http.StatusOK)
+}
+
+func (s *Server) draftRestriction(betID string, odds []*callback.Odd, rr restrictionRequest) (callback.Restriction, error) {
+	if rr.Leg < 0 || rr.Leg >= len(odds) {
+		return callback.Restriction{}, fmt.Errorf("%w: leg %d is out of %d odds", errBadRequest, rr.Leg, len(odds))
+	}
+
+	variants := s.sv.Restrictions().Variants(rr.Type)
+	if len(variants) == 0 {
+		return callback.Restriction{}, fmt.Errorf("%w: unknown restriction %s", errBadRequest, rr.Type)
+	}
+
+	tmpl, ok := s.sv.Restrictions().Default(rr.Type)
+
+	if rr.Variant != "" {
+		i := slices.IndexFunc(variants, func(t *restriction.Template) bool { return t.Name == rr.Variant })
+		ok = i >= 0
+
+		if ok {
+			tmpl = variants[i]
+		}
+	}
+
+	if !ok {
+		return callback.Restriction{}, fmt.Errorf("%w: unknown variant %q of the restriction %s", errBadRequest, rr.Variant, rr.Type)
+	}
+
+	draft, err := s.sv.DraftRestriction(betID, tmpl, odds[rr.Leg])
+	if err != nil {
+		return draft, err
+	}
+
+	for key, value := range rr.Context {
+		draft.Context[key] = value
+	}
+
+	return draft, nil
+}
+
+// settleRequest - statuses of odds of the bet in order, the single status is declared for all odds
+type settleRequest struct {
+	Statuses []sportsbook.OddStatus `json:"statuses"`
+}
+
+func (s *Server) settleBet(r *http.Request) (int, any, error) {
+	betID := r.PathValue("id")
+
+	var req settleRequest
+	if err := decode(r, &req); err != nil {
+		return 0, nil, err
+	}
+
+	bet, err := s.findBet(betID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	legs := bet.Value.PrivateOdds
+	if len(req.Statuses) != 1 && len(req.Statuses) != len(legs) {
+		return 0, nil, fmt.Errorf("%w: %d statuses for %d odds", errBadRequest, len(req.Statuses), len(legs))
+	}
+
+	odds := make([]*callback.Odd, len(legs))
+
+	for i, odd := range legs {
+		status := req.Statuses[min(i, len(req.Statuses)-1)]
+		if !slices.Contains(oddStatuses, status) {
+			return 0, nil, fmt.Errorf("%w: unknown odd status %q", errBadRequest, status)
+		}
+
+		odds[i] = odd.WithStatus(status, s.sv.Now())
+	}
+
+	if err := s.sv.SettleBet(r.Context(), betID, odds); err != nil {
+		return 0, nil, err
+	}
+
+	return s.betState(betID, http.StatusOK)
+}
+
+// timeline returns callbacks of the bet with expected balances and violations of its lifecycle
+func (s *Server) timeline(r *http.Request) (int, any, error) {
+	betID := r.PathValue("id")
+
+	if _, err := s.findBet(betID); err != nil {
+		return 0, nil, err
+	}
+
+	rep, err := report.Build(s.sv, time.Now().UTC())
+	if err != nil {
+		return 0, nil, err
+	}
+
+	i := slices.IndexFunc(rep.Lifecycles, func(l *report.Lifecycle) bool { return l.BetID == betID })
+	if i < 0 {
+		return 0, nil, fmt.Errorf("%w: no callbacks of the bet %s", errNotFound, betID)
+	}
+
+	return http.StatusOK, rep.Lifecycles[i], nil
+}
+
+func (s *Server) unSettleBet(r *http.Request) (int, any, error) {
+	if err := s.sv.UnSettleBet(r.Context(), r.PathValue("id")); err != nil {
+		return 0, nil, err
+	}
+
+	return s.betState(r.PathValue("id"), http.StatusOK)
+}
+
+func (s *Server) betCashOuts(r *http.Request) (int, any, error) {
+	return s.cashOutOrders(r.PathValue("id"), r)
+}
+
+func (s *Server) cashOuts(r *http.Request) (int, any, error) {
+	return s.cashOutOrders(r.URL.Query().Get("bet_id"), r)
+}
+
+// cashOutOrders returns orders of the bet in states of the query parameter state
+func (s *Server) cashOutOrders(betID string, r *http.Request) (int, any, error) {
+	states := convert(r.URL.Query()["state"], func(v string) service.CashOutOrderState { return service.CashOutOrderState(v) })
+
+	return http.StatusOK, convert(s.sv.CashOutOrders(betID, states...), newCashOutOrder), nil
+}
+
+type createCashOutRequest struct {
+	// Fraction - fraction of the stake which is not cashed out yet
+	Fraction float64 `json:"fraction"`
+}
+
+func (s *Server) createCashOut(r *http.Request) (int, any, error) {
+	var req createCashOutRequest
+	if err := decode(r, &req); err != nil {
+		return 0, nil, err
+	}
+
+	if req.Fraction <= 0 || req.Fraction > 1 {
+		return 0, nil, fmt.Errorf("%w: fraction %v is out of (0, 1]", errBadRequest, req.Fraction)
+	}
+
+	order, err := s.sv.CreateCashOutOrder(r.Context(), r.PathValue("id"), req.Fraction)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return s.cashOutOrder(order.ID, http.StatusCreated)
+}
+
+func (s *Server) acceptCashOut(r *http.Request) (int, any, error) {
+	if err := s.sv.AcceptCashOutOrder(r.Context(), r.PathValue("id")); err != nil {
+		return 0, nil, err
+	}
+
+	return s.cashOutOrder(r.PathValue("id"), http.StatusOK)
+}
+
+type declineCashOutsRequest struct {
+	OrderIDs []string `json:"order_ids"`
+}
+
+func (s *Server) declineCashOuts(r *http.Request) (int, any, error) {
+	betID := r.PathValue("id")
+
+	var req declineCashOutsRequest
+	if err := decode(r, &req); err != nil {
+		return 0, nil, err
+	}
+
+	if len(req.OrderIDs) == 0 {
+		return 0, nil, fmt.Errorf("%w: order_ids are required", errBadRequest)
+	}
+
+	if err := s.sv.DeclineCashOutOrders(r.Context(), betID, req.OrderIDs); err != nil {
+		return 0, nil, err
+	}
+
+	orders := s.sv.CashOutOrders(betID)
+	orders = slices.DeleteFunc(orders, func(d *storage.Document[*service.CashOutOrder]) bool {
+		return !slices.Contains(req.OrderIDs, d.Value.ID)
+	})
+
+	return http.StatusOK, convert(orders, newCashOutOrder), nil
+}
+
+func (s *Server) cashOutOrder(orderID string, status int) (int, any, error) {
+	orders := s.sv.CashOutOrders("")
+
+	i := slices.IndexFunc(orders, func(d *storage.Document[*service.CashOutOrder]) bool { return d.Value.ID == orderID })
+	if i < 0 {
+		return 0, nil, fmt.Errorf("%w: %s", service.ErrCashOutOrderNotFound, orderID)
+	}
+
+	return status, newCashOutOrder(orders[i]), nil
+}
+
+// requests returns sent requests of types of the query parameter type
+func (s *Server) requests(r *http.Request) (int, any, error) {
+	types := convert(r.URL.Query()["type"], func(v string) callback.RequestType { return callback.RequestType(v) })
+
+	return http.StatusOK, convert(s.sv.SentRequests(types...), newRequest), nil
+}
+
+// replay sends the request again and answers with the exchange of the replay, the replay is the last exchange
+// of the request
+func (s *Server) replay(r *http.Request) (int, any, error) {
+	requestID := r.PathValue("id")
+	requests := s.sv.SentRequests()
+
+	i := slices.IndexFunc(requests, func(d *storage.Document[*callback.Data]) bool { return d.Value.RequestID == requestID })
+	if i < 0 {
+		return 0, nil, fmt.Errorf("%w: request %s is not found", errNotFound, requestID)
+	}
+
+	if err := s.sv.ReplayCallback(r.Context(), requests[i].Value); err != nil {
+		return 0, nil, err
+	}
+
+	exchanges := s.sv.Exchanges()
+	slices.Reverse(exchanges)
+
+	j := slices.IndexFunc(exchanges, func(d *storage.Document[*service.Exchange]) bool {
+		return d.Value.Request.RequestID == requestID
+	})
+
+	return http.StatusOK, newExchange(exchanges[j]), nil
+}
+
+// exchanges returns sent callbacks of the bet of the query parameter bet_id, all callbacks without the parameter
+func (s *Server) exchanges(r *http.Request) (int, any, error) {
+	exchanges := s.sv.Exchanges()
+
+	if betID := r.URL.Query().Get("bet_id"); betID != "" {
+		exchanges = slices.DeleteFunc(exchanges, func(d *storage.Document[*service.Exchange]) bool {
+			return d.Value.Request.BetID != betID
+		})
+	}
+
+	return http.StatusOK, convert(exchanges, newExchange), nil
+}
+
+func (s *Server) selections(_ *http.Request) (int, any, error) {
+	return http.StatusOK, convert(s.sv.OpenSelections(), newLeg), nil
+}
+
+type resolveRequest struct {
+	Results []resultRequest `json:"results"`
+}
+
+// resultRequest - declared status of the odd, the odd is identified like legs of bets
+type resultRequest struct {
+	SportEventID string               `json:"sport_event_id"`
+	MarketID     string               `json:"market_id"`
+	OddID        string               `json:"odd_id"`
+	Status       sportsbook.OddStatus `json:"status"`
+}
+
+// resolve declares results of odds, bets with all legs resolved are settled, it answers with open selections
+func (s *Server) resolve(r *http.Request) (int, any, error) {
+	var req resolveRequest
+	if err := decode(r, &req); err != nil {
+		return 0, nil, err
+	}
+
+	if len(req.Results) == 0 {
+		return 0, nil, fmt.Errorf("%w: results are required", errBadRequest)
+	}
+
+	results := make([]*service.OddResult, len(req.Results))
+
+	for i, result := range req.Results {
+		if !slices.Contains(oddStatuses, result.Status) || result.Status == sportsbook.OddStatusNotResulted {
+			return 0, nil, fmt.Errorf("%w: unknown odd status %q", errBadRequest, result.Status)
+		}
+
+		results[i] = &service.OddResult{
+			MatchID:  result.SportEventID,
+			MarketID: result.MarketID,
+			OddID:    result.OddID,
+			Status:   result.Status,
+		}
+	}
+
+	if err := s.sv.ResolveSportEvent(r.Context(), results); err != nil {
+		return 0, nil, err
+	}
+
+	return s.selections(r)
+}
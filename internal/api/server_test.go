@@ -0,0 +1,342 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/report"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+	"github.com/databet-cloud/callback-test-tool/internal/servicetest"
+)
+
+func TestServer_Lifecycle(t *testing.T) {
+	api := newTestAPI(t, servicetest.NewCallbackServer(t))
+
+	var bet Bet
+	api.do(http.MethodPost, "/bets", `{"bet_type": "express", "stake": "10"}`, http.StatusCreated, &bet)
+	assert.Equal(t, callback.BetPlaceRequestType, bet.State)
+	assert.Equal(t, "express", bet.Type)
+	assert.Equal(t, "10.00", bet.Stake)
+
+	api.do(http.MethodPost, "/bets/"+bet.ID+"/accept", "", http.StatusOK, &bet)
+	assert.Equal(t, callback.BetAcceptRequestType, bet.State)
+
+	var order CashOutOrder
+	api.do(http.MethodPost, "/bets/"+bet.ID+"/cash-outs", `{"fraction": 0.5}`, http.StatusCreated, &order)
+	assert.Equal(t, service.CashOutOrderCreated, order.State)
+
+	api.do(http.MethodPost, "/cash-outs/"+order.ID+"/accept", "", http.StatusOK, &order)
+	assert.Equal(t, service.CashOutOrderAccepted, order.State)
+
+	api.do(http.MethodPost, "/bets/"+bet.ID+"/settle", `{"statuses": ["WIN"]}`, http.StatusOK, &bet)
+	assert.Equal(t, callback.BetSettleRequestType, bet.State)
+	assert.Equal(t, callback.WinSettleType, decodeRequest(t, bet).SettleType)
+
+	api.do(http.MethodPost, "/bets/"+bet.ID+"/unsettle", "", http.StatusOK, &bet)
+	assert.Equal(t, callback.BetUnSettleRequestType, bet.State)
+
+	requestID := decodeRequest(t, bet).RequestID
+
+	var exchange Exchange
+	api.do(http.MethodPost, "/requests/"+requestID+"/replay", "", http.StatusOK, &exchange)
+	assert.Equal(t, requestID, exchange.RequestID)
+	assert.True(t, exchange.Succeeded)
+
+	var exchanges []Exchange
+	api.do(http.MethodGet, "/exchanges", "", http.StatusOK, &exchanges)
+	assert.Len(t, exchanges, 6)
+
+	var timeline report.Lifecycle
+	api.do(http.MethodGet, "/bets/"+bet.ID+"/timeline", "", http.StatusOK, &timeline)
+	assert.Equal(t, bet.ID, timeline.BetID)
+	assert.Len(t, timeline.Steps, 6)
+
+	var bets []Bet
+	api.do(http.MethodGet, "/bets?state=unsettle", "", http.StatusOK, &bets)
+	assert.Len(t, bets, 1)
+
+	var player Player
+	api.do(http.MethodGet, "/player", "", http.StatusOK, &player)
+	assert.Equal(t, "player-1", player.ID)
+	assert.Equal(t, "EUR", player.Currency)
+}
+
+func TestServer_DeclineBet(t *testing.T) {
+	api := newTestAPI(t, servicetest.NewCallbackServer(t))
+
+	var bet Bet
+	api.do(http.MethodPost, "/bets", `{"bet_type": "single", "stake": "10"}`, http.StatusCreated, &bet)
+
+	body := `{"restrictions": [{"type": "max_bet", "leg": 0, "context": {"max_bet": "5.00"}}]}`
+	api.do(http.MethodPost, "/bets/"+bet.ID+"/decline", body, http.StatusOK, &bet)
+
+	assert.Equal(t, callback.BetDeclineRequestType, bet.State)
+
+	restrictions := decodeRequest(t, bet).Restrictions
+	require.Len(t, restrictions, 1)
+	assert.Equal(t, callback.MaxBetRestriction, restrictions[0].Type)
+	assert.Equal(t, "5.00", restrictions[0].Context["max_bet"])
+}
+
+func TestServer_Errors(t *testing.T) {
+	callbackURL := servicetest.NewCallbackServerFunc(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bet/accept" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("maintenance"))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+	api := newTestAPI(t, callbackURL)
+
+	var bet Bet
+	api.do(http.MethodPost, "/bets", `{"bet_type": "single", "stake": "10"}`, http.StatusCreated, &bet)
+
+	var apiErr Error
+	api.do(http.MethodPost, "/bets/"+bet.ID+"/accept", "", http.StatusBadGateway, &apiErr)
+	assert.Contains(t, apiErr.Error, "unknown status code 500")
+
+	var exchanges []Exchange
+	api.do(http.MethodGet, "/exchanges?bet_id="+bet.ID, "", http.StatusOK, &exchanges)
+	require.Len(t, exchanges, 2)
+	assert.Equal(t, http.StatusInternalServerError, exchanges[1].StatusCode)
+	assert.Equal(t, "maintenance", exchanges[1].ResponseBody)
+
+	api.do(http.MethodPost, "/bets", `{"bet_type": "parlay", "stake": "10"}`, http.StatusBadRequest, &apiErr)
+	api.do(http.MethodGet, "/bets/unknown", "", http.StatusNotFound, &apiErr)
+	api.do(http.MethodPost, "/cash-outs/unknown/accept", "", http.StatusNotFound, &apiErr)
+	api.do(http.MethodPost, "/bets/"+bet.ID+"/settle", `{"statuses": ["WIN", "LOSS"]}`, http.StatusBadRequest, &apiErr)
+}
+
+func TestServer_Resolve(t *testing.T) {
+	api := newTestAPI(t, servicetest.NewCallbackServer(t))
+
+	var bet Bet
+	api.do(http.MethodPost, "/bets", `{"bet_type": "single", "stake": "10"}`, http.StatusCreated, &bet)
+	api.do(http.MethodPost, "/bets/"+bet.ID+"/accept", "", http.StatusOK, &bet)
+
+	var selections []Leg
+	api.do(http.MethodGet, "/selections", "", http.StatusOK, &selections)
+	require.Len(t, selections, 1)
+
+	leg := selections[0]
+	body := fmt.Sprintf(
+		`{"results": [{"sport_event_id": %q, "market_id": %q, "odd_id": %q, "status": "WIN"}]}`,
+		leg.SportEventID, leg.MarketID, leg.OddID,
+	)
+	api.do(http.MethodPost, "/selections/resolve", body, http.StatusOK, &selections)
+	assert.Empty(t, selections)
+
+	api.do(http.MethodGet, "/bets/"+bet.ID, "", http.StatusOK, &bet)
+	assert.Equal(t, callback.BetSettleRequestType, bet.State)
+	assert.Equal(t, "WIN", bet.Legs[0].Status)
+}
+
+func TestServer_ResolveFailed(t *testing.T) {
+	callbackURL := servicetest.NewCallbackServerFunc(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bet/settle" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+	api := newTestAPI(t, callbackURL)
+
+	var bet Bet
+	api.do(http.MethodPost, "/bets", `{"bet_type": "single", "stake": "10"}`, http.StatusCreated, &bet)
+	api.do(http.MethodPost, "/bets/"+bet.ID+"/accept", "", http.StatusOK, &bet)
+
+	leg := bet.Legs[0]
+	body := fmt.Sprintf(
+		`{"results": [{"sport_event_id": %q, "market_id": %q, "odd_id": %q, "status": "LOSS"}]}`,
+		leg.SportEventID, leg.MarketID, leg.OddID,
+	)
+
+	var apiErr Error
+	api.do(http.MethodPost, "/selections/resolve", body, http.StatusBadGateway, &apiErr)
+	assert.Contains(t, apiErr.Error, "settle bet "+bet.ID)
+
+	api.do(http.MethodGet, "/bets/"+bet.ID, "", http.StatusOK, &bet)
+	assert.Equal(t, callback.BetAcceptRequestType, bet.State)
+}
+
+// TestServer_PolicyAnswer places the bet through the HTTP server, the policy answers it
+// after the request is finished and its context is canceled
+func TestServer_PolicyAnswer(t *testing.T) {
+	policy := &service.Policy{AcceptProbability: 1, MinDelay: 10 * time.Millisecond, MaxDelay: 20 * time.Millisecond}
+	sv := servicetest.NewPolicyService(t, servicetest.NewCallbackServer(t), policy)
+
+	server := httptest.NewServer(NewServer(sv, zap.NewNop()))
+	t.Cleanup(server.Close)
+
+	response, err := http.Post(server.URL+"/bets", "application/json", strings.NewReader(`{"bet_type": "single", "stake": "10"}`))
+	require.NoError(t, err)
+
+	var bet Bet
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&bet))
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+
+	require.Eventually(t, func() bool {
+		return len(sv.Bets(callback.BetAcceptRequestType)) == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestServer_Feed(t *testing.T) {
+	api := newTestAPI(t, servicetest.NewCallbackServer(t))
+
+	var bet Bet
+	api.do(http.MethodPost, "/bets", `{"bet_type": "single", "stake": "10"}`, http.StatusCreated, &bet)
+	api.do(http.MethodPost, "/bets/"+bet.ID+"/accept", "", http.StatusOK, &bet)
+
+	server := httptest.NewServer(api.handler)
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/exchanges/feed?from=1", nil)
+	require.NoError(t, err)
+
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+
+	defer response.Body.Close()
+
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	lines := bufio.NewScanner(response.Body)
+	event := make([]string, 0, 3)
+
+	for len(event) < 3 && lines.Scan() {
+		event = append(event, lines.Text())
+	}
+
+	require.Len(t, event, 3)
+	assert.Equal(t, "id: 2", event[0])
+	assert.Equal(t, "event: exchange", event[1])
+
+	var exchange Exchange
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(event[2], "data: ")), &exchange))
+	assert.Equal(t, callback.BetAcceptRequestType, exchange.RequestType)
+}
+
+// TestServer_FeedCursor streams exchanges once, the canceled request stops the stream after the first check
+func TestServer_FeedCursor(t *testing.T) {
+	api := newTestAPI(t, servicetest.NewCallbackServer(t))
+
+	var bet Bet
+	api.do(http.MethodPost, "/bets", `{"bet_type": "single", "stake": "10"}`, http.StatusCreated, &bet)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		from   string
+		status int
+		events int
+	}{
+		{from: "", status: http.StatusOK, events: 1},
+		{from: "-5", status: http.StatusOK, events: 1},
+		{from: "1", status: http.StatusOK, events: 0},
+		{from: "100", status: http.StatusOK, events: 0},
+		{from: "abc", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/exchanges/feed?from="+tt.from, nil).WithContext(ctx)
+			recorder := httptest.NewRecorder()
+			api.handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			assert.Equal(t, tt.events, strings.Count(recorder.Body.String(), "event: exchange"))
+		})
+	}
+}
+
+func TestServer_CheckRequest(t *testing.T) {
+	api := newTestAPI(t, servicetest.NewCallbackServer(t))
+
+	tests := []struct {
+		name        string
+		contentType string
+		origin      string
+		status      int
+	}{
+		{name: "json", contentType: "application/json; charset=utf-8", status: http.StatusCreated},
+		{name: "same origin", contentType: "application/json", origin: "http://example.com", status: http.StatusCreated},
+		{name: "no content type", status: http.StatusUnsupportedMediaType},
+		{name: "form", contentType: "application/x-www-form-urlencoded", status: http.StatusUnsupportedMediaType},
+		{name: "cross origin", contentType: "application/json", origin: "http://evil.test", status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/bets", strings.NewReader(`{"bet_type": "single", "stake": "10"}`))
+			request.Header.Set("Content-Type", tt.contentType)
+			request.Header.Set("Origin", tt.origin)
+
+			recorder := httptest.NewRecorder()
+			api.handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tt.status, recorder.Code, recorder.Body.String())
+		})
+	}
+
+	var failure Error
+	api.do(http.MethodPost, "/bets", `{"bet_type": "single", "stake": "0"}`, http.StatusBadRequest, &failure)
+	assert.Contains(t, failure.Error, service.ErrInvalidAmount.Error())
+}
+
+// testRequest - fields of the request body which are checked by tests
+type testRequest struct {
+	RequestID    string                 `json:"request_id"`
+	SettleType   callback.SettleType    `json:"settle_type"`
+	Restrictions []callback.Restriction `json:"restrictions"`
+}
+
+func decodeRequest(t *testing.T, bet Bet) testRequest {
+	var request testRequest
+	require.NoError(t, json.Unmarshal(bet.Request, &request))
+
+	return request
+}
+
+type testAPI struct {
+	t       *testing.T
+	handler http.Handler
+}
+
+func newTestAPI(t *testing.T, callbackURL string) testAPI {
+	return testAPI{t: t, handler: NewServer(servicetest.NewService(t, callbackURL), zap.NewNop())}
+}
+
+func (a testAPI) do(method, path, body string, status int, result any) {
+	a.t.Helper()
+
+	request := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	request.Header.Set("Content-Type", "application/json")
+
+	recorder := httptest.NewRecorder()
+	a.handler.ServeHTTP(recorder, request)
+
+	require.Equal(a.t, status, recorder.Code, fmt.Sprintf("%s %s: %s", method, path, recorder.Body))
+	require.NoError(a.t, json.Unmarshal(recorder.Body.Bytes(), result))
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/databet-cloud/callback-test-tool/internal/balance"
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/currency"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+	"github.com/databet-cloud/callback-test-tool/internal/storage"
+)
+
+// Player - player of the session and the balance which the callback server is expected to have
+type Player struct {
+	ID             string          `json:"id"`
+	Currency       string          `json:"currency"`
+	Balance        balance.Balance `json:"balance"`
+	TokenExpiresAt time.Time       `json:"token_expires_at"`
+}
+
+// Bet - current state of the bet, the state is the type of the last request which changed the bet
+type Bet struct {
+	ID             string               `json:"id"`
+	State          callback.RequestType `json:"state"`
+	Type           string               `json:"type"`
+	SystemSizes    []int                `json:"system_sizes,omitempty"`
+	Stake          string               `json:"stake"`
+	CashedOutStake string               `json:"cashed_out_stake,omitempty"`
+	Legs           []Leg                `json:"legs"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+	// Request - body of the last request of the bet
+	Request json.RawMessage `json:"request"`
+}
+
+// Leg - selection of the bet, the status is declared by the settlement
+type Leg struct {
+	SportEventID string `json:"sport_event_id"`
+	MarketID     string `json:"market_id"`
+	OddID        string `json:"odd_id"`
+	Ratio        string `json:"ratio"`
+	Status       string `json:"status"`
+}
+
+// Request - sent request
+type Request struct {
+	Type      callback.RequestType `json:"type"`
+	ID        string               `json:"id"`
+	BetID     string               `json:"bet_id"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+	Body      json.RawMessage      `json:"body"`
+}
+
+// CashOutOrder - cash-out order of the bet
+type CashOutOrder struct {
+	*service.CashOutOrder
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Exchange - sent callback and the answer of the callback server
+type Exchange struct {
+	RequestType     callback.RequestType `json:"request_type"`
+	RequestID       string               `json:"request_id"`
+	BetID           string               `json:"bet_id"`
+	SentAt          time.Time            `json:"sent_at"`
+	DurationMs      int64                `json:"duration_ms"`
+	StatusCode      int                  `json:"status_code"`
+	Succeeded       bool                 `json:"succeeded"`
+	Error           string               `json:"error,omitempty"`
+	ExpectedBalance balance.Balance      `json:"expected_balance"`
+	Body            json.RawMessage      `json:"body"`
+	ResponseBody    string               `json:"response_body,omitempty"`
+}
+
+// Restriction - restriction type and names of its templates in the catalog
+type Restriction struct {
+	Type     callback.RestrictionType `json:"type"`
+	Variants []string                 `json:"variants"`
+}
+
+// Error - body of failed responses
+type Error struct {
+	Error string `json:"error"`
+}
+
+func newBet(doc *storage.Document[*callback.Data], cur currency.Currency) Bet {
+	// odds of the last request carry declared statuses, requests without odds keep odds of the placement
+	odds := doc.Value.BetOdds
+	if len(odds) == 0 {
+		odds = doc.Value.PrivateOdds
+	}
+
+	bet := Bet{
+		ID:          doc.Value.BetID,
+		State:       doc.Value.RequestType,
+		Type:        doc.Value.PrivateBetType.String(),
+		SystemSizes: doc.Value.PrivateBetSystemSizes,
+		Stake:       cur.Format(doc.Value.PrivateStake),
+		Legs:        convert(odds, newLeg),
+		CreatedAt:   doc.CreatedAt,
+		UpdatedAt:   doc.UpdatedAt,
+		Request:     payload(doc.Value),
+	}
+
+	if doc.Value.PrivateCashOutStake != nil {
+		bet.CashedOutStake = cur.Format(doc.Value.PrivateCashOutStake)
+	}
+
+	return bet
+}
+
+func newRequest(doc *storage.Document[*callback.Data]) Request {
+	return Request{
+		Type:      doc.Value.RequestType,
+		ID:        doc.Value.RequestID,
+		BetID:     doc.Value.BetID,
+		CreatedAt: doc.CreatedAt,
+		UpdatedAt: doc.UpdatedAt,
+		Body:      payload(doc.Value),
+	}
+}
+
+func newCashOutOrder(doc *storage.Document[*service.CashOutOrder]) CashOutOrder {
+	return CashOutOrder{CashOutOrder: doc.Value, CreatedAt: doc.CreatedAt, UpdatedAt: doc.UpdatedAt}
+}
+
+func newExchange(doc *storage.Document[*service.Exchange]) Exchange {
+	exchange := doc.Value
+
+	return Exchange{
+		RequestType:     exchange.Request.RequestType,
+		RequestID:       exchange.Request.RequestID,
+		BetID:           exchange.Request.BetID,
+		SentAt:          exchange.SentAt,
+		DurationMs:      exchange.Duration.Milliseconds(),
+		StatusCode:      exchange.StatusCode,
+		Succeeded:       exchange.Succeeded(),
+		Error:           exchange.Error,
+		ExpectedBalance: exchange.ExpectedBalance,
+		Body:            payload(exchange.Request),
+		ResponseBody:    exchange.ResponseBody,
+	}
+}
+
+func newLeg(odd *callback.Odd) Leg {
+	return Leg{
+		SportEventID: odd.MatchId,
+		MarketID:     odd.MarketId,
+		OddID:        odd.OddId,
+		Ratio:        odd.OddRatio.Text('f'),
+		Status:       odd.OddStatus.String(),
+	}
+}
+
+// payload returns the body of the request as it is sent to the callback server,
+// bodies of stored requests are already marshaled on sending, so the error is not expected
+func payload(data *callback.Data) json.RawMessage {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil
+	}
+
+	return raw
+}
+
+func convert[T, V any](values []V, f func(V) T) []T {
+	result := make([]T, len(values))
+	for i := range values {
+		result[i] = f(values[i])
+	}
+
+	return result
+}
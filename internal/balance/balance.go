@@ -5,28 +5,37 @@ import (
 
 	"github.com/cockroachdb/apd/v3"
 	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/currency"
 )
 
 type Balance struct {
+	Currency  string       `json:"currency"`
 	Available *apd.Decimal `json:"available"`
 	Hold      *apd.Decimal `json:"hold"`
 }
 
 type Service struct {
-	mu   sync.RWMutex
-	left *apd.Decimal
-	hold *apd.Decimal
-	log  *zap.Logger
+	mu       sync.RWMutex
+	currency currency.Currency
+	left     *apd.Decimal
+	hold     *apd.Decimal
+	log      *zap.Logger
 }
 
-func NewService(log *zap.Logger) *Service {
+func NewService(cur currency.Currency, log *zap.Logger) *Service {
 	return &Service{
-		left: apd.New(0, 0),
-		hold: apd.New(0, 0),
-		log:  log,
+		currency: cur,
+		left:     apd.New(0, 0),
+		hold:     apd.New(0, 0),
+		log:      log,
 	}
 }
 
+func (s *Service) Currency() currency.Currency {
+	return s.currency
+}
+
 func (s *Service) Deposit(amount *apd.Decimal) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -47,8 +56,9 @@ func (s *Service) DepositHold(amount *apd.Decimal) {
 	}
 }
 
-func (s *Service) DepositFloat(amount float64) error {
-	decimal, err := apd.New(0, 0).SetFloat64(amount)
+// DepositString deposits amount in the currency of the balance
+func (s *Service) DepositString(amount string) error {
+	decimal, err := s.currency.Parse(amount)
 	if err != nil {
 		return err
 	}
@@ -125,11 +135,24 @@ func (s *Service) State() Balance {
 	defer s.mu.RUnlock()
 
 	return Balance{
-		Available: s.left,
-		Hold:      s.hold,
+		Currency:  s.currency.Code,
+		Available: s.rounded(s.left),
+		Hold:      s.rounded(s.hold),
 	}
 }
 
+// rounded returns copy of the amount with all minor units of the currency
+func (s *Service) rounded(amount *apd.Decimal) *apd.Decimal {
+	rounded, err := s.currency.Round(amount)
+	if err != nil {
+		s.log.Error("failed to round amount", zap.Any("amount", amount), zap.Error(err))
+
+		return new(apd.Decimal).Set(amount)
+	}
+
+	return rounded
+}
+
 func (s *Service) newApdCtx() *apd.Context {
 	ctx := apd.BaseContext.WithPrecision(100)
 	ctx.Rounding = apd.RoundDown
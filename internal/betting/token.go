@@ -0,0 +1,129 @@
+package betting
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// expiryLeeway - token is re-issued a bit earlier than it expires to avoid requests with expired token
+const expiryLeeway = 30 * time.Second
+
+type Token struct {
+	Value     string    `json:"value"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (t Token) Expired(now time.Time) bool {
+	return t.Value == "" || !now.Before(t.ExpiresAt.Add(-expiryLeeway))
+}
+
+// TokenSource issues the auth token through the betting client and re-issues it when it expires
+type TokenSource struct {
+	client  *Client
+	request map[string]any
+	// ttl - token lifetime, used when expiry can not be extracted from the token
+	ttl time.Duration
+
+	mu    sync.Mutex
+	token Token
+
+	log *zap.Logger
+}
+
+func NewTokenSource(client *Client, request map[string]any, ttl time.Duration, log *zap.Logger) *TokenSource {
+	return &TokenSource{
+		client:  client,
+		request: request,
+		ttl:     ttl,
+		log:     log,
+	}
+}
+
+// Token returns the current token, the token is issued if it is absent or expired
+func (s *TokenSource) Token(ctx context.Context) (Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.token.Expired(time.Now()) {
+		return s.token, nil
+	}
+
+	return s.issue(ctx)
+}
+
+// Refresh re-issues the token regardless of its expiry
+func (s *TokenSource) Refresh(ctx context.Context) (Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.issue(ctx)
+}
+
+// Current returns the last issued token without re-issuing
+func (s *TokenSource) Current() Token {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.token
+}
+
+func (s *TokenSource) AuthToken(ctx context.Context) (string, error) {
+	token, err := s.Token(ctx)
+
+	return token.Value, err
+}
+
+func (s *TokenSource) RefreshAuthToken(ctx context.Context) (string, error) {
+	token, err := s.Refresh(ctx)
+
+	return token.Value, err
+}
+
+func (s *TokenSource) issue(ctx context.Context) (Token, error) {
+	value, err := s.client.GetToken(ctx, s.request)
+	if err != nil {
+		return Token{}, err
+	}
+
+	issuedAt := time.Now().UTC()
+
+	expiresAt, ok := jwtExpiry(value)
+	if !ok {
+		expiresAt = issuedAt.Add(s.ttl)
+	}
+
+	s.token = Token{Value: value, IssuedAt: issuedAt, ExpiresAt: expiresAt}
+	s.log.Info("auth token issued", zap.Time("expires_at", expiresAt))
+
+	return s.token, nil
+}
+
+// jwtExpiry extracts "exp" claim in case the token is JWT
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	claims := struct {
+		Exp int64 `json:"exp"`
+	}{}
+
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(claims.Exp, 0).UTC(), true
+}
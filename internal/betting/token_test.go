@@ -0,0 +1,128 @@
+package betting
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestToken_Expired(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		token   Token
+		expired bool
+	}{
+		{name: "empty", token: Token{ExpiresAt: now.Add(time.Hour)}, expired: true},
+		{name: "valid", token: Token{Value: "t", ExpiresAt: now.Add(time.Hour)}},
+		{name: "in leeway", token: Token{Value: "t", ExpiresAt: now.Add(expiryLeeway - time.Second)}, expired: true},
+		{name: "expired", token: Token{Value: "t", ExpiresAt: now.Add(-time.Second)}, expired: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expired, tt.token.Expired(now))
+		})
+	}
+}
+
+func TestJWTExpiry(t *testing.T) {
+	exp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		token  string
+		expiry time.Time
+		ok     bool
+	}{
+		{name: "jwt", token: testJWT(exp), expiry: exp, ok: true},
+		{name: "opaque", token: "8f2c1e"},
+		{name: "invalid payload", token: "header.!!!.signature"},
+		{name: "without exp", token: "header." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1"}`)) + ".signature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expiry, ok := jwtExpiry(tt.token)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expiry, expiry)
+		})
+	}
+}
+
+func TestTokenSource_Token(t *testing.T) {
+	tests := []struct {
+		name   string
+		expiry time.Duration
+		issued int32
+	}{
+		{name: "valid token is reused", expiry: time.Hour, issued: 1},
+		{name: "token in leeway is re-issued", expiry: expiryLeeway / 2, issued: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, issued := newTestTokenSource(t, func() string { return testJWT(time.Now().Add(tt.expiry)) })
+
+			first, err := source.Token(context.Background())
+			require.NoError(t, err)
+
+			second, err := source.Token(context.Background())
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.issued, issued.Load())
+			assert.Equal(t, second, source.Current())
+			assert.Equal(t, tt.issued == 1, first == second)
+		})
+	}
+}
+
+func TestTokenSource_Refresh(t *testing.T) {
+	source, issued := newTestTokenSource(t, func() string { return "opaque" })
+
+	token, err := source.AuthToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "opaque", token)
+
+	refreshed, err := source.Refresh(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), issued.Load())
+	assert.Equal(t, "opaque", refreshed.Value)
+	// expiry of the opaque token is the lifetime of the source
+	assert.Equal(t, refreshed.IssuedAt.Add(time.Hour), refreshed.ExpiresAt)
+}
+
+// newTestTokenSource returns the source of tokens of the betting server and the count of issued tokens
+func newTestTokenSource(t *testing.T, token func() string) (*TokenSource, *atomic.Int32) {
+	var issued atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, tokenCreatePath, r.URL.Path)
+
+		issued.Add(1)
+		assert.NoError(t, json.NewEncoder(w).Encode(map[string]string{"token": token()}))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(server.URL, server.Client(), zap.NewNop())
+
+	return NewTokenSource(client, map[string]any{}, time.Hour, zap.NewNop()), &issued
+}
+
+func testJWT(exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix())))
+
+	return "header." + payload + ".signature"
+}
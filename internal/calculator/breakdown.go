@@ -0,0 +1,140 @@
+package calculator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/apd/v3"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
+)
+
+// Breakdown - explanation of the settle amount calculation
+type Breakdown struct {
+	BetType callback.BetType `json:"bet_type"`
+	Sizes   []int            `json:"sizes,omitempty"`
+	Rules   string           `json:"rules"`
+	Stake   *apd.Decimal     `json:"stake"`
+	// ExpressStake - equal share of the stake for each express
+	ExpressStake *apd.Decimal `json:"express_stake"`
+	// Remainder - part of the stake added to the last express
+	Remainder *apd.Decimal        `json:"remainder"`
+	Expresses []*ExpressBreakdown `json:"expresses"`
+	// Total - sum of express payouts before the payout cap and rounding
+	Total  *apd.Decimal `json:"total"`
+	Capped bool         `json:"capped"`
+	Amount *apd.Decimal `json:"amount"`
+	// Precision and Rounding - rounding of shown stakes, multipliers and payouts like the payout of the rules,
+	// the calculation keeps full precision
+	Precision int32       `json:"-"`
+	Rounding  apd.Rounder `json:"-"`
+}
+
+type ExpressBreakdown struct {
+	Stake      *apd.Decimal    `json:"stake"`
+	Legs       []*LegBreakdown `json:"legs"`
+	Multiplier *apd.Decimal    `json:"multiplier"`
+	Payout     *apd.Decimal    `json:"payout"`
+}
+
+type LegBreakdown struct {
+	Value  *apd.Decimal         `json:"value"`
+	Status sportsbook.OddStatus `json:"status"`
+	// SettledAs - status applied by the rules, differs from Status for odds lower than minimal
+	SettledAs  sportsbook.OddStatus `json:"settled_as"`
+	Multiplier *apd.Decimal         `json:"multiplier"`
+}
+
+func (b *Breakdown) String() string {
+	r := b.Rounded()
+	sb := &strings.Builder{}
+
+	fmt.Fprintf(sb, "bet: %s %v, rules: %s\n", r.BetType, r.Sizes, r.Rules)
+	fmt.Fprintf(sb, "stake: %s, express stake: %s, remainder: %s\n", text(r.Stake), text(r.ExpressStake), text(r.Remainder))
+
+	for i, express := range r.Expresses {
+		legs := make([]string, len(express.Legs))
+		for j, leg := range express.Legs {
+			status := string(leg.SettledAs)
+			if leg.SettledAs != leg.Status {
+				status = fmt.Sprintf("%s->%s", leg.Status, leg.SettledAs)
+			}
+
+			legs[j] = fmt.Sprintf("%s[%s]x%s", text(leg.Value), status, text(leg.Multiplier))
+		}
+
+		fmt.Fprintf(
+			sb, "#%d stake: %s, legs: %s, multiplier: %s, payout: %s\n",
+			i+1, text(express.Stake), strings.Join(legs, " "), text(express.Multiplier), text(express.Payout),
+		)
+	}
+
+	fmt.Fprintf(sb, "total: %s, capped: %t, amount: %s", text(r.Total), r.Capped, text(r.Amount))
+
+	return sb.String()
+}
+
+// MarshalJSON writes the breakdown with values rounded to the precision
+func (b *Breakdown) MarshalJSON() ([]byte, error) {
+	type breakdown Breakdown
+
+	return json.Marshal((*breakdown)(b.Rounded()))
+}
+
+// Rounded returns copy of the breakdown with stakes, multipliers and payouts rounded to the precision,
+// values of odds are kept as they are
+func (b *Breakdown) Rounded() *Breakdown {
+	r := *b
+	r.Stake = b.round(b.Stake)
+	r.ExpressStake = b.round(b.ExpressStake)
+	r.Remainder = b.round(b.Remainder)
+	r.Total = b.round(b.Total)
+	r.Amount = b.round(b.Amount)
+	r.Expresses = make([]*ExpressBreakdown, len(b.Expresses))
+
+	for i, express := range b.Expresses {
+		e := &ExpressBreakdown{
+			Stake:      b.round(express.Stake),
+			Legs:       make([]*LegBreakdown, len(express.Legs)),
+			Multiplier: b.round(express.Multiplier),
+			Payout:     b.round(express.Payout),
+		}
+
+		for j, leg := range express.Legs {
+			l := *leg
+			l.Multiplier = b.round(leg.Multiplier)
+			e.Legs[j] = &l
+		}
+
+		r.Expresses[i] = e
+	}
+
+	return &r
+}
+
+func (b *Breakdown) round(v *apd.Decimal) *apd.Decimal {
+	if v == nil {
+		return nil
+	}
+
+	ctx := apd.BaseContext.WithPrecision(100)
+	ctx.Rounding = b.Rounding
+
+	rounded := new(apd.Decimal)
+	if _, err := ctx.Quantize(rounded, v, -b.Precision); err != nil {
+		return v
+	}
+
+	return rounded
+}
+
+// text formats the value without the exponent
+func text(v *apd.Decimal) string {
+	if v == nil {
+		return "<nil>"
+	}
+
+	return v.Text('f')
+}
@@ -25,22 +25,47 @@ func (c *Calculator) Settle(
 	betStake *apd.Decimal,
 	odds []*callback.Odd,
 ) (*apd.Decimal, callback.SettleType, error) {
+	amount, err := c.refundCalculator.Calc(betType, sizes, betStake, oddSelections(odds))
+	if err != nil {
+		return apd.New(0, 0), 0, err
+	}
+
+	return amount, SettleTypeOf(amount, betStake), nil
+}
+
+// SettleWithBreakdown settles the bet and explains how the amount is calculated,
+// every express of the system is kept in the breakdown, so it is built only to be shown
+func (c *Calculator) SettleWithBreakdown(
+	betType callback.BetType,
+	sizes []int,
+	betStake *apd.Decimal,
+	odds []*callback.Odd,
+) (*apd.Decimal, callback.SettleType, *Breakdown, error) {
+	breakdown, err := c.refundCalculator.Explain(betType, sizes, betStake, oddSelections(odds))
+	if err != nil {
+		return apd.New(0, 0), 0, nil, err
+	}
+
+	return breakdown.Amount, SettleTypeOf(breakdown.Amount, betStake), breakdown, nil
+}
+
+func oddSelections(odds []*callback.Odd) []Selection {
 	selections := make([]Selection, len(odds))
 	for i, odd := range odds {
 		selections[i] = odd
 	}
 
-	amount, err := c.refundCalculator.Calc(betType, sizes, betStake, selections)
-	if err != nil {
-		return apd.New(0, 0), 0, err
-	}
+	return selections
+}
 
+// SettleTypeOf returns settle type of the bet by comparison of the settle amount with the stake
+func SettleTypeOf(amount, betStake *apd.Decimal) callback.SettleType {
 	switch amount.Cmp(betStake) {
 	case 1:
-		return amount, callback.WinSettleType, nil
+		return callback.WinSettleType
 	case 0:
-		return amount, callback.RefundSettleType, nil
+		return callback.RefundSettleType
 	default:
-		return amount, callback.LossSettleType, nil
+		return callback.LossSettleType
 	}
 }
@@ -0,0 +1,93 @@
+package calculator
+
+import (
+	"errors"
+
+	"github.com/cockroachdb/apd/v3"
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
+)
+
+// cashOutSelection - leg of the bet valued by the ratio of the placed odd value to the current one
+type cashOutSelection struct {
+	ratio *apd.Decimal
+}
+
+func (s cashOutSelection) GetStatus() sportsbook.OddStatus {
+	return sportsbook.OddStatusWin
+}
+
+func (s cashOutSelection) GetValue() *apd.Decimal {
+	return s.ratio
+}
+
+// CashOutCalc values cash-out as the potential payout of the bet divided by the current odds
+// decreased by the margin: stake * Π(placed odd / current odd) * (1 - margin).
+// System bets are valued by every express separately.
+type CashOutCalc struct {
+	refundCalculator *RefundCalc
+	margin           *apd.Decimal
+	log              *zap.Logger
+}
+
+func NewCashOutCalc(refundCalculator *RefundCalc, margin *apd.Decimal, log *zap.Logger) *CashOutCalc {
+	if margin == nil {
+		margin = apd.New(0, 0)
+	}
+
+	return &CashOutCalc{
+		// cash-out value does not depend on settlement rules of the operator
+		refundCalculator: refundCalculator.withRules(DefaultRules()),
+		margin:           margin,
+		log:              log,
+	}
+}
+
+func (c *CashOutCalc) Value(
+	betType callback.BetType,
+	sizes []int,
+	stake *apd.Decimal,
+	placedOdds []*apd.Decimal,
+	currentOdds []*apd.Decimal,
+) (*apd.Decimal, error) {
+	if len(placedOdds) != len(currentOdds) {
+		return nil, errors.New("count of placed and current odds is different")
+	}
+
+	ctx := c.refundCalculator.newApdCtx()
+
+	selections := make([]Selection, len(placedOdds))
+
+	for i := range placedOdds {
+		if currentOdds[i].Sign() <= 0 {
+			return nil, errors.New("current odd value must be positive")
+		}
+
+		ratio := new(apd.Decimal)
+		if _, err := ctx.Quo(ratio, placedOdds[i], currentOdds[i]); err != nil {
+			return nil, err
+		}
+
+		selections[i] = cashOutSelection{ratio: ratio}
+	}
+
+	value, err := c.refundCalculator.CalcRefund(betType, sizes, stake, selections)
+	if err != nil {
+		return nil, err
+	}
+
+	share := new(apd.Decimal)
+	if _, err := ctx.Sub(share, apd.New(1, 0), c.margin); err != nil {
+		return nil, err
+	}
+
+	if _, err := ctx.Mul(value, value, share); err != nil {
+		return nil, err
+	}
+
+	_, err = ctx.Quantize(value, value, -6)
+
+	return value, err
+}
@@ -1,31 +1,79 @@
 package former
 
+// FormExpresses returns all expresses of the system, use EachExpress to avoid allocation of every express
 func FormExpresses[T any](selections []T, systemSizes []int) [][]T {
-	expresses := make([][]T, 0)
+	expresses := make([][]T, 0, CountExpresses(len(selections), systemSizes))
 
-	for _, systemSize := range systemSizes {
-		expresses = append(expresses, formSystemSizeExpresses(selections, systemSize)...)
-	}
+	EachExpress(selections, systemSizes, func(express []T) bool {
+		expresses = append(expresses, append([]T(nil), express...))
+
+		return true
+	})
 
 	return expresses
 }
 
-func formSystemSizeExpresses[T any](selections []T, systemSize int) [][]T {
-	expresses := make([][]T, 0)
+// EachExpress calls f for every express of the system in the order of FormExpresses until f returns false.
+// The express slice is reused between calls, it must be copied to be retained after f returns.
+func EachExpress[T any](selections []T, systemSizes []int, f func(express []T) bool) {
+	var express []T
+
+	EachExpressIndexes(len(selections), systemSizes, func(indexes []int) bool {
+		express = express[:0]
+		for _, selIndex := range indexes {
+			express = append(express, selections[selIndex])
+		}
+
+		return f(express)
+	})
+}
+
+// EachExpressIndexes calls f with indexes of selections of every express of the system until f returns false.
+// The indexes slice is owned by the iterator, it must not be modified or retained after f returns.
+func EachExpressIndexes(selectionsCount int, systemSizes []int, f func(indexes []int) bool) {
+	for _, systemSize := range systemSizes {
+		if systemSize < 1 || systemSize > selectionsCount {
+			continue
+		}
 
-	expressIterator := newExpressIterator(systemSize, len(selections))
-	for expressIterator.next() {
-		expresses = append(expresses, get(selections, expressIterator.expressSelIndexes, systemSize))
+		expressIterator := newExpressIterator(systemSize, selectionsCount)
+		for expressIterator.next() {
+			if !f(expressIterator.expressSelIndexes) {
+				return
+			}
+		}
 	}
+}
 
-	return expresses
+// CountExpresses returns count of expresses of the system: sum of binomial coefficients C(selectionsCount, size)
+func CountExpresses(selectionsCount int, systemSizes []int) int {
+	count := 0
+
+	for _, systemSize := range systemSizes {
+		// empty expresses are not formed
+		if systemSize < 1 {
+			continue
+		}
+
+		count += binomial(selectionsCount, systemSize)
+	}
+
+	return count
 }
 
-func get[T any](selections []T, expressSelIndexes []int, systemSize int) []T {
-	express := make([]T, systemSize)
-	for i, selIndex := range expressSelIndexes {
-		express[i] = selections[selIndex]
+func binomial(n, k int) int {
+	if k < 0 || k > n {
+		return 0
+	}
+
+	if k > n-k {
+		k = n - k
+	}
+
+	result := 1
+	for i := 1; i <= k; i++ {
+		result = result * (n - k + i) / i
 	}
 
-	return express
+	return result
 }
@@ -173,3 +173,61 @@ func extractSeIDs(expresses [][]selection) [][]string {
 
 	return result
 }
+
+func TestCountExpresses(t *testing.T) {
+	testCases := []struct {
+		selectionsCount int
+		systemSizes     []int
+		expected        int
+	}{
+		{4, []int{3}, 4},
+		{6, []int{2, 3}, 35},
+		{16, []int{4, 5, 6, 7, 8, 9, 10, 11, 12}, 64142},
+		{3, []int{0, 4}, 0},
+	}
+
+	for _, tc := range testCases {
+		var (
+			selections = make([]int, tc.selectionsCount)
+			count      = 0
+		)
+
+		EachExpress(selections, tc.systemSizes, func([]int) bool {
+			count++
+			return true
+		})
+
+		assert.Equal(t, tc.expected, CountExpresses(tc.selectionsCount, tc.systemSizes))
+		assert.Equal(t, tc.expected, count)
+	}
+}
+
+// allElements = 16, systemSizes = 4..12 (64142 expresses)
+// BenchmarkFormExpressesLargeSystem 	      13	  91641201 ns/op	54861952 B/op	   64157 allocs/op
+func BenchmarkFormExpressesLargeSystem(b *testing.B) {
+	const allElements = 16
+
+	selections := generateSelections(allElements)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		FormExpresses(selections, []int{4, 5, 6, 7, 8, 9, 10, 11, 12})
+	}
+}
+
+// allElements = 16, systemSizes = 4..12 (64142 expresses)
+// BenchmarkEachExpressLargeSystem   	     298	   4238304 ns/op	    3968 B/op	      14 allocs/op
+func BenchmarkEachExpressLargeSystem(b *testing.B) {
+	const allElements = 16
+
+	selections := generateSelections(allElements)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		EachExpress(selections, []int{4, 5, 6, 7, 8, 9, 10, 11, 12}, func(express []selection) bool { return true })
+	}
+}
@@ -1,27 +1,73 @@
 package calculator
 
 import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
 	"github.com/cockroachdb/apd/v3"
 	"go.uber.org/zap"
 
+	"github.com/databet-cloud/callback-test-tool/internal/calculator/former"
 	"github.com/databet-cloud/callback-test-tool/internal/callback"
 	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
 )
 
+// systemChunkSize - count of expresses summed separately before they are added to the total,
+// the sum does not depend on the count of workers because of the fixed order of additions
+const systemChunkSize = 256
+
+// ErrNotResulted - bet can not be settled until all selections are resulted
+var ErrNotResulted = errors.New("selection is not resulted")
+
 type Selection interface {
 	GetStatus() sportsbook.OddStatus
 	GetValue() *apd.Decimal
 }
 
-type FormExpressesFunc func([]Selection, []int) [][]Selection
+// EachExpressFunc calls f with indexes of selections of every express of the system until f returns false
+type EachExpressFunc func(selectionsCount int, systemSizes []int, f func(indexes []int) bool)
 
 type RefundCalc struct {
-	formExpresses FormExpressesFunc
-	log           *zap.Logger
+	eachExpress EachExpressFunc
+	rules       Rules
+	// workers - count of goroutines which sum expresses of large systems
+	workers int
+	log     *zap.Logger
+}
+
+func NewRefundCalc(log *zap.Logger, eachExpress EachExpressFunc) *RefundCalc {
+	return NewRefundCalcWithRules(log, eachExpress, DefaultRules())
+}
+
+func NewRefundCalcWithRules(log *zap.Logger, eachExpress EachExpressFunc, rules Rules) *RefundCalc {
+	return &RefundCalc{log: log, eachExpress: eachExpress, rules: rules, workers: 1}
+}
+
+func (c *RefundCalc) Rules() Rules {
+	return c.rules
+}
+
+// WithWorkers returns copy of the calculator which sums expresses of large systems in the workers goroutines,
+// zero or negative count means GOMAXPROCS
+func (c *RefundCalc) WithWorkers(workers int) *RefundCalc {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	calc := c.withRules(c.rules)
+	calc.workers = workers
+
+	return calc
 }
 
-func NewRefundCalc(log *zap.Logger, formExpresses FormExpressesFunc) *RefundCalc {
-	return &RefundCalc{log: log, formExpresses: formExpresses}
+// withRules returns copy of the calculator with other rules
+func (c *RefundCalc) withRules(rules Rules) *RefundCalc {
+	calc := NewRefundCalcWithRules(c.log, c.eachExpress, rules)
+	calc.workers = c.workers
+
+	return calc
 }
 
 func (c *RefundCalc) Calc(
@@ -30,15 +76,22 @@ func (c *RefundCalc) Calc(
 	refundBase *apd.Decimal,
 	selections []Selection,
 ) (*apd.Decimal, error) {
-	refund, err := c.CalcRefund(betType, betSize, refundBase, selections)
+	breakdown, err := c.settle(betType, betSize, refundBase, selections, false)
 	if err != nil {
 		return nil, err
 	}
 
-	ctx := c.newApdCtx()
-	_, err = ctx.Quantize(refund, refund, -6)
+	return breakdown.Amount, nil
+}
 
-	return refund, err
+// Explain calculates settle amount and returns breakdown of the calculation
+func (c *RefundCalc) Explain(
+	betType callback.BetType,
+	betSize []int,
+	refundBase *apd.Decimal,
+	selections []Selection,
+) (*Breakdown, error) {
+	return c.settle(betType, betSize, refundBase, selections, true)
 }
 
 func (c *RefundCalc) CalcRefund(
@@ -47,46 +100,290 @@ func (c *RefundCalc) CalcRefund(
 	refundBase *apd.Decimal,
 	selections []Selection,
 ) (*apd.Decimal, error) {
-	if betType != callback.SystemBetType {
-		return c.calcExpress(refundBase, selections)
+	breakdown, err := c.explainRefund(betType, betSize, refundBase, selections, false)
+	if err != nil {
+		return nil, err
 	}
 
-	return c.calcSystem(refundBase, betSize, selections)
+	return breakdown.Total, nil
 }
 
-func (c *RefundCalc) calcSystem(
+// settle calculates refund and applies payout cap and rounding of the rules,
+// expresses are added to the breakdown only in detailed mode
+func (c *RefundCalc) settle(
+	betType callback.BetType,
+	betSize []int,
 	refundBase *apd.Decimal,
-	systemSizes []int,
 	selections []Selection,
-) (*apd.Decimal, error) {
-	refund := apd.New(0, 0)
+	detailed bool,
+) (*Breakdown, error) {
+	breakdown, err := c.explainRefund(betType, betSize, refundBase, selections, detailed)
+	if err != nil {
+		return nil, err
+	}
 
-	if len(selections) == 0 {
-		return refund, nil
+	breakdown.Amount = new(apd.Decimal).Set(breakdown.Total)
+
+	if c.rules.MaxPayout != nil && breakdown.Amount.Cmp(c.rules.MaxPayout) > 0 {
+		breakdown.Amount.Set(c.rules.MaxPayout)
+		breakdown.Capped = true
 	}
 
 	ctx := c.newApdCtx()
+	ctx.Rounding = c.rules.Rounding
+	_, err = ctx.Quantize(breakdown.Amount, breakdown.Amount, -c.rules.Precision)
 
-	expresses := c.formExpresses(selections, systemSizes)
+	return breakdown, err
+}
 
-	expressStakes, err := c.expressStakes(refundBase, len(expresses))
-	if err != nil {
-		return nil, err
+func (c *RefundCalc) explainRefund(
+	betType callback.BetType,
+	betSize []int,
+	refundBase *apd.Decimal,
+	selections []Selection,
+	detailed bool,
+) (*Breakdown, error) {
+	breakdown := &Breakdown{
+		BetType:      betType,
+		Sizes:        betSize,
+		Rules:        c.rules.Name,
+		Stake:        refundBase,
+		ExpressStake: refundBase,
+		Remainder:    apd.New(0, 0),
+		Total:        apd.New(0, 0),
+		Precision:    c.rules.Precision,
+		Rounding:     c.rules.Rounding,
 	}
 
-	for index, express := range expresses {
-		expressRefund, err := c.calcExpress(expressStakes[index], express)
+	if betType != callback.SystemBetType {
+		express, err := c.explainExpress(refundBase, selections, true)
 		if err != nil {
 			return nil, err
 		}
 
-		_, err = ctx.Add(refund, refund, expressRefund)
+		breakdown.Expresses = []*ExpressBreakdown{express}
+		breakdown.Total = express.Payout
+
+		return breakdown, nil
+	}
+
+	return breakdown, c.explainSystem(breakdown, selections, detailed)
+}
+
+// explainSystem sums expresses of the system without forming all of them at once
+func (c *RefundCalc) explainSystem(breakdown *Breakdown, selections []Selection, detailed bool) error {
+	count := former.CountExpresses(len(selections), breakdown.Sizes)
+	if count == 0 {
+		return nil
+	}
+
+	expressStake, remainder, err := c.expressStake(breakdown.Stake, count)
+	if err != nil {
+		return err
+	}
+
+	breakdown.ExpressStake = expressStake
+	breakdown.Remainder = remainder
+
+	stakes := systemStakes{count: count, stake: expressStake, last: new(apd.Decimal)}
+
+	_, err = c.newApdCtx().Add(stakes.last, expressStake, remainder)
+	if err != nil {
+		return err
+	}
+
+	if detailed {
+		breakdown.Expresses = make([]*ExpressBreakdown, 0, count)
+	}
+
+	if c.workers > 1 && !detailed && count > systemChunkSize {
+		return c.sumSystemConcurrently(breakdown.Total, breakdown.Sizes, selections, stakes)
+	}
+
+	var (
+		ctx        = c.newApdCtx()
+		chunkTotal = apd.New(0, 0)
+		express    = make([]Selection, 0, len(selections))
+		number     = 0
+	)
+
+	c.eachExpress(len(selections), breakdown.Sizes, func(indexes []int) bool {
+		express = express[:0]
+		for _, i := range indexes {
+			express = append(express, selections[i])
+		}
+
+		var expressBreakdown *ExpressBreakdown
+
+		expressBreakdown, err = c.explainExpress(stakes.of(number), express, detailed)
+		if err != nil {
+			return false
+		}
+
+		if detailed {
+			breakdown.Expresses = append(breakdown.Expresses, expressBreakdown)
+		}
+
+		if _, err = ctx.Add(chunkTotal, chunkTotal, expressBreakdown.Payout); err != nil {
+			return false
+		}
+
+		number++
+
+		if number%systemChunkSize == 0 {
+			_, err = ctx.Add(breakdown.Total, breakdown.Total, chunkTotal)
+			chunkTotal.SetInt64(0)
+		}
+
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = ctx.Add(breakdown.Total, breakdown.Total, chunkTotal)
+
+	return err
+}
+
+type systemStakes struct {
+	count int
+	stake *apd.Decimal
+	// last - stake of the last express with the remainder
+	last *apd.Decimal
+}
+
+func (s systemStakes) of(number int) *apd.Decimal {
+	if number == s.count-1 {
+		return s.last
+	}
+
+	return s.stake
+}
+
+type systemChunk struct {
+	index int
+	// first - number of the first express of the chunk
+	first   int
+	indexes [][]int
+}
+
+// sumSystemConcurrently forms chunks of expresses and sums them in the workers,
+// chunk sums are added to the total in the order of chunks
+func (c *RefundCalc) sumSystemConcurrently(
+	total *apd.Decimal,
+	systemSizes []int,
+	selections []Selection,
+	stakes systemStakes,
+) error {
+	var (
+		chunksCount = (stakes.count + systemChunkSize - 1) / systemChunkSize
+		sums        = make([]*apd.Decimal, chunksCount)
+		errs        = make([]error, chunksCount)
+		chunks      = make(chan systemChunk, c.workers)
+		failed      atomic.Bool
+		wg          sync.WaitGroup
+	)
+
+	for w := 0; w < c.workers; w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for chunk := range chunks {
+				sums[chunk.index], errs[chunk.index] = c.sumChunk(chunk, selections, stakes)
+				if errs[chunk.index] != nil {
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+
+	maxSize := 0
+	for _, size := range systemSizes {
+		maxSize = max(maxSize, size)
+	}
+
+	newChunk := func(index int) systemChunk {
+		return systemChunk{index: index, first: index * systemChunkSize, indexes: make([][]int, 0, systemChunkSize)}
+	}
+
+	var (
+		chunk  = newChunk(0)
+		flat   = make([]int, 0, systemChunkSize*maxSize)
+		number = 0
+	)
+
+	c.eachExpress(len(selections), systemSizes, func(indexes []int) bool {
+		if number >= stakes.count || failed.Load() {
+			return false
+		}
+
+		flat = append(flat, indexes...)
+		chunk.indexes = append(chunk.indexes, flat[len(flat)-len(indexes):])
+		number++
+
+		if len(chunk.indexes) == systemChunkSize {
+			chunks <- chunk
+
+			chunk = newChunk(chunk.index + 1)
+			flat = make([]int, 0, systemChunkSize*maxSize)
+		}
+
+		return true
+	})
+
+	if len(chunk.indexes) > 0 {
+		chunks <- chunk
+	}
+
+	close(chunks)
+	wg.Wait()
+
+	ctx := c.newApdCtx()
+
+	for i := range sums {
+		if errs[i] != nil {
+			return errs[i]
+		}
+
+		if sums[i] == nil {
+			continue
+		}
+
+		if _, err := ctx.Add(total, total, sums[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *RefundCalc) sumChunk(chunk systemChunk, selections []Selection, stakes systemStakes) (*apd.Decimal, error) {
+	var (
+		ctx     = c.newApdCtx()
+		sum     = apd.New(0, 0)
+		express = make([]Selection, 0, len(selections))
+	)
+
+	for i, indexes := range chunk.indexes {
+		express = express[:0]
+		for _, index := range indexes {
+			express = append(express, selections[index])
+		}
+
+		expressBreakdown, err := c.explainExpress(stakes.of(chunk.first+i), express, false)
 		if err != nil {
 			return nil, err
 		}
+
+		if _, err = ctx.Add(sum, sum, expressBreakdown.Payout); err != nil {
+			return nil, err
+		}
 	}
 
-	return refund, nil
+	return sum, nil
 }
 
 /**
@@ -100,7 +397,7 @@ func (c *RefundCalc) calcSystem(
  * 2 - 0.333333
  * 3 - 0.333334
  */
-func (c *RefundCalc) expressStakes(refundBase *apd.Decimal, expressesCount int) ([]*apd.Decimal, error) {
+func (c *RefundCalc) expressStake(refundBase *apd.Decimal, expressesCount int) (*apd.Decimal, *apd.Decimal, error) {
 	ctx := c.newApdCtx()
 
 	expressStake := new(apd.Decimal)
@@ -108,106 +405,140 @@ func (c *RefundCalc) expressStakes(refundBase *apd.Decimal, expressesCount int)
 
 	_, err := ctx.Quo(expressStake, refundBase, expressesCountDec)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	expressStakeWithoutReminder := new(apd.Decimal)
 
 	_, err = ctx.Mul(expressStakeWithoutReminder, expressStake, expressesCountDec)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	expressStakeReminder := new(apd.Decimal)
 
 	_, err = ctx.Sub(expressStakeReminder, refundBase, expressStakeWithoutReminder)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	expressStakes := make([]*apd.Decimal, expressesCount)
-	for i := 0; i < expressesCount; i++ {
-		expressStakes[i] = new(apd.Decimal).Set(expressStake)
-	}
+	return expressStake, expressStakeReminder, nil
+}
+
+// explainExpress calculates payout of the express, legs are added to the breakdown only in detailed mode
+func (c *RefundCalc) explainExpress(
+	refundBase *apd.Decimal,
+	selections []Selection,
+	detailed bool,
+) (*ExpressBreakdown, error) {
+	ctx := c.newApdCtx()
 
-	lastExpressStakeIndex := expressesCount - 1
+	r := apd.New(1, 0)
+	lost := false
+	notResulted := false
 
-	_, err = ctx.Add(expressStakes[lastExpressStakeIndex], expressStakes[lastExpressStakeIndex], expressStakeReminder)
-	if err != nil {
-		return nil, err
+	express := &ExpressBreakdown{
+		Stake: refundBase,
 	}
 
-	return expressStakes, nil
-}
-
-func (c *RefundCalc) apdFromString(value string) *apd.Decimal {
-	res, _, err := apd.BaseContext.WithPrecision(100).NewFromString(value)
-	if err != nil {
-		c.log.Warn("cannot convert string to decimal", zap.String("value", value))
+	if detailed {
+		express.Legs = make([]*LegBreakdown, 0, len(selections))
 	}
 
-	return res
-}
+	for _, s := range selections {
+		status := s.GetStatus()
 
-func (c *RefundCalc) calcExpress(
-	refundBase *apd.Decimal,
-	selections []Selection,
-) (*apd.Decimal, error) {
-	ctx := c.newApdCtx()
+		// odds lower than minimal are settled as refunded
+		if c.rules.MinOdds != nil && s.GetValue().Cmp(c.rules.MinOdds) < 0 && status != sportsbook.OddStatusLoss {
+			status = sportsbook.OddStatusRefunded
+		}
 
-	r := c.apdFromString("1")
+		multiplier, err := c.legMultiplier(ctx, status, s.GetValue())
+		if err != nil {
+			return nil, err
+		}
 
-	for _, s := range selections {
-		switch s.GetStatus() {
-		case sportsbook.OddStatusLoss:
-			return c.apdFromString("0"), nil
+		if detailed {
+			express.Legs = append(express.Legs, &LegBreakdown{
+				Value:      s.GetValue(),
+				Status:     s.GetStatus(),
+				SettledAs:  status,
+				Multiplier: multiplier,
+			})
+		}
 
-		case sportsbook.OddStatusWin:
-			_, err := ctx.Mul(r, r, s.GetValue())
-			if err != nil {
+		switch {
+		case status == sportsbook.OddStatusLoss:
+			lost = true
+		case status == sportsbook.OddStatusNotResulted:
+			notResulted = true
+		case multiplier != nil:
+			if _, err = ctx.Mul(r, r, multiplier); err != nil {
 				return nil, err
 			}
+		}
+	}
 
-		case sportsbook.OddStatusHalfWin:
-			n := c.apdFromString("0")
-			_, err := ctx.Mul(n, s.GetValue(), c.apdFromString("1"))
+	// lost express is settled regardless of not resulted selections
+	if lost {
+		express.Multiplier = apd.New(0, 0)
+		express.Payout = apd.New(0, 0)
 
-			if err != nil {
-				return nil, err
-			}
-			_, err = ctx.Quo(n, n, c.apdFromString("2"))
+		return express, nil
+	}
 
-			if err != nil {
-				return nil, err
-			}
+	if notResulted {
+		return nil, ErrNotResulted
+	}
 
-			_, err = ctx.Mul(r, r, n)
-			if err != nil {
-				return nil, err
-			}
+	express.Multiplier = r
+	express.Payout = new(apd.Decimal)
 
-		case sportsbook.OddStatusHalfLoss:
-			_, err := ctx.Quo(r, r, c.apdFromString("2"))
-			if err != nil {
-				return nil, err
-			}
+	_, err := ctx.Mul(express.Payout, r, refundBase)
+
+	return express, err
+}
 
-		case sportsbook.OddStatusRefunded:
-			_, err := ctx.Mul(r, r, c.apdFromString("1"))
-			if err != nil {
+// legMultiplier returns multiplier of the express payout for the selection status,
+// nil is returned for not resulted selection
+func (c *RefundCalc) legMultiplier(
+	ctx *apd.Context,
+	status sportsbook.OddStatus,
+	value *apd.Decimal,
+) (*apd.Decimal, error) {
+	switch status {
+	case sportsbook.OddStatusLoss:
+		return apd.New(0, 0), nil
+
+	case sportsbook.OddStatusWin:
+		// odd value is not modified, so it is not copied
+		return value, nil
+
+	case sportsbook.OddStatusHalfWin:
+		n := new(apd.Decimal).Set(value)
+
+		if c.rules.HalfWin == HalfWinHalfProfit {
+			if _, err := ctx.Add(n, n, apd.New(1, 0)); err != nil {
 				return nil, err
 			}
-
-		default:
-			r = c.apdFromString("0")
 		}
-	}
 
-	res := new(apd.Decimal)
+		_, err := ctx.Quo(n, n, apd.New(2, 0))
+
+		return n, err
+
+	case sportsbook.OddStatusHalfLoss:
+		return apd.New(5, -1), nil
 
-	_, err := ctx.Mul(res, r, refundBase)
+	case sportsbook.OddStatusRefunded, sportsbook.OddStatusCancelled:
+		return apd.New(1, 0), nil
 
-	return res, err
+	case sportsbook.OddStatusNotResulted:
+		return nil, nil
+
+	default:
+		return apd.New(0, 0), nil
+	}
 }
 
 func (c *RefundCalc) newApdCtx() *apd.Context {
@@ -0,0 +1,80 @@
+package calculator
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/apd/v3"
+)
+
+const (
+	DatabetDefaultRules      = "databet-default"
+	AsianHandicapStrictRules = "asian-handicap-strict"
+	CappedPayoutRules        = "capped-payout"
+)
+
+type HalfWinMode string
+
+const (
+	// HalfWinHalfOdd - half win selection multiplies the payout by the half of the odd value
+	HalfWinHalfOdd HalfWinMode = "half_odd"
+	// HalfWinHalfProfit - half of the stake wins, another half is refunded: (odd value + 1) / 2
+	HalfWinHalfProfit HalfWinMode = "half_profit"
+)
+
+// Rules - operator specific settlement arithmetic
+type Rules struct {
+	Name    string      `json:"name"`
+	HalfWin HalfWinMode `json:"half_win"`
+	// Precision - count of digits after the decimal point of the payout
+	Precision int32 `json:"precision"`
+	// Rounding - rounding of the payout to the precision
+	Rounding apd.Rounder `json:"rounding"`
+	// MaxPayout - payout cap of the bet, nil means no cap
+	MaxPayout *apd.Decimal `json:"max_payout,omitempty"`
+	// MinOdds - selections with lower odd value are settled as refunded, nil means no limit
+	MinOdds *apd.Decimal `json:"min_odds,omitempty"`
+}
+
+func DefaultRules() Rules {
+	return Rules{
+		Name:      DatabetDefaultRules,
+		HalfWin:   HalfWinHalfOdd,
+		Precision: 6,
+		Rounding:  apd.RoundDown,
+	}
+}
+
+// RuleSets returns all known rule sets
+func RuleSets() []Rules {
+	return []Rules{
+		DefaultRules(),
+		{
+			Name:      AsianHandicapStrictRules,
+			HalfWin:   HalfWinHalfProfit,
+			Precision: 2,
+			Rounding:  apd.RoundHalfEven,
+			MinOdds:   apd.New(101, -2),
+		},
+		{
+			Name:      CappedPayoutRules,
+			HalfWin:   HalfWinHalfOdd,
+			Precision: 2,
+			Rounding:  apd.RoundDown,
+			MaxPayout: apd.New(100000, 0),
+		},
+	}
+}
+
+func RulesByName(name string) (Rules, error) {
+	for _, rules := range RuleSets() {
+		if rules.Name == name {
+			return rules, nil
+		}
+	}
+
+	return Rules{}, fmt.Errorf("unknown settlement rules %q", name)
+}
+
+func (r Rules) String() string {
+	return r.Name
+}
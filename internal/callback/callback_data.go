@@ -1,6 +1,7 @@
 package callback
 
 import (
+	"fmt"
 	"slices"
 	"time"
 
@@ -24,6 +25,43 @@ const (
 	LossSettleType   SettleType = 3
 )
 
+func (t BetType) String() string {
+	switch t {
+	case SingleBetType:
+		return "single"
+	case ExpressBetType:
+		return "express"
+	case SystemBetType:
+		return "system"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
+
+// ParseBetType parses name of the bet type: single, express or system
+func ParseBetType(name string) (BetType, error) {
+	for _, t := range []BetType{SingleBetType, ExpressBetType, SystemBetType} {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown bet type %q", name)
+}
+
+func (t SettleType) String() string {
+	switch t {
+	case WinSettleType:
+		return "win"
+	case RefundSettleType:
+		return "refund"
+	case LossSettleType:
+		return "loss"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
+
 type Competitor struct {
 	Id   string `json:"id"`
 	Type int    `json:"type"`
@@ -86,10 +124,11 @@ func (o *Odd) Clone() *Odd {
 	}
 }
 
-func (o *Odd) WithStatus(status sportsbook.OddStatus) *Odd {
+// WithStatus returns copy of the odd with the status updated at the time
+func (o *Odd) WithStatus(status sportsbook.OddStatus, updatedAt time.Time) *Odd {
 	odd := o.Clone()
 	odd.OddStatus = status
-	odd.OddUpdatedAt = time.Now()
+	odd.OddUpdatedAt = updatedAt
 
 	return odd
 }
@@ -101,6 +140,7 @@ type Data struct {
 	PrivateBetType        BetType      `json:"-"`
 	PrivateBetSystemSizes []int        `json:"-"`
 	PrivateCashOutAmount  *apd.Decimal `json:"-"`
+	PrivateCashOutStake   *apd.Decimal `json:"-"` // part of the stake which is cashed out
 
 	RequestID       string        `json:"request_id"`
 	BetID           string        `json:"bet_id"`
@@ -150,6 +190,7 @@ func (d *Data) Clone() *Data {
 		PrivateBetType:        d.PrivateBetType,
 		PrivateBetSystemSizes: d.PrivateBetSystemSizes,
 		PrivateCashOutAmount:  d.PrivateCashOutAmount,
+		PrivateCashOutStake:   d.PrivateCashOutStake,
 
 		RequestID:       d.RequestID,
 		BetID:           d.BetID,
@@ -34,6 +34,16 @@ const (
 	BetCashOutOrdersDeclinedRequestType RequestType = "cash-out_declined"
 )
 
+// StatusError - response of the callback server with a status code other than 204 No Content
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unknown status code %d, body %s", e.StatusCode, e.Body)
+}
+
 type Client struct {
 	url           string
 	foreignParams map[string]any
@@ -111,7 +121,7 @@ func (c *Client) sendRequest(ctx context.Context, path string, body any) (*http.
 	if response.StatusCode != http.StatusNoContent {
 		rawBody, _ := io.ReadAll(response.Body)
 
-		return nil, fmt.Errorf("unknown status code %d, body %s", response.StatusCode, rawBody)
+		return nil, &StatusError{StatusCode: response.StatusCode, Body: string(rawBody)}
 	}
 
 	return response, nil
@@ -0,0 +1,258 @@
+// Package certify runs the versioned battery of scenarios against the callback server of the operator
+// and produces the signed pass/fail summary of the certification
+package certify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+
+	"github.com/databet-cloud/callback-test-tool/internal/report"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+)
+
+const (
+	// SHA256Algorithm - signature is the digest of the summary, it detects accidental changes only
+	SHA256Algorithm = "sha256"
+	// HMACSHA256Algorithm - signature is keyed by the secret of the certifying party
+	HMACSHA256Algorithm = "hmac-sha256"
+)
+
+// Result - result of the scenario
+type Result struct {
+	Scenario    string        `json:"scenario"`
+	Description string        `json:"description"`
+	Passed      bool          `json:"passed"`
+	Failures    []string      `json:"failures,omitempty"`
+	Exchanges   int           `json:"exchanges"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// Summary - results of all scenarios, it is signed after the run
+type Summary struct {
+	Version    string    `json:"version"`
+	PlayerID   string    `json:"player_id"`
+	Currency   string    `json:"currency"`
+	Operator   string    `json:"operator,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Passed     bool      `json:"passed"`
+	Results    []Result  `json:"results"`
+	// BalanceViolations - violations of the expected balance after all scenarios
+	BalanceViolations []string `json:"balance_violations,omitempty"`
+
+	SignatureAlgorithm string `json:"signature_algorithm,omitempty"`
+	Signature          string `json:"signature,omitempty"`
+}
+
+// Run runs all scenarios with the stake in the currency of the player, failed scenarios do not stop the run
+func Run(ctx context.Context, sv *service.Service, stake *apd.Decimal) (*Summary, error) {
+	if sv.AnswersAutomatically() {
+		return nil, errors.New("certification can not run while placed bets are answered automatically")
+	}
+
+	summary := &Summary{
+		Version:   Version,
+		PlayerID:  sv.PlayerID(),
+		Currency:  sv.PlayerCurrency().Code,
+		StartedAt: time.Now().UTC(),
+	}
+
+	for _, scenario := range Scenarios() {
+		summary.Results = append(summary.Results, runScenario(ctx, sv, stake, scenario))
+	}
+
+	r, err := report.Build(sv, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range r.BalanceViolations {
+		summary.BalanceViolations = append(summary.BalanceViolations, v.String())
+	}
+
+	summary.FinishedAt = time.Now().UTC()
+	summary.Passed = len(summary.BalanceViolations) == 0 && !slices.ContainsFunc(summary.Results, func(r Result) bool {
+		return !r.Passed
+	})
+
+	return summary, nil
+}
+
+// runScenario runs the scenario and checks exchanges and lifecycles of its bets
+func runScenario(ctx context.Context, sv *service.Service, stake *apd.Decimal, scenario Scenario) Result {
+	var (
+		s        = &session{ctx: ctx, sv: sv, stake: stake}
+		start    = time.Now()
+		sent     = len(sv.Exchanges())
+		failures []string
+	)
+
+	if err := scenario.Run(s); err != nil {
+		failures = append(failures, err.Error())
+	}
+
+	exchanges := sv.Exchanges()[sent:]
+
+	for _, doc := range exchanges {
+		if exchange := doc.Value; !exchange.Succeeded() {
+			failures = append(failures, fmt.Sprintf(
+				"%s %s: status code %d %s",
+				exchange.Request.RequestType,
+				exchange.Request.RequestID,
+				exchange.StatusCode,
+				exchange.Error,
+			))
+		}
+	}
+
+	if r, err := report.Build(sv, time.Now().UTC()); err != nil {
+		failures = append(failures, err.Error())
+	} else {
+		for _, lifecycle := range r.Lifecycles {
+			if !slices.Contains(s.betIDs, lifecycle.BetID) {
+				continue
+			}
+
+			for _, v := range lifecycle.Violations {
+				// unexpected statuses are already reported by exchanges
+				if v.Kind != report.UnexpectedStatus {
+					failures = append(failures, v.String())
+				}
+			}
+		}
+	}
+
+	return Result{
+		Scenario:    scenario.Name,
+		Description: scenario.Description,
+		Passed:      len(failures) == 0,
+		Failures:    failures,
+		Exchanges:   len(exchanges),
+		Duration:    time.Since(start),
+	}
+}
+
+// PassedScenarios returns count of passed scenarios
+func (s *Summary) PassedScenarios() int {
+	passed := 0
+
+	for _, r := range s.Results {
+		if r.Passed {
+			passed++
+		}
+	}
+
+	return passed
+}
+
+// Sign signs the summary by HMAC-SHA256 with the key, the summary is only digested by SHA-256 without the key
+func (s *Summary) Sign(key []byte) error {
+	s.SignatureAlgorithm = SHA256Algorithm
+	if len(key) > 0 {
+		s.SignatureAlgorithm = HMACSHA256Algorithm
+	}
+
+	signature, err := s.signature(key)
+	if err != nil {
+		return err
+	}
+
+	s.Signature = signature
+
+	return nil
+}
+
+// Verify reports whether the signature matches the summary and the key
+func (s *Summary) Verify(key []byte) (bool, error) {
+	if (s.SignatureAlgorithm == HMACSHA256Algorithm) != (len(key) > 0) {
+		return false, nil
+	}
+
+	signature, err := s.signature(key)
+	if err != nil {
+		return false, err
+	}
+
+	return hmac.Equal([]byte(signature), []byte(s.Signature)), nil
+}
+
+// signature signs JSON of the summary without the signature, the algorithm is signed too
+func (s *Summary) signature(key []byte) (string, error) {
+	unsigned := *s
+	unsigned.Signature = ""
+
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", err
+	}
+
+	if len(key) == 0 {
+		digest := sha256.Sum256(data)
+
+		return hex.EncodeToString(digest[:]), nil
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// WriteText writes the summary for the console
+func (s *Summary) WriteText(w io.Writer) error {
+	status := "FAILED"
+	if s.Passed {
+		status = "PASSED"
+	}
+
+	fmt.Fprintf(w, "Certification suite v%s: %s (%d/%d scenarios)\n", s.Version, status, s.PassedScenarios(), len(s.Results))
+	fmt.Fprintf(w, "Player: %s (%s)\n", s.PlayerID, s.Currency)
+
+	if s.Operator != "" {
+		fmt.Fprintf(w, "Signed off by: %s\n", s.Operator)
+	}
+
+	fmt.Fprintf(w, "Run: %s - %s\n\n", s.StartedAt.Format(time.RFC3339), s.FinishedAt.Format(time.RFC3339))
+
+	for _, r := range s.Results {
+		mark := "FAIL"
+		if r.Passed {
+			mark = "PASS"
+		}
+
+		fmt.Fprintf(w, "[%s] %-40s %s\n", mark, r.Scenario, r.Description)
+
+		for _, failure := range r.Failures {
+			fmt.Fprintf(w, "       - %s\n", failure)
+		}
+	}
+
+	for _, v := range s.BalanceViolations {
+		fmt.Fprintf(w, "[FAIL] balance: %s\n", v)
+	}
+
+	_, err := fmt.Fprintf(w, "\nSignature (%s): %s\n", s.SignatureAlgorithm, s.Signature)
+
+	return err
+}
+
+// WriteFile writes JSON of the summary to the file
+func (s *Summary) WriteFile(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,92 @@
+package certify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/databet-cloud/callback-test-tool/internal/servicetest"
+)
+
+func TestRun(t *testing.T) {
+	summary := runSuite(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for _, r := range summary.Results {
+		assert.True(t, r.Passed, "%s: %v", r.Scenario, r.Failures)
+	}
+
+	assert.True(t, summary.Passed)
+	assert.Len(t, summary.Results, len(Scenarios()))
+	assert.Empty(t, summary.BalanceViolations)
+}
+
+// TestRun_FailedUnsettle expects failures of scenarios with unsettles only
+func TestRun_FailedUnsettle(t *testing.T) {
+	summary := runSuite(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bet/unsettle" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	failed := make([]string, 0)
+
+	for _, r := range summary.Results {
+		if !r.Passed {
+			failed = append(failed, r.Scenario)
+		}
+	}
+
+	assert.False(t, summary.Passed)
+	assert.Equal(t, []string{"unsettle-resettle", "replay-lifecycle"}, failed)
+}
+
+func TestSummary_Sign(t *testing.T) {
+	summary := &Summary{Version: Version, PlayerID: "player-1", Results: []Result{{Scenario: "single-win", Passed: true}}}
+	key := []byte("secret")
+
+	require.NoError(t, summary.Sign(key))
+	assert.Equal(t, HMACSHA256Algorithm, summary.SignatureAlgorithm)
+
+	data, err := json.Marshal(summary)
+	require.NoError(t, err)
+
+	var decoded Summary
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	ok, err := decoded.Verify(key)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = decoded.Verify([]byte("other"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	decoded.Results[0].Passed = false
+	ok, err = decoded.Verify(key)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	var text strings.Builder
+	require.NoError(t, summary.WriteText(&text))
+	assert.Contains(t, text.String(), "[PASS] single-win")
+}
+
+func runSuite(t *testing.T, handler http.HandlerFunc) *Summary {
+	sv := servicetest.NewService(t, servicetest.NewCallbackServerFunc(t, handler))
+
+	summary, err := Run(context.Background(), sv, apd.New(10, 0))
+	require.NoError(t, err)
+
+	return summary
+}
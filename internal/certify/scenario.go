@@ -0,0 +1,302 @@
+package certify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/apd/v3"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
+)
+
+// Version - version of the battery of scenarios, it is changed on every change of Scenarios
+const Version = "1"
+
+// cashOutFraction - fraction of the stake of cash-out orders
+const cashOutFraction = 0.5
+
+// anySettleType - settle type is not checked, it depends on odd values of half won bets
+const anySettleType callback.SettleType = 0
+
+// Scenario - fixed sequence of requests, the scenario passes when all requests are answered with 204
+// and lifecycles of its bets have no violations
+type Scenario struct {
+	Name        string
+	Description string
+	Run         func(s *session) error
+}
+
+// Scenarios returns the battery of the current version in the order of the run
+// nolint:funlen // the battery is declared in one place to be reviewed as a whole
+func Scenarios() []Scenario {
+	scenarios := []Scenario{
+		settleScenario("single-win", "single bet is won", callback.SingleBetType,
+			callback.WinSettleType, sportsbook.OddStatusWin),
+		settleScenario("single-loss", "single bet is lost", callback.SingleBetType,
+			callback.LossSettleType, sportsbook.OddStatusLoss),
+		settleScenario("single-refund", "single bet is refunded", callback.SingleBetType,
+			callback.RefundSettleType, sportsbook.OddStatusRefunded),
+		settleScenario("single-half-win", "single bet is half won", callback.SingleBetType,
+			anySettleType, sportsbook.OddStatusHalfWin),
+		settleScenario("single-half-loss", "single bet is half lost", callback.SingleBetType,
+			callback.LossSettleType, sportsbook.OddStatusHalfLoss),
+		settleScenario("express-win", "express bet is won", callback.ExpressBetType,
+			callback.WinSettleType, sportsbook.OddStatusWin),
+		settleScenario("express-loss", "one leg of the express bet is lost", callback.ExpressBetType,
+			callback.LossSettleType, sportsbook.OddStatusWin, sportsbook.OddStatusLoss),
+		settleScenario("express-refund", "all legs of the express bet are refunded", callback.ExpressBetType,
+			callback.RefundSettleType, sportsbook.OddStatusRefunded),
+		settleScenario("express-half-win", "one leg of the express bet is half won", callback.ExpressBetType,
+			anySettleType, sportsbook.OddStatusHalfWin, sportsbook.OddStatusWin),
+		settleScenario("system-win", "system bet is won", callback.SystemBetType,
+			callback.WinSettleType, sportsbook.OddStatusWin),
+		settleScenario("system-loss", "system bet is lost", callback.SystemBetType,
+			callback.LossSettleType, sportsbook.OddStatusLoss),
+		settleScenario("system-refund", "all legs of the system bet are refunded", callback.SystemBetType,
+			callback.RefundSettleType, sportsbook.OddStatusRefunded),
+		{
+			Name:        "cash-out-accept",
+			Description: "half of the stake is cashed out, the rest is won",
+			Run: func(s *session) error {
+				bet, err := s.placeAccepted(callback.SingleBetType)
+				if err != nil {
+					return err
+				}
+
+				order, err := s.sv.CreateCashOutOrder(s.ctx, bet.BetID, cashOutFraction)
+				if err != nil {
+					return err
+				}
+
+				if err := s.sv.AcceptCashOutOrder(s.ctx, order.ID); err != nil {
+					return err
+				}
+
+				return s.settle(bet, callback.WinSettleType, sportsbook.OddStatusWin)
+			},
+		},
+		{
+			Name:        "cash-out-decline",
+			Description: "cash-out order is declined, the bet is lost",
+			Run: func(s *session) error {
+				bet, err := s.placeAccepted(callback.ExpressBetType)
+				if err != nil {
+					return err
+				}
+
+				order, err := s.sv.CreateCashOutOrder(s.ctx, bet.BetID, cashOutFraction)
+				if err != nil {
+					return err
+				}
+
+				if err := s.sv.DeclineCashOutOrders(s.ctx, bet.BetID, []string{order.ID}); err != nil {
+					return err
+				}
+
+				return s.settle(bet, callback.LossSettleType, sportsbook.OddStatusLoss)
+			},
+		},
+		{
+			Name:        "unsettle-resettle",
+			Description: "won bet is unsettled and settled again as lost",
+			Run: func(s *session) error {
+				bet, err := s.placeAccepted(callback.SingleBetType)
+				if err != nil {
+					return err
+				}
+
+				if err := s.settle(bet, callback.WinSettleType, sportsbook.OddStatusWin); err != nil {
+					return err
+				}
+
+				if err := s.sv.UnSettleBet(s.ctx, bet.BetID); err != nil {
+					return err
+				}
+
+				return s.settle(bet, callback.LossSettleType, sportsbook.OddStatusLoss)
+			},
+		},
+		{
+			Name:        "replay-lifecycle",
+			Description: "every request of the lifecycle is sent twice with the same request ID",
+			Run: func(s *session) error {
+				bet, err := s.place(callback.SingleBetType)
+				if err != nil {
+					return err
+				}
+
+				steps := []func() error{
+					func() error { return s.sv.AcceptBet(s.ctx, bet.BetID) },
+					func() error { return s.settle(bet, callback.WinSettleType, sportsbook.OddStatusWin) },
+					func() error { return s.sv.UnSettleBet(s.ctx, bet.BetID) },
+				}
+
+				if err := s.replayLast(bet.BetID); err != nil {
+					return err
+				}
+
+				for _, step := range steps {
+					if err := step(); err != nil {
+						return err
+					}
+
+					if err := s.replayLast(bet.BetID); err != nil {
+						return err
+					}
+				}
+
+				return s.settle(bet, callback.LossSettleType, sportsbook.OddStatusLoss)
+			},
+		},
+		{
+			Name:        "replay-decline",
+			Description: "decline is sent twice with the same request ID",
+			Run: func(s *session) error {
+				bet, err := s.place(callback.SingleBetType)
+				if err != nil {
+					return err
+				}
+
+				if err := s.decline(bet, callback.InternalErrorRestriction); err != nil {
+					return err
+				}
+
+				return s.replayLast(bet.BetID)
+			},
+		},
+	}
+
+	betTypes := []callback.BetType{callback.SingleBetType, callback.ExpressBetType, callback.SystemBetType}
+
+	for i, restrictionType := range callback.GetAllBetRestrictions() {
+		betType := betTypes[i%len(betTypes)]
+
+		scenarios = append(scenarios, Scenario{
+			Name:        fmt.Sprintf("restriction-%s", restrictionType),
+			Description: fmt.Sprintf("%s bet is declined with the %s restriction", betType, restrictionType),
+			Run: func(s *session) error {
+				bet, err := s.place(betType)
+				if err != nil {
+					return err
+				}
+
+				return s.decline(bet, restrictionType)
+			},
+		})
+	}
+
+	return scenarios
+}
+
+// settleScenario places, accepts and settles the bet, statuses are assigned to legs in order,
+// the last status is assigned to the remaining legs
+func settleScenario(
+	name, description string,
+	betType callback.BetType,
+	expected callback.SettleType,
+	statuses ...sportsbook.OddStatus,
+) Scenario {
+	return Scenario{
+		Name:        name,
+		Description: description,
+		Run: func(s *session) error {
+			bet, err := s.placeAccepted(betType)
+			if err != nil {
+				return err
+			}
+
+			return s.settle(bet, expected, statuses...)
+		},
+	}
+}
+
+// session - state of the running scenario
+type session struct {
+	ctx   context.Context
+	sv    *service.Service
+	stake *apd.Decimal
+	// betIDs - bets placed by the scenario
+	betIDs []string
+}
+
+func (s *session) place(betType callback.BetType) (*callback.Data, error) {
+	bet, err := s.sv.PlaceBet(s.ctx, betType, s.stake)
+	if err != nil {
+		return nil, err
+	}
+
+	s.betIDs = append(s.betIDs, bet.BetID)
+
+	return bet, nil
+}
+
+func (s *session) placeAccepted(betType callback.BetType) (*callback.Data, error) {
+	bet, err := s.place(betType)
+	if err != nil {
+		return nil, err
+	}
+
+	return bet, s.sv.AcceptBet(s.ctx, bet.BetID)
+}
+
+// settle settles the bet and checks the settle type of the sent request if it is expected
+func (s *session) settle(bet *callback.Data, expected callback.SettleType, statuses ...sportsbook.OddStatus) error {
+	odds := make([]*callback.Odd, len(bet.PrivateOdds))
+	for i, odd := range bet.PrivateOdds {
+		odds[i] = odd.WithStatus(statuses[min(i, len(statuses)-1)], s.sv.Now())
+	}
+
+	if err := s.sv.SettleBet(s.ctx, bet.BetID, odds); err != nil {
+		return err
+	}
+
+	settle, ok := s.lastRequest(bet.BetID)
+	if !ok || settle.RequestType != callback.BetSettleRequestType {
+		return fmt.Errorf("settle of the bet %s is not sent", bet.BetID)
+	}
+
+	if expected != anySettleType && settle.SettleType != expected {
+		return fmt.Errorf("settle type of the bet %s is %s, expected %s", bet.BetID, settle.SettleType, expected)
+	}
+
+	return nil
+}
+
+// decline declines the bet with the default template of the restriction type for the first leg
+func (s *session) decline(bet *callback.Data, restrictionType callback.RestrictionType) error {
+	tmpl, ok := s.sv.Restrictions().Default(restrictionType)
+	if !ok {
+		return fmt.Errorf("no templates of the restriction %s", restrictionType)
+	}
+
+	r, err := s.sv.DraftRestriction(bet.BetID, tmpl, bet.PrivateOdds[0])
+	if err != nil {
+		return err
+	}
+
+	return s.sv.DeclineBet(s.ctx, bet.BetID, []callback.Restriction{r})
+}
+
+// replayLast sends the last request of the bet again
+func (s *session) replayLast(betID string) error {
+	request, ok := s.lastRequest(betID)
+	if !ok {
+		return fmt.Errorf("no requests of the bet %s to replay", betID)
+	}
+
+	return s.sv.ReplayCallback(s.ctx, request)
+}
+
+func (s *session) lastRequest(betID string) (*callback.Data, bool) {
+	exchanges := s.sv.Exchanges()
+
+	for i := len(exchanges) - 1; i >= 0; i-- {
+		if request := exchanges[i].Value.Request; request.BetID == betID {
+			return request, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,57 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock - source of the current time
+type Clock interface {
+	Now() time.Time
+	// Fork returns the clock which continues from the current time independently of this one
+	Fork() Clock
+}
+
+type system struct{}
+
+// System returns clock of the system time
+func System() Clock {
+	return system{}
+}
+
+func (system) Now() time.Time {
+	return time.Now()
+}
+
+func (c system) Fork() Clock {
+	return c
+}
+
+// Stepping - clock which starts at the fixed time and moves forward by the step on every call,
+// so the time is the same for the same sequence of calls
+type Stepping struct {
+	step time.Duration
+
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewStepping(start time.Time, step time.Duration) *Stepping {
+	return &Stepping{now: start, step: step}
+}
+
+func (c *Stepping) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now
+	c.now = c.now.Add(c.step)
+
+	return now
+}
+
+// Fork returns the stepping clock which starts at the next time of this one,
+// readings of the fork do not move this clock
+func (c *Stepping) Fork() Clock {
+	return NewStepping(c.Now(), c.step)
+}
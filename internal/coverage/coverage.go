@@ -0,0 +1,229 @@
+// Package coverage tracks which combinations of request types, bet types, settle types and restriction types
+// were handled by the callback server, only succeeded callbacks cover their combination
+package coverage
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+	"github.com/databet-cloud/callback-test-tool/internal/storage"
+)
+
+// Source - session to cover, it is implemented by service.Service
+type Source interface {
+	Exchanges() []*storage.Document[*service.Exchange]
+}
+
+var (
+	BetTypes = []callback.BetType{callback.SingleBetType, callback.ExpressBetType, callback.SystemBetType}
+
+	RequestTypes = []callback.RequestType{
+		callback.BetPlaceRequestType,
+		callback.BetAcceptRequestType,
+		callback.BetDeclineRequestType,
+		callback.BetSettleRequestType,
+		callback.BetUnSettleRequestType,
+		callback.BetCashOutOrdersAcceptedRequestType,
+		callback.BetCashOutOrdersDeclinedRequestType,
+	}
+
+	SettleTypes = []callback.SettleType{callback.WinSettleType, callback.RefundSettleType, callback.LossSettleType}
+)
+
+// Matrix - count of succeeded callbacks per combination
+type Matrix struct {
+	Requests     map[callback.BetType]map[callback.RequestType]int
+	Settles      map[callback.BetType]map[callback.SettleType]int
+	Restrictions map[callback.RestrictionType]int
+}
+
+// Gap - combination without succeeded callbacks, only one of SettleType and RestrictionType is set
+type Gap struct {
+	BetType         callback.BetType
+	RequestType     callback.RequestType
+	SettleType      callback.SettleType
+	RestrictionType callback.RestrictionType
+}
+
+func (g Gap) String() string {
+	switch {
+	case g.RestrictionType != "":
+		return fmt.Sprintf("%s restriction", g.RestrictionType)
+	case g.SettleType != 0:
+		return fmt.Sprintf("%s %s %s", g.BetType, g.RequestType, g.SettleType)
+	default:
+		return fmt.Sprintf("%s %s", g.BetType, g.RequestType)
+	}
+}
+
+// Build counts succeeded callbacks of the session
+func Build(source Source) *Matrix {
+	m := &Matrix{
+		Requests:     make(map[callback.BetType]map[callback.RequestType]int),
+		Settles:      make(map[callback.BetType]map[callback.SettleType]int),
+		Restrictions: make(map[callback.RestrictionType]int),
+	}
+
+	for _, betType := range BetTypes {
+		m.Requests[betType] = make(map[callback.RequestType]int)
+		m.Settles[betType] = make(map[callback.SettleType]int)
+	}
+
+	for _, doc := range source.Exchanges() {
+		if !doc.Value.Succeeded() {
+			continue
+		}
+
+		request := doc.Value.Request
+
+		requests, ok := m.Requests[request.PrivateBetType]
+		if !ok {
+			continue
+		}
+
+		requests[request.RequestType]++
+
+		switch request.RequestType {
+		case callback.BetSettleRequestType:
+			m.Settles[request.PrivateBetType][request.SettleType]++
+		case callback.BetDeclineRequestType:
+			for _, r := range request.Restrictions {
+				m.Restrictions[r.Type]++
+			}
+		}
+	}
+
+	return m
+}
+
+// Gaps returns uncovered combinations: requests and settlements per bet type, then restrictions
+func (m *Matrix) Gaps() []Gap {
+	gaps := make([]Gap, 0)
+
+	for _, betType := range BetTypes {
+		for _, requestType := range RequestTypes {
+			if m.Requests[betType][requestType] == 0 {
+				gaps = append(gaps, Gap{BetType: betType, RequestType: requestType})
+			}
+		}
+
+		for _, settleType := range SettleTypes {
+			if m.Settles[betType][settleType] == 0 {
+				gaps = append(gaps, Gap{BetType: betType, RequestType: callback.BetSettleRequestType, SettleType: settleType})
+			}
+		}
+	}
+
+	for _, restrictionType := range callback.GetAllBetRestrictions() {
+		if m.Restrictions[restrictionType] == 0 {
+			gaps = append(gaps, Gap{RestrictionType: restrictionType})
+		}
+	}
+
+	return gaps
+}
+
+// Total returns count of all tracked combinations
+func (m *Matrix) Total() int {
+	return len(BetTypes)*(len(RequestTypes)+len(SettleTypes)) + len(callback.GetAllBetRestrictions())
+}
+
+// Covered returns count of combinations with succeeded callbacks
+func (m *Matrix) Covered() int {
+	return m.Total() - len(m.Gaps())
+}
+
+// String formats the matrix without highlighting
+func (m *Matrix) String() string {
+	return m.Format(func(s string) string { return s })
+}
+
+// Format formats tables of the matrix, counts are printed for covered combinations
+// and highlighted gaps for uncovered ones
+func (m *Matrix) Format(highlight func(string) string) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Coverage %d/%d\n\n", m.Covered(), m.Total())
+
+	header := []string{"bet type"}
+	for _, requestType := range RequestTypes {
+		header = append(header, string(requestType))
+	}
+
+	for _, settleType := range SettleTypes {
+		header = append(header, "settle "+settleType.String())
+	}
+
+	requests := [][]string{header}
+
+	for _, betType := range BetTypes {
+		row := []string{betType.String()}
+		for _, requestType := range RequestTypes {
+			row = append(row, countCell(m.Requests[betType][requestType]))
+		}
+
+		for _, settleType := range SettleTypes {
+			row = append(row, countCell(m.Settles[betType][settleType]))
+		}
+
+		requests = append(requests, row)
+	}
+
+	writeTable(&sb, requests, highlight)
+	sb.WriteString("\n")
+
+	restrictionTypes := callback.GetAllBetRestrictions()
+	slices.Sort(restrictionTypes)
+
+	restrictions := [][]string{{"restriction", "declines"}}
+	for _, restrictionType := range restrictionTypes {
+		restrictions = append(restrictions, []string{string(restrictionType), countCell(m.Restrictions[restrictionType])})
+	}
+
+	writeTable(&sb, restrictions, highlight)
+
+	return sb.String()
+}
+
+const missingCell = "MISSING"
+
+func countCell(count int) string {
+	if count == 0 {
+		return missingCell
+	}
+
+	return strconv.Itoa(count)
+}
+
+// writeTable writes rows with padded columns, missing cells are highlighted after padding
+// so escape sequences of the highlight do not break the alignment
+func writeTable(sb *strings.Builder, rows [][]string, highlight func(string) string) {
+	widths := make([]int, len(rows[0]))
+
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	for _, row := range rows {
+		for i, cell := range row {
+			padding := ""
+			if i < len(row)-1 {
+				padding = strings.Repeat(" ", widths[i]-len(cell)+2)
+			}
+
+			if cell == missingCell {
+				cell = highlight(cell)
+			}
+
+			sb.WriteString(cell + padding)
+		}
+
+		sb.WriteString("\n")
+	}
+}
@@ -0,0 +1,87 @@
+package coverage
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+	"github.com/databet-cloud/callback-test-tool/internal/servicetest"
+	"github.com/databet-cloud/callback-test-tool/internal/storage"
+)
+
+func TestBuild(t *testing.T) {
+	source := testSource{
+		testExchange(callback.BetPlaceRequestType, http.StatusNoContent),
+		testExchange(callback.BetAcceptRequestType, http.StatusInternalServerError),
+		testExchange(callback.BetDeclineRequestType, http.StatusNoContent, callback.MaxBetRestriction),
+	}
+
+	m := Build(source)
+
+	assert.Equal(t, 1, m.Requests[callback.SingleBetType][callback.BetPlaceRequestType])
+	assert.Equal(t, 0, m.Requests[callback.SingleBetType][callback.BetAcceptRequestType])
+	assert.Equal(t, 1, m.Restrictions[callback.MaxBetRestriction])
+	assert.Equal(t, 53, m.Total())
+	assert.Equal(t, 3, m.Covered())
+	assert.Contains(t, m.Gaps(), Gap{BetType: callback.SingleBetType, RequestType: callback.BetAcceptRequestType})
+	assert.Contains(t, m.Gaps(), Gap{RestrictionType: callback.WLDefinedRestriction})
+
+	table := m.Format(func(s string) string { return "[" + s + "]" })
+	assert.Contains(t, table, "Coverage 3/53")
+	assert.Contains(t, table, "\nmax_bet                   1\n")
+	assert.Contains(t, table, "\nwl_defined                [MISSING]\n")
+}
+
+func TestDeclineCases(t *testing.T) {
+	m := Build(testSource{})
+	for _, restrictionType := range callback.GetAllBetRestrictions()[1:] {
+		m.Restrictions[restrictionType] = 1
+	}
+
+	assert.Equal(t, []declineCase{
+		{betType: callback.SingleBetType, restrictionType: callback.GetAllBetRestrictions()[0]},
+		{betType: callback.ExpressBetType, restrictionType: declineRestriction},
+		{betType: callback.SystemBetType, restrictionType: declineRestriction},
+	}, declineCases(m))
+}
+
+// TestFiller_Fill fills all gaps of the empty session and expects the full coverage
+func TestFiller_Fill(t *testing.T) {
+	sv := servicetest.NewService(t, servicetest.NewCallbackServer(t))
+
+	require.NoError(t, NewFiller(sv, apd.New(10, 0)).Fill(context.Background(), Build(sv)))
+
+	m := Build(sv)
+	assert.Empty(t, m.Gaps())
+	assert.Equal(t, m.Total(), m.Covered())
+}
+
+type testSource []*service.Exchange
+
+func (s testSource) Exchanges() []*storage.Document[*service.Exchange] {
+	docs := make([]*storage.Document[*service.Exchange], len(s))
+	for i, exchange := range s {
+		docs[i] = storage.NewDocument(exchange)
+	}
+
+	return docs
+}
+
+func testExchange(
+	requestType callback.RequestType,
+	statusCode int,
+	restrictions ...callback.RestrictionType,
+) *service.Exchange {
+	request := &callback.Data{RequestType: requestType, PrivateBetType: callback.SingleBetType}
+	for _, restrictionType := range restrictions {
+		request.Restrictions = append(request.Restrictions, callback.Restriction{Type: restrictionType})
+	}
+
+	return &service.Exchange{Request: request, StatusCode: statusCode}
+}
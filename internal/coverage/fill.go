@@ -0,0 +1,214 @@
+package coverage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/cockroachdb/apd/v3"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
+)
+
+// cashOutFraction - fraction of the stake of generated cash-out orders
+const cashOutFraction = 0.5
+
+// declineRestriction - restriction of generated declines which cover bet types only
+const declineRestriction = callback.InternalErrorRestriction
+
+var settleStatuses = map[callback.SettleType]sportsbook.OddStatus{
+	callback.WinSettleType:    sportsbook.OddStatusWin,
+	callback.RefundSettleType: sportsbook.OddStatusRefunded,
+	callback.LossSettleType:   sportsbook.OddStatusLoss,
+}
+
+// Filler generates callbacks for gaps of the matrix
+type Filler struct {
+	sv    *service.Service
+	stake *apd.Decimal
+}
+
+// NewFiller creates filler which places bets with the stake in the currency of the player
+func NewFiller(sv *service.Service, stake *apd.Decimal) *Filler {
+	return &Filler{sv: sv, stake: stake}
+}
+
+type declineCase struct {
+	betType         callback.BetType
+	restrictionType callback.RestrictionType
+}
+
+// Fill generates cases for gaps of the matrix: a lifecycle of the bet per bet type with missing requests
+// or settle types and a declined bet per missing restriction type. Failed cases do not stop other cases,
+// errors of all failed cases are returned
+func (f *Filler) Fill(ctx context.Context, m *Matrix) error {
+	if f.sv.AnswersAutomatically() {
+		return errors.New("gaps can not be filled while placed bets are answered automatically")
+	}
+
+	errs := make([]error, 0)
+
+	for _, betType := range BetTypes {
+		if !lifecycleRequired(m, betType) {
+			continue
+		}
+
+		if err := f.lifecycle(ctx, m, betType); err != nil {
+			errs = append(errs, fmt.Errorf("%s lifecycle: %w", betType, err))
+		}
+	}
+
+	for _, c := range declineCases(m) {
+		if err := f.decline(ctx, c); err != nil {
+			errs = append(errs, fmt.Errorf("%s decline with %s: %w", c.betType, c.restrictionType, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// lifecycleRequired reports whether the bet type has gaps which are covered by the lifecycle,
+// declines are covered separately
+func lifecycleRequired(m *Matrix, betType callback.BetType) bool {
+	for _, requestType := range RequestTypes {
+		if requestType != callback.BetDeclineRequestType && m.Requests[betType][requestType] == 0 {
+			return true
+		}
+	}
+
+	return len(missingSettles(m, betType)) > 0
+}
+
+func missingSettles(m *Matrix, betType callback.BetType) []callback.SettleType {
+	missing := make([]callback.SettleType, 0, len(SettleTypes))
+
+	for _, settleType := range SettleTypes {
+		if m.Settles[betType][settleType] == 0 {
+			missing = append(missing, settleType)
+		}
+	}
+
+	return missing
+}
+
+// lifecycle places and accepts the bet, accepts and declines cash-out orders when they are missing,
+// then settles the bet with every missing settle type, the bet is unsettled between settlements
+func (f *Filler) lifecycle(ctx context.Context, m *Matrix, betType callback.BetType) error {
+	bet, err := f.sv.PlaceBet(ctx, betType, f.stake)
+	if err != nil {
+		return err
+	}
+
+	if err := f.sv.AcceptBet(ctx, bet.BetID); err != nil {
+		return err
+	}
+
+	if m.Requests[betType][callback.BetCashOutOrdersAcceptedRequestType] == 0 {
+		order, err := f.sv.CreateCashOutOrder(ctx, bet.BetID, cashOutFraction)
+		if err != nil {
+			return err
+		}
+
+		if err := f.sv.AcceptCashOutOrder(ctx, order.ID); err != nil {
+			return err
+		}
+	}
+
+	if m.Requests[betType][callback.BetCashOutOrdersDeclinedRequestType] == 0 {
+		order, err := f.sv.CreateCashOutOrder(ctx, bet.BetID, cashOutFraction)
+		if err != nil {
+			return err
+		}
+
+		if err := f.sv.DeclineCashOutOrders(ctx, bet.BetID, []string{order.ID}); err != nil {
+			return err
+		}
+	}
+
+	settles := missingSettles(m, betType)
+	if len(settles) == 0 {
+		settles = []callback.SettleType{callback.LossSettleType}
+	}
+
+	// the unsettle needs a settlement after it to leave the bet settled
+	if m.Requests[betType][callback.BetUnSettleRequestType] == 0 && len(settles) == 1 {
+		settles = append(settles, settles[0])
+	}
+
+	for i, settleType := range settles {
+		if i > 0 {
+			if err := f.sv.UnSettleBet(ctx, bet.BetID); err != nil {
+				return err
+			}
+		}
+
+		if err := f.settle(ctx, bet, settleType); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// settle declares the same result of all selections, the result is chosen by the settle type
+func (f *Filler) settle(ctx context.Context, bet *callback.Data, settleType callback.SettleType) error {
+	odds := make([]*callback.Odd, len(bet.PrivateOdds))
+	for i, odd := range bet.PrivateOdds {
+		odds[i] = odd.WithStatus(settleStatuses[settleType], f.sv.Now())
+	}
+
+	return f.sv.SettleBet(ctx, bet.BetID, odds)
+}
+
+// declineCases assigns missing restriction types to bet types, bet types with missing declines go first,
+// bet types which are still missing declines are declined with the internal error
+func declineCases(m *Matrix) []declineCase {
+	missing := make([]callback.BetType, 0, len(BetTypes))
+	covered := make([]callback.BetType, 0, len(BetTypes))
+
+	for _, betType := range BetTypes {
+		if m.Requests[betType][callback.BetDeclineRequestType] == 0 {
+			missing = append(missing, betType)
+		} else {
+			covered = append(covered, betType)
+		}
+	}
+
+	betTypes := slices.Concat(missing, covered)
+	cases := make([]declineCase, 0)
+
+	for _, restrictionType := range callback.GetAllBetRestrictions() {
+		if m.Restrictions[restrictionType] == 0 {
+			cases = append(cases, declineCase{betType: betTypes[len(cases)%len(betTypes)], restrictionType: restrictionType})
+		}
+	}
+
+	for i := len(cases); i < len(missing); i++ {
+		cases = append(cases, declineCase{betType: missing[i], restrictionType: declineRestriction})
+	}
+
+	return cases
+}
+
+// decline places the bet and declines it with the default template of the restriction type
+func (f *Filler) decline(ctx context.Context, c declineCase) error {
+	tmpl, ok := f.sv.Restrictions().Default(c.restrictionType)
+	if !ok {
+		return fmt.Errorf("no templates of the restriction %s", c.restrictionType)
+	}
+
+	bet, err := f.sv.PlaceBet(ctx, c.betType, f.stake)
+	if err != nil {
+		return err
+	}
+
+	r, err := f.sv.DraftRestriction(bet.BetID, tmpl, bet.PrivateOdds[0])
+	if err != nil {
+		return err
+	}
+
+	return f.sv.DeclineBet(ctx, bet.BetID, []callback.Restriction{r})
+}
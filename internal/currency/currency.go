@@ -0,0 +1,75 @@
+package currency
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/apd/v3"
+)
+
+// Currency - ISO 4217 currency with count of digits after the decimal separator
+type Currency struct {
+	Code       string `json:"code"`
+	MinorUnits int32  `json:"minor_units"`
+}
+
+func New(code string, minorUnits int32) Currency {
+	return Currency{Code: code, MinorUnits: minorUnits}
+}
+
+func (c Currency) String() string {
+	return c.Code
+}
+
+// Parse parses amount and validates that it fits minor units of the currency
+func (c Currency) Parse(amount string) (*apd.Decimal, error) {
+	d, _, err := apd.NewFromString(strings.TrimSpace(amount))
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+
+	if err := c.Validate(d); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Validate checks that amount is finite, not negative and has no more digits than minor units of the currency
+func (c Currency) Validate(amount *apd.Decimal) error {
+	if amount.Form != apd.Finite || amount.Negative {
+		return fmt.Errorf("invalid amount %s", amount.Text('f'))
+	}
+
+	rounded, err := c.Round(amount)
+	if err != nil {
+		return err
+	}
+
+	if rounded.Cmp(amount) != 0 {
+		return fmt.Errorf("amount %s has more than %d minor units of %s", amount.Text('f'), c.MinorUnits, c.Code)
+	}
+
+	return nil
+}
+
+// Round rounds amount down to minor units of the currency
+func (c Currency) Round(amount *apd.Decimal) (*apd.Decimal, error) {
+	ctx := apd.BaseContext.WithPrecision(100)
+	ctx.Rounding = apd.RoundDown
+
+	rounded := new(apd.Decimal)
+	_, err := ctx.Quantize(rounded, amount, -c.MinorUnits)
+
+	return rounded, err
+}
+
+// Format formats amount with all minor units of the currency, amount is rounded down if needed
+func (c Currency) Format(amount *apd.Decimal) string {
+	rounded, err := c.Round(amount)
+	if err != nil {
+		return amount.Text('f')
+	}
+
+	return rounded.Text('f')
+}
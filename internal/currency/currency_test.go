@@ -0,0 +1,74 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrency_Parse(t *testing.T) {
+	testCases := []struct {
+		code     string
+		amount   string
+		expected string
+		isValid  bool
+	}{
+		{code: "EUR", amount: "10.25", expected: "10.25", isValid: true},
+		{code: "eur", amount: "0.1", expected: "0.10", isValid: true},
+		{code: "EUR", amount: "10.255", isValid: false},
+		{code: "JPY", amount: "100", expected: "100", isValid: true},
+		{code: "JPY", amount: "100.5", isValid: false},
+		{code: "KWD", amount: "1.125", expected: "1.125", isValid: true},
+		{code: "USD", amount: "-1", isValid: false},
+		{code: "USD", amount: "abc", isValid: false},
+	}
+
+	registry, err := DefaultRegistry()
+	assert.NoError(t, err)
+
+	for _, tc := range testCases {
+		cur, err := registry.Currency(tc.code)
+		assert.NoError(t, err)
+
+		amount, err := cur.Parse(tc.amount)
+		if !tc.isValid {
+			assert.Error(t, err, "%s %s", tc.code, tc.amount)
+			continue
+		}
+
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, cur.Format(amount))
+	}
+}
+
+func TestRegistry_Convert(t *testing.T) {
+	registry, err := DefaultRegistry()
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		from, to string
+		amount   string
+		expected string
+	}{
+		{from: "EUR", to: "EUR", amount: "10", expected: "10.00"},
+		{from: "EUR", to: "USD", amount: "10", expected: "10.80"},
+		{from: "USD", to: "EUR", amount: "10", expected: "9.25"},
+		{from: "EUR", to: "JPY", amount: "10.55", expected: "1709"},
+		{from: "USD", to: "KWD", amount: "100", expected: "30.740"},
+	}
+
+	for _, tc := range testCases {
+		from, err := registry.Currency(tc.from)
+		assert.NoError(t, err)
+
+		to, err := registry.Currency(tc.to)
+		assert.NoError(t, err)
+
+		amount, err := from.Parse(tc.amount)
+		assert.NoError(t, err)
+
+		converted, err := registry.Convert(amount, from, to)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, to.Format(converted), "%s %s -> %s", tc.amount, tc.from, tc.to)
+	}
+}
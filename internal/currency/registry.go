@@ -0,0 +1,138 @@
+package currency
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cockroachdb/apd/v3"
+)
+
+//go:embed currencies.json
+var defaultCurrencies []byte
+
+// Registry - known currencies with exchange rates to the base currency
+type Registry struct {
+	base       string
+	currencies map[string]Currency
+	// rates - count of units of the currency for one unit of the base currency
+	rates map[string]*apd.Decimal
+}
+
+type registryFile struct {
+	Base       string `json:"base"`
+	Currencies []struct {
+		Code       string `json:"code"`
+		MinorUnits int32  `json:"minor_units"`
+		Rate       string `json:"rate"`
+	} `json:"currencies"`
+}
+
+// DefaultRegistry returns registry of the embedded currencies.json
+func DefaultRegistry() (*Registry, error) {
+	return ParseRegistry(defaultCurrencies)
+}
+
+// LoadRegistry reads registry from the file in the format of the embedded currencies.json
+func LoadRegistry(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseRegistry(data)
+}
+
+func ParseRegistry(data []byte) (*Registry, error) {
+	var file registryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse currencies: %w", err)
+	}
+
+	r := &Registry{
+		base:       strings.ToUpper(file.Base),
+		currencies: make(map[string]Currency, len(file.Currencies)),
+		rates:      make(map[string]*apd.Decimal, len(file.Currencies)),
+	}
+
+	for _, c := range file.Currencies {
+		code := strings.ToUpper(c.Code)
+
+		if c.MinorUnits < 0 {
+			return nil, fmt.Errorf("invalid minor units of %s: %d", code, c.MinorUnits)
+		}
+
+		rate, _, err := apd.NewFromString(c.Rate)
+		if err != nil || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid rate of %s: %q", code, c.Rate)
+		}
+
+		r.currencies[code] = New(code, c.MinorUnits)
+		r.rates[code] = rate
+	}
+
+	if _, ok := r.currencies[r.base]; !ok {
+		return nil, fmt.Errorf("base currency %q is not in the list of currencies", file.Base)
+	}
+
+	return r, nil
+}
+
+func (r *Registry) Base() Currency {
+	return r.currencies[r.base]
+}
+
+// Currency returns currency by the code
+func (r *Registry) Currency(code string) (Currency, error) {
+	c, ok := r.currencies[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return Currency{}, fmt.Errorf("unknown currency %q, known currencies: %s", code, strings.Join(r.Codes(), ", "))
+	}
+
+	return c, nil
+}
+
+// Codes returns sorted codes of all currencies
+func (r *Registry) Codes() []string {
+	codes := make([]string, 0, len(r.currencies))
+	for code := range r.currencies {
+		codes = append(codes, code)
+	}
+
+	slices.Sort(codes)
+
+	return codes
+}
+
+// Convert converts amount through the base currency and rounds it down to minor units of the target currency
+func (r *Registry) Convert(amount *apd.Decimal, from, to Currency) (*apd.Decimal, error) {
+	if from.Code == to.Code {
+		return to.Round(amount)
+	}
+
+	fromRate, ok := r.rates[from.Code]
+	if !ok {
+		return nil, fmt.Errorf("unknown currency %q", from.Code)
+	}
+
+	toRate, ok := r.rates[to.Code]
+	if !ok {
+		return nil, fmt.Errorf("unknown currency %q", to.Code)
+	}
+
+	ctx := apd.BaseContext.WithPrecision(100)
+	converted := new(apd.Decimal)
+
+	if _, err := ctx.Quo(converted, amount, fromRate); err != nil {
+		return nil, err
+	}
+
+	if _, err := ctx.Mul(converted, converted, toRate); err != nil {
+		return nil, err
+	}
+
+	return to.Round(converted)
+}
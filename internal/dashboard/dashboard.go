@@ -0,0 +1,25 @@
+// Package dashboard serves the embedded web UI of the session, the UI reads and drives the session by the API
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// Prefix - path of the UI, the API is expected at the root of the same host
+const Prefix = "/dashboard/"
+
+//go:embed static
+var static embed.FS
+
+// Handler returns the handler of files of the UI, it is mounted at Prefix
+func Handler() http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		// the directory is embedded, so it always exists
+		panic(err)
+	}
+
+	return http.StripPrefix(Prefix, http.FileServer(http.FS(files)))
+}
@@ -0,0 +1,26 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle(Prefix, Handler())
+
+	for path, contentType := range map[string]string{
+		Prefix:               "text/html; charset=utf-8",
+		Prefix + "app.js":    "text/javascript; charset=utf-8",
+		Prefix + "style.css": "text/css; charset=utf-8",
+	} {
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code, path)
+		assert.Equal(t, contentType, recorder.Header().Get("Content-Type"), path)
+	}
+}
@@ -0,0 +1,320 @@
+'use strict';
+
+// states of bets are types of requests which changed the bet last
+const STATES = ['place', 'accept', 'decline', 'settle', 'unsettle', 'cash-out_accepted', 'cash-out_declined'];
+const STATUSES = ['WIN', 'LOSS', 'HALF_WIN', 'HALF_LOSS', 'REFUNDED', 'CANCELLED', 'NOT_RESULTED'];
+
+const session = {
+  bet: null,
+  restrictions: [],
+  refreshTimer: null,
+};
+
+const $ = (selector) => document.querySelector(selector);
+
+function element(tag, text, attributes = {}) {
+  const el = document.createElement(tag);
+  if (text !== undefined && text !== null) {
+    el.textContent = text;
+  }
+  for (const [name, value] of Object.entries(attributes)) {
+    el.setAttribute(name, value);
+  }
+  return el;
+}
+
+function row(...cells) {
+  const tr = element('tr');
+  for (const cell of cells) {
+    const td = element('td');
+    td.append(cell instanceof Node ? cell : String(cell ?? ''));
+    tr.append(td);
+  }
+  return tr;
+}
+
+function button(text, onClick) {
+  const b = element('button', text);
+  b.addEventListener('click', onClick);
+  return b;
+}
+
+function select(values, selected) {
+  const s = element('select');
+  for (const v of values) {
+    s.append(element('option', v, { value: v }));
+  }
+  s.value = selected;
+  return s;
+}
+
+function time(value) {
+  return value ? new Date(value).toLocaleTimeString() : '';
+}
+
+function showError(message) {
+  const el = $('#error');
+  el.textContent = message;
+  el.hidden = !message;
+}
+
+// api calls the API and reports failures, actions refresh the whole page state
+async function api(method, path, body) {
+  const response = await fetch(path, {
+    method,
+    // the API rejects POST requests without the JSON content type
+    headers: { 'Content-Type': 'application/json' },
+    body: body ? JSON.stringify(body) : undefined,
+  });
+  const result = await response.json();
+  if (!response.ok) {
+    throw new Error(`${method} ${path}: ${result.error}`);
+  }
+  return result;
+}
+
+async function action(method, path, body) {
+  try {
+    showError('');
+    const result = await api(method, path, body);
+    await refresh();
+    return result;
+  } catch (e) {
+    showError(e.message);
+    await refresh();
+    return null;
+  }
+}
+
+async function loadPlayer() {
+  const player = await api('GET', '/player');
+  const { available, hold } = player.balance;
+  const expires = player.token_expires_at && !player.token_expires_at.startsWith('0001')
+    ? `, token expires ${new Date(player.token_expires_at).toLocaleString()}` : '';
+  $('#player').textContent = `${player.id}: available ${available} ${player.currency}, hold ${hold} ${player.currency}${expires}`;
+}
+
+async function loadBets() {
+  const states = [...document.querySelectorAll('#state-filter input:checked')].map((i) => i.value);
+  const query = states.length === STATES.length ? '' : '?' + states.map((s) => `state=${encodeURIComponent(s)}`).join('&');
+  const bets = states.length === 0 ? [] : await api('GET', `/bets${query}`);
+
+  const tbody = $('#bets');
+  tbody.replaceChildren();
+  for (const bet of bets) {
+    const legs = bet.legs.map((l) => `${l.ratio} ${l.status}`).join(', ');
+    const tr = row(bet.id, bet.type, bet.stake, bet.state, legs, time(bet.created_at), time(bet.updated_at));
+    tr.classList.add('clickable', `state-${bet.state}`);
+    if (session.bet && session.bet.id === bet.id) {
+      tr.classList.add('selected');
+    }
+    tr.addEventListener('click', () => selectBet(bet.id));
+    tbody.append(tr);
+  }
+}
+
+async function loadSelections() {
+  const selections = await api('GET', '/selections');
+  const tbody = $('#selections');
+  tbody.replaceChildren();
+  for (const leg of selections) {
+    const status = select(STATUSES.filter((s) => s !== 'NOT_RESULTED'), 'WIN');
+    const resolve = button('Resolve', () => action('POST', '/selections/resolve', {
+      results: [{ sport_event_id: leg.sport_event_id, market_id: leg.market_id, odd_id: leg.odd_id, status: status.value }],
+    }));
+    const cell = element('span');
+    cell.append(status, resolve);
+    tbody.append(row(leg.sport_event_id, leg.market_id, leg.odd_id, leg.ratio, cell));
+  }
+}
+
+async function selectBet(id) {
+  session.bet = await api('GET', `/bets/${id}`);
+  await loadBet();
+  await loadBets();
+}
+
+async function loadBet() {
+  if (!session.bet) {
+    return;
+  }
+
+  const id = session.bet.id;
+  const [bet, orders, timeline] = await Promise.all([
+    api('GET', `/bets/${id}`),
+    api('GET', `/bets/${id}/cash-outs`),
+    api('GET', `/bets/${id}/timeline`),
+  ]);
+  session.bet = bet;
+
+  $('#bet-section').hidden = false;
+  $('#bet-id').textContent = `${bet.id} (${bet.type}, ${bet.stake}, ${bet.state})`;
+
+  const legs = $('#legs');
+  legs.replaceChildren();
+  for (const leg of bet.legs) {
+    const status = select(STATUSES, leg.status === 'NOT_RESULTED' ? 'WIN' : leg.status);
+    status.classList.add('leg-status');
+    legs.append(row(leg.sport_event_id, leg.market_id, leg.odd_id, leg.ratio, leg.status, status));
+  }
+
+  const legSelect = $('#decline-form select[name=leg]');
+  legSelect.replaceChildren(...bet.legs.map((leg, i) => element('option', `${i}: ${leg.odd_id}`, { value: i })));
+
+  const tbody = $('#cash-outs');
+  tbody.replaceChildren();
+  for (const order of orders) {
+    const actions = element('span');
+    if (order.state === 'created') {
+      actions.append(
+        button('Accept', () => action('POST', `/cash-outs/${order.id}/accept`)),
+        button('Decline', () => action('POST', `/bets/${id}/cash-outs/decline`, { order_ids: [order.id] })),
+      );
+    }
+    tbody.append(row(order.id, order.state, order.stake, order.amount, time(order.created_at), actions));
+  }
+
+  const steps = $('#timeline');
+  steps.replaceChildren();
+  for (const step of timeline.steps) {
+    const status = step.error ? `${step.status_code} ${step.error}` : step.status_code;
+    const tr = row(step.request_type, time(step.sent_at), status, step.available, step.hold);
+    tr.classList.toggle('failed', step.status_code !== 204 || !!step.error);
+    steps.append(tr);
+  }
+
+  $('#violations').replaceChildren(...(timeline.violations || []).map((v) => element('li', `${v.kind}: ${v.message}`)));
+}
+
+async function loadRestrictions() {
+  session.restrictions = await api('GET', '/restrictions');
+  const types = $('#decline-form select[name=type]');
+  types.replaceChildren(...session.restrictions.map((r) => element('option', r.type, { value: r.type })));
+  types.addEventListener('change', showVariants);
+  showVariants();
+}
+
+function showVariants() {
+  const type = $('#decline-form select[name=type]').value;
+  const restriction = session.restrictions.find((r) => r.type === type);
+  const variants = $('#decline-form select[name=variant]');
+  variants.replaceChildren(...(restriction ? restriction.variants : []).map((v) => element('option', v, { value: v })));
+}
+
+async function refresh() {
+  try {
+    await Promise.all([loadPlayer(), loadBets(), loadSelections(), loadBet()]);
+  } catch (e) {
+    showError(e.message);
+  }
+}
+
+function feedItem(exchange) {
+  const item = element('li');
+  item.classList.toggle('failed', !exchange.succeeded);
+
+  const summary = element('summary',
+    `${time(exchange.sent_at)} ${exchange.request_type} ${exchange.bet_id} → ${exchange.status_code || 'no response'}` +
+    ` (${exchange.duration_ms} ms)`);
+  const details = element('details');
+  details.append(summary);
+
+  details.append(element('h4', 'Request'), element('pre', JSON.stringify(exchange.body, null, 2)));
+  details.append(element('h4', 'Response'), element('pre', [
+    `status: ${exchange.status_code}`,
+    exchange.error ? `error: ${exchange.error}` : '',
+    exchange.response_body ? `body: ${exchange.response_body}` : '',
+  ].filter(Boolean).join('\n')));
+  details.append(element('h4', 'Expected balance'),
+    element('pre', `available ${exchange.expected_balance.available}, hold ${exchange.expected_balance.hold}`));
+  details.append(button('Replay', () => action('POST', `/requests/${exchange.request_id}/replay`)));
+
+  item.append(details);
+  return item;
+}
+
+// listenFeed prepends sent callbacks, the page state is refreshed because callbacks can be sent
+// by the policy or the risk engine without actions of the page
+function listenFeed() {
+  const feed = new EventSource('/exchanges/feed');
+  const state = $('#feed-state');
+
+  feed.onopen = () => { state.textContent = '(live)'; };
+  feed.onerror = () => { state.textContent = '(reconnecting)'; };
+  feed.addEventListener('exchange', (event) => {
+    $('#feed').prepend(feedItem(JSON.parse(event.data)));
+    scheduleRefresh();
+  });
+}
+
+// scheduleRefresh refreshes the page state once for a burst of callbacks, e.g. the history on connect
+function scheduleRefresh() {
+  clearTimeout(session.refreshTimer);
+  session.refreshTimer = setTimeout(refresh, 200);
+}
+
+function init() {
+  const filter = $('#state-filter');
+  for (const state of STATES) {
+    const label = element('label');
+    const input = element('input', null, { type: 'checkbox', value: state });
+    input.checked = true;
+    input.addEventListener('change', loadBets);
+    label.append(input, ` ${state}`);
+    filter.append(label);
+  }
+
+  $('#place-form').addEventListener('submit', async (event) => {
+    event.preventDefault();
+    const form = new FormData(event.target);
+    const bet = await action('POST', '/bets', { bet_type: form.get('bet_type'), stake: form.get('stake') });
+    if (bet) {
+      await selectBet(bet.id);
+    }
+  });
+
+  $('#decline-form').addEventListener('submit', (event) => {
+    event.preventDefault();
+    const form = new FormData(event.target);
+    const restriction = { type: form.get('type'), variant: form.get('variant'), leg: Number(form.get('leg')) };
+    if (form.get('context')) {
+      try {
+        restriction.context = JSON.parse(form.get('context'));
+      } catch (e) {
+        showError(`invalid context: ${e.message}`);
+        return;
+      }
+    }
+    action('POST', `/bets/${session.bet.id}/decline`, { restrictions: [restriction] });
+  });
+
+  $('#cash-out-form').addEventListener('submit', (event) => {
+    event.preventDefault();
+    const fraction = Number(new FormData(event.target).get('fraction'));
+    action('POST', `/bets/${session.bet.id}/cash-outs`, { fraction });
+  });
+
+  document.querySelectorAll('button[data-action]').forEach((b) => {
+    b.addEventListener('click', () => {
+      switch (b.dataset.action) {
+        case 'refresh-token':
+          return action('POST', '/player/token');
+        case 'accept':
+          return action('POST', `/bets/${session.bet.id}/accept`);
+        case 'unsettle':
+          return action('POST', `/bets/${session.bet.id}/unsettle`);
+        case 'settle': {
+          const statuses = [...document.querySelectorAll('.leg-status')].map((s) => s.value);
+          return action('POST', `/bets/${session.bet.id}/settle`, { statuses });
+        }
+      }
+      return null;
+    });
+  });
+
+  loadRestrictions().catch((e) => showError(e.message));
+  refresh();
+  listenFeed();
+}
+
+init();
@@ -8,6 +8,7 @@ import (
 
 const (
 	exitCommand          = "exit"
+	doneCommand          = "done"
 	defaultSelectionSize = 15
 )
 
@@ -43,6 +44,48 @@ func Select[T Stringer](label string, values ...T) (value T, err error) {
 	return values[i], nil
 }
 
+// MultiSelect - selection of many values, every selection toggles the value until "done" is selected
+func MultiSelect[T Stringer](label string, values ...T) ([]T, error) {
+	selected := make([]bool, len(values))
+
+	for {
+		keys := make([]string, 0, len(values)+1)
+		keys = append(keys, doneCommand)
+
+		for i, v := range values {
+			mark := "[ ]"
+			if selected[i] {
+				mark = "[x]"
+			}
+
+			keys = append(keys, fmt.Sprintf("%s %s", mark, v.String()))
+		}
+
+		selection := promptui.Select{Label: label, Items: keys, Size: defaultSelectionSize}
+
+		i, _, err := selection.Run()
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			break
+		}
+
+		selected[i-1] = !selected[i-1]
+	}
+
+	result := make([]T, 0, len(values))
+
+	for i, v := range values {
+		if selected[i] {
+			result = append(result, v)
+		}
+	}
+
+	return result, nil
+}
+
 func ProcessCommands(tree *Tree) {
 	list := tree.Commands()
 	commands := make(map[string]*Command, len(list))
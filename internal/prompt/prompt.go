@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/cockroachdb/apd/v3"
 	"github.com/manifoldco/promptui"
 )
 
@@ -31,3 +32,37 @@ func Float(label string) float64 {
 
 	return value
 }
+
+// String asks for the value which passes validation, validate may be nil
+func String(label string, validate func(string) error) (string, error) {
+	prompt := &promptui.Prompt{
+		Label:    label,
+		Validate: validate,
+	}
+
+	return prompt.Run()
+}
+
+// Decimal asks for the decimal value which is parsed by parse function
+func Decimal(label string, parse func(string) (*apd.Decimal, error)) (*apd.Decimal, error) {
+	valueStr, err := String(label, func(input string) error {
+		_, err := parse(input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(valueStr)
+}
+
+// Edit asks for the value with the current value as editable default
+func Edit(label, value string) (string, error) {
+	prompt := &promptui.Prompt{
+		Label:     label,
+		Default:   value,
+		AllowEdit: true,
+	}
+
+	return prompt.Run()
+}
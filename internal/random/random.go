@@ -0,0 +1,111 @@
+package random
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/xid"
+)
+
+// Rand - source of randomness and IDs of the session, the same seed produces the same sequence of values.
+// Rand is safe for concurrent use, but the sequence depends on the order of calls.
+type Rand struct {
+	seed int64
+
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func New(seed int64) *Rand {
+	return &Rand{
+		seed: seed,
+		r:    rand.New(rand.NewSource(seed)), //nolint:gosec // reproducible randomness is required
+	}
+}
+
+// NewSeed returns a seed for a session without an explicit seed
+func NewSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+func (r *Rand) Seed() int64 {
+	return r.seed
+}
+
+// Fork returns an independent Rand seeded by this one, calls of the fork do not change this sequence
+func (r *Rand) Fork() *Rand {
+	return New(r.Int63())
+}
+
+func (r *Rand) Int63() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.r.Int63()
+}
+
+func (r *Rand) Int63n(n int64) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.r.Int63n(n)
+}
+
+func (r *Rand) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.r.Intn(n)
+}
+
+func (r *Rand) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.r.Float64()
+}
+
+func (r *Rand) Perm(n int) []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.r.Perm(n)
+}
+
+// Read fills p with random bytes, it never returns an error
+func (r *Rand) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.r.Read(p)
+}
+
+// UUID returns random version 4 UUID
+func (r *Rand) UUID() string {
+	id, err := uuid.NewRandomFromReader(r)
+	if err != nil {
+		// Read never fails
+		panic(err)
+	}
+
+	return id.String()
+}
+
+// XID returns xid of the time with random machine, process and counter parts
+func (r *Rand) XID(t time.Time) string {
+	var b [12]byte
+
+	binary.BigEndian.PutUint32(b[:4], uint32(t.Unix()))
+	_, _ = r.Read(b[4:])
+
+	id, err := xid.FromBytes(b[:])
+	if err != nil {
+		// length of bytes is always valid
+		panic(err)
+	}
+
+	return id.String()
+}
@@ -0,0 +1,31 @@
+package random
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/xid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRand_Reproducible(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	generate := func(r *Rand) []any {
+		return []any{r.UUID(), r.XID(createdAt), r.Intn(100), r.Float64(), r.Fork().UUID(), r.UUID()}
+	}
+
+	first, second := generate(New(42)), generate(New(42))
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, generate(New(43)))
+
+	parsedUUID, err := uuid.Parse(first[0].(string))
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(4), parsedUUID.Version())
+
+	parsedXID, err := xid.FromString(first[1].(string))
+	require.NoError(t, err)
+	assert.True(t, createdAt.Equal(parsedXID.Time()))
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+type Format string
+
+const (
+	// JUnitFormat - JUnit XML for CI, every lifecycle is a test case
+	JUnitFormat Format = "junit"
+	// MarkdownFormat - Markdown for tickets
+	MarkdownFormat Format = "markdown"
+	// HTMLFormat - self-contained HTML page for partners
+	HTMLFormat Format = "html"
+)
+
+func Formats() []Format {
+	return []Format{JUnitFormat, MarkdownFormat, HTMLFormat}
+}
+
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats() {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown report format %q", name)
+}
+
+func (f Format) String() string {
+	return string(f)
+}
+
+// Extension returns extension of the report file
+func (f Format) Extension() string {
+	switch f {
+	case JUnitFormat:
+		return ".xml"
+	case MarkdownFormat:
+		return ".md"
+	case HTMLFormat:
+		return ".html"
+	default:
+		return ""
+	}
+}
+
+// Write writes the report in the format
+func (r *Report) Write(w io.Writer, format Format) error {
+	switch format {
+	case JUnitFormat:
+		return r.WriteJUnit(w)
+	case MarkdownFormat:
+		return r.WriteMarkdown(w)
+	case HTMLFormat:
+		return r.WriteHTML(w)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+// WriteFile writes the report in the format to the file
+func (r *Report) WriteFile(path string, format Format) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := r.Write(f, format); err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
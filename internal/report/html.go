@@ -0,0 +1,25 @@
+package report
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"time"
+)
+
+//go:embed report.html.tmpl
+var rawHTMLTemplate string
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"result": result,
+	"status": status,
+	"time":   func(t time.Time) string { return t.Format(time.RFC3339) },
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Millisecond).String()
+	},
+}).Parse(rawHTMLTemplate))
+
+// WriteHTML writes the report as the self-contained HTML page without external resources
+func (r *Report) WriteHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, r)
+}
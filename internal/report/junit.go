@@ -0,0 +1,127 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes lifecycles and balance checks as JUnit test cases
+func (r *Report) WriteJUnit(w io.Writer) error {
+	timestamp := r.GeneratedAt.Format("2006-01-02T15:04:05")
+
+	lifecycles := junitTestSuite{Name: "lifecycles", Timestamp: timestamp}
+
+	for _, l := range r.Lifecycles {
+		seconds := 0.0
+		steps := make([]string, 0, len(l.Steps))
+
+		for _, s := range l.Steps {
+			seconds += s.Duration.Seconds()
+			steps = append(steps, fmt.Sprintf(
+				"%s %s status=%d available=%s hold=%s", s.RequestType, s.RequestID, s.StatusCode, s.Available, s.Hold,
+			))
+		}
+
+		testCase := junitTestCase{
+			Name:      fmt.Sprintf("%s %s", l.BetType, l.BetID),
+			ClassName: "lifecycles",
+			Time:      fmt.Sprintf("%.3f", seconds),
+			Failure:   junitFailureOf(l.Violations),
+			SystemOut: strings.Join(steps, "\n"),
+		}
+
+		lifecycles.Cases = append(lifecycles.Cases, testCase)
+	}
+
+	balance := junitTestSuite{
+		Name:      "balance",
+		Timestamp: timestamp,
+		Cases: []junitTestCase{{
+			Name:      "balance",
+			ClassName: "balance",
+			Time:      "0.000",
+			Failure:   junitFailureOf(r.BalanceViolations),
+			SystemOut: fmt.Sprintf("available=%s hold=%s open_stake=%s", r.Available, r.Hold, r.OpenStake),
+		}},
+	}
+
+	suites := junitTestSuites{Name: "callback-test-tool " + r.PlayerID}
+
+	for _, suite := range []junitTestSuite{lifecycles, balance} {
+		suite.Tests = len(suite.Cases)
+
+		for _, c := range suite.Cases {
+			if c.Failure != nil {
+				suite.Failures++
+			}
+		}
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+func junitFailureOf(violations []Violation) *junitFailure {
+	if len(violations) == 0 {
+		return nil
+	}
+
+	lines := make([]string, 0, len(violations))
+	for _, v := range violations {
+		lines = append(lines, v.String())
+	}
+
+	return &junitFailure{
+		Message: violations[0].String(),
+		Type:    string(violations[0].Kind),
+		Text:    strings.Join(lines, "\n"),
+	}
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteMarkdown writes the report as Markdown tables
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Callback test report: %s\n\n", result(r.Passed()))
+	fmt.Fprintf(&b, "| Player | Currency | Available | Hold | Open stake | Lifecycles | Callbacks | Failed callbacks |\n")
+	fmt.Fprintf(&b, "|---|---|---|---|---|---|---|---|\n")
+	fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %d/%d passed | %d | %d |\n\n",
+		r.PlayerID, r.Currency, r.Available, r.Hold, r.OpenStake,
+		r.PassedLifecycles(), len(r.Lifecycles), r.Exchanges, r.Failed,
+	)
+	fmt.Fprintf(&b, "Generated at %s\n\n", r.GeneratedAt.Format(time.RFC3339))
+
+	if violations := r.Violations(); len(violations) > 0 {
+		b.WriteString("## Violations\n\n| Kind | Bet | Request | Message |\n|---|---|---|---|\n")
+
+		for _, v := range violations {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", v.Kind, v.BetID, v.RequestID, markdownEscape(v.Message))
+		}
+
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Lifecycles\n")
+
+	for _, l := range r.Lifecycles {
+		fmt.Fprintf(&b, "\n### %s %s %s: %s\n\n", result(l.Passed()), l.BetType, l.BetID, l.State)
+		fmt.Fprintf(&b, "Stake: %s\n\n", l.Stake)
+		b.WriteString("| Request | Request ID | Sent at | Duration | Status | Expected available | Expected hold |\n")
+		b.WriteString("|---|---|---|---|---|---|---|\n")
+
+		for _, s := range l.Steps {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n",
+				s.RequestType, s.RequestID, s.SentAt.Format(time.RFC3339), s.Duration.Round(time.Millisecond),
+				markdownEscape(status(s)), s.Available, s.Hold,
+			)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func result(passed bool) string {
+	if passed {
+		return "PASSED"
+	}
+
+	return "FAILED"
+}
+
+// status returns status code of the step with the error
+func status(s Step) string {
+	switch {
+	case s.Error == "":
+		return fmt.Sprint(s.StatusCode)
+	case s.StatusCode == 0:
+		return s.Error
+	default:
+		return fmt.Sprintf("%d %s", s.StatusCode, s.Error)
+	}
+}
+
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
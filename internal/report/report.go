@@ -0,0 +1,331 @@
+// Package report summarizes the session for the partner: lifecycles of bets, results of callbacks,
+// expected balances and violations of the callback contract
+package report
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+
+	"github.com/databet-cloud/callback-test-tool/internal/balance"
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/currency"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+	"github.com/databet-cloud/callback-test-tool/internal/storage"
+)
+
+// Source - session to report, it is implemented by service.Service
+type Source interface {
+	PlayerID() string
+	PlayerCurrency() currency.Currency
+	PlayerBalance() balance.Balance
+	Exchanges() []*storage.Document[*service.Exchange]
+	Bets(types ...callback.RequestType) []*storage.Document[*callback.Data]
+}
+
+type ViolationKind string
+
+const (
+	// UnexpectedStatus - the callback server responded with a status other than 204 or did not respond
+	UnexpectedStatus ViolationKind = "unexpected_status"
+	// InvalidTransition - the request is not allowed in the current state of the bet
+	InvalidTransition ViolationKind = "invalid_transition"
+	// UnansweredBet - the placed bet is neither accepted nor declined
+	UnansweredBet ViolationKind = "unanswered_bet"
+	// NegativeBalance - expected balance of the player is negative
+	NegativeBalance ViolationKind = "negative_balance"
+	// HoldMismatch - hold of the player differs from the sum of stakes of open bets
+	HoldMismatch ViolationKind = "hold_mismatch"
+)
+
+type Violation struct {
+	Kind      ViolationKind `json:"kind"`
+	BetID     string        `json:"bet_id,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+	Message   string        `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Kind, v.Message)
+}
+
+// Step - callback of the bet lifecycle
+type Step struct {
+	RequestType callback.RequestType `json:"request_type"`
+	RequestID   string               `json:"request_id"`
+	SentAt      time.Time            `json:"sent_at"`
+	Duration    time.Duration        `json:"duration"`
+	StatusCode  int                  `json:"status_code"`
+	Error       string               `json:"error,omitempty"`
+	// Available, Hold - expected balance of the player after the request
+	Available string `json:"available"`
+	Hold      string `json:"hold"`
+}
+
+func (s Step) Succeeded() bool {
+	return s.Error == "" && s.StatusCode == http.StatusNoContent
+}
+
+// Lifecycle - all callbacks of the bet
+type Lifecycle struct {
+	BetID   string               `json:"bet_id"`
+	BetType callback.BetType     `json:"bet_type"`
+	Stake   string               `json:"stake"`
+	State   callback.RequestType `json:"state"`
+	Steps   []Step               `json:"steps"`
+	// Violations - violations of the bet
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+func (l *Lifecycle) Passed() bool {
+	return len(l.Violations) == 0
+}
+
+type Report struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	PlayerID    string    `json:"player_id"`
+	Currency    string    `json:"currency"`
+	// Available, Hold - final expected balance of the player
+	Available string `json:"available"`
+	Hold      string `json:"hold"`
+	// OpenStake - sum of stakes of open bets which is expected to be on hold
+	OpenStake  string       `json:"open_stake"`
+	Exchanges  int          `json:"exchanges"`
+	Failed     int          `json:"failed"`
+	Lifecycles []*Lifecycle `json:"lifecycles"`
+	// BalanceViolations - violations which do not belong to a bet
+	BalanceViolations []Violation `json:"balance_violations,omitempty"`
+}
+
+// Passed reports whether the session has no violations
+func (r *Report) Passed() bool {
+	return len(r.Violations()) == 0
+}
+
+// Violations returns violations of all lifecycles and balance violations
+func (r *Report) Violations() []Violation {
+	violations := make([]Violation, 0)
+
+	for _, l := range r.Lifecycles {
+		violations = append(violations, l.Violations...)
+	}
+
+	return append(violations, r.BalanceViolations...)
+}
+
+// PassedLifecycles returns count of lifecycles without violations
+func (r *Report) PassedLifecycles() int {
+	passed := 0
+
+	for _, l := range r.Lifecycles {
+		if l.Passed() {
+			passed++
+		}
+	}
+
+	return passed
+}
+
+// transitions - request types allowed in the state of the bet, the empty state is the bet before the place
+var transitions = map[callback.RequestType][]callback.RequestType{
+	"": {callback.BetPlaceRequestType},
+	callback.BetPlaceRequestType: {
+		callback.BetAcceptRequestType,
+		callback.BetDeclineRequestType,
+	},
+	callback.BetAcceptRequestType: {
+		callback.BetSettleRequestType,
+		callback.BetCashOutOrdersAcceptedRequestType,
+		callback.BetCashOutOrdersDeclinedRequestType,
+	},
+	callback.BetCashOutOrdersAcceptedRequestType: {
+		callback.BetSettleRequestType,
+		callback.BetCashOutOrdersAcceptedRequestType,
+		callback.BetCashOutOrdersDeclinedRequestType,
+	},
+	callback.BetCashOutOrdersDeclinedRequestType: {
+		callback.BetSettleRequestType,
+		callback.BetCashOutOrdersAcceptedRequestType,
+		callback.BetCashOutOrdersDeclinedRequestType,
+	},
+	callback.BetSettleRequestType: {
+		callback.BetUnSettleRequestType,
+	},
+	callback.BetUnSettleRequestType: {
+		callback.BetSettleRequestType,
+		callback.BetCashOutOrdersAcceptedRequestType,
+		callback.BetCashOutOrdersDeclinedRequestType,
+	},
+}
+
+// Build builds the report of the session
+func Build(source Source, generatedAt time.Time) (*Report, error) {
+	cur := source.PlayerCurrency()
+	state := source.PlayerBalance()
+
+	report := &Report{
+		GeneratedAt: generatedAt,
+		PlayerID:    source.PlayerID(),
+		Currency:    cur.Code,
+		Available:   cur.Format(state.Available),
+		Hold:        cur.Format(state.Hold),
+	}
+
+	lifecycles := make(map[string]*Lifecycle)
+
+	for _, doc := range source.Exchanges() {
+		exchange := doc.Value
+		request := exchange.Request
+		report.Exchanges++
+
+		lifecycle, ok := lifecycles[request.BetID]
+		if !ok {
+			lifecycle = &Lifecycle{BetID: request.BetID, BetType: request.PrivateBetType}
+			if request.PrivateStake != nil {
+				lifecycle.Stake = cur.Format(request.PrivateStake)
+			}
+
+			lifecycles[request.BetID] = lifecycle
+			report.Lifecycles = append(report.Lifecycles, lifecycle)
+		}
+
+		addStep(lifecycle, exchange, cur)
+
+		if !exchange.Succeeded() {
+			report.Failed++
+		}
+	}
+
+	for _, lifecycle := range report.Lifecycles {
+		if lifecycle.State == callback.BetPlaceRequestType {
+			lifecycle.Violations = append(lifecycle.Violations, Violation{
+				Kind:    UnansweredBet,
+				BetID:   lifecycle.BetID,
+				Message: "placed bet is neither accepted nor declined",
+			})
+		}
+	}
+
+	openStake, err := openStake(source)
+	if err != nil {
+		return nil, err
+	}
+
+	report.OpenStake = cur.Format(openStake)
+	report.BalanceViolations = balanceViolations(state, openStake, cur)
+
+	return report, nil
+}
+
+// addStep adds the exchange to the lifecycle, only succeeded requests change the state of the bet
+func addStep(lifecycle *Lifecycle, exchange *service.Exchange, cur currency.Currency) {
+	request := exchange.Request
+
+	step := Step{
+		RequestType: request.RequestType,
+		RequestID:   request.RequestID,
+		SentAt:      exchange.SentAt,
+		Duration:    exchange.Duration,
+		StatusCode:  exchange.StatusCode,
+		Error:       exchange.Error,
+		Available:   cur.Format(exchange.ExpectedBalance.Available),
+		Hold:        cur.Format(exchange.ExpectedBalance.Hold),
+	}
+
+	// replayed request has the request ID of the previous step, it does not change the state
+	replayed := slices.ContainsFunc(lifecycle.Steps, func(s Step) bool { return s.RequestID == step.RequestID })
+
+	lifecycle.Steps = append(lifecycle.Steps, step)
+
+	if !step.Succeeded() {
+		message := step.Error
+		if message == "" {
+			message = fmt.Sprintf("status code %d", step.StatusCode)
+		}
+
+		lifecycle.Violations = append(lifecycle.Violations, Violation{
+			Kind:      UnexpectedStatus,
+			BetID:     lifecycle.BetID,
+			RequestID: step.RequestID,
+			Message:   fmt.Sprintf("%s: %s", step.RequestType, message),
+		})
+
+		return
+	}
+
+	if replayed {
+		return
+	}
+
+	if !slices.Contains(transitions[lifecycle.State], step.RequestType) {
+		lifecycle.Violations = append(lifecycle.Violations, Violation{
+			Kind:      InvalidTransition,
+			BetID:     lifecycle.BetID,
+			RequestID: step.RequestID,
+			Message:   fmt.Sprintf("%s is not allowed after %q", step.RequestType, lifecycle.State),
+		})
+	}
+
+	lifecycle.State = step.RequestType
+}
+
+// openStake returns sum of stakes of open bets which are not cashed out
+func openStake(source Source) (*apd.Decimal, error) {
+	ctx := apd.BaseContext.WithPrecision(100)
+	total := new(apd.Decimal)
+
+	open := source.Bets(
+		callback.BetPlaceRequestType,
+		callback.BetAcceptRequestType,
+		callback.BetUnSettleRequestType,
+		callback.BetCashOutOrdersAcceptedRequestType,
+		callback.BetCashOutOrdersDeclinedRequestType,
+	)
+
+	for _, doc := range open {
+		if _, err := ctx.Add(total, total, doc.Value.PrivateStake); err != nil {
+			return nil, err
+		}
+
+		if doc.Value.PrivateCashOutStake == nil {
+			continue
+		}
+
+		if _, err := ctx.Sub(total, total, doc.Value.PrivateCashOutStake); err != nil {
+			return nil, err
+		}
+	}
+
+	return total, nil
+}
+
+func balanceViolations(state balance.Balance, openStake *apd.Decimal, cur currency.Currency) []Violation {
+	violations := make([]Violation, 0)
+
+	if state.Available.Sign() < 0 {
+		violations = append(violations, Violation{
+			Kind:    NegativeBalance,
+			Message: fmt.Sprintf("available balance is %s", cur.Format(state.Available)),
+		})
+	}
+
+	if state.Hold.Sign() < 0 {
+		violations = append(violations, Violation{
+			Kind:    NegativeBalance,
+			Message: fmt.Sprintf("hold is %s", cur.Format(state.Hold)),
+		})
+	}
+
+	rounded, err := cur.Round(openStake)
+	if err != nil || rounded.Cmp(state.Hold) != 0 {
+		violations = append(violations, Violation{
+			Kind:    HoldMismatch,
+			Message: fmt.Sprintf("hold is %s, stakes of open bets are %s", cur.Format(state.Hold), cur.Format(openStake)),
+		})
+	}
+
+	return violations
+}
@@ -0,0 +1,156 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/databet-cloud/callback-test-tool/internal/balance"
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/currency"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+	"github.com/databet-cloud/callback-test-tool/internal/storage"
+)
+
+func TestBuild(t *testing.T) {
+	source := testSource{
+		hold: apd.New(15, 0),
+		bets: []*callback.Data{
+			testRequest(callback.BetSettleRequestType, "settled", "r3"),
+			testRequest(callback.BetPlaceRequestType, "unanswered", "r4"),
+		},
+		exchanges: []*service.Exchange{
+			testExchange(callback.BetPlaceRequestType, "settled", "r1", http.StatusNoContent),
+			testExchange(callback.BetAcceptRequestType, "settled", "r2", http.StatusNoContent),
+			testExchange(callback.BetSettleRequestType, "settled", "r3", http.StatusNoContent),
+			// replay of the settle does not change the state
+			testExchange(callback.BetSettleRequestType, "settled", "r3", http.StatusNoContent),
+			testExchange(callback.BetPlaceRequestType, "unanswered", "r4", http.StatusNoContent),
+			testExchange(callback.BetAcceptRequestType, "unanswered", "r5", http.StatusInternalServerError),
+			testExchange(callback.BetPlaceRequestType, "declined", "r6", http.StatusNoContent),
+			testExchange(callback.BetDeclineRequestType, "declined", "r7", http.StatusNoContent),
+			testExchange(callback.BetSettleRequestType, "declined", "r8", http.StatusNoContent),
+		},
+	}
+
+	report, err := Build(source, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	assert.False(t, report.Passed())
+	assert.Equal(t, 9, report.Exchanges)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, "10.00", report.OpenStake)
+	require.Len(t, report.Lifecycles, 3)
+
+	settled, unanswered, declined := report.Lifecycles[0], report.Lifecycles[1], report.Lifecycles[2]
+
+	assert.True(t, settled.Passed())
+	assert.Equal(t, callback.BetSettleRequestType, settled.State)
+	assert.Len(t, settled.Steps, 4)
+
+	assert.Equal(t, []ViolationKind{UnexpectedStatus, UnansweredBet}, kinds(unanswered.Violations))
+	assert.Equal(t, []ViolationKind{InvalidTransition}, kinds(declined.Violations))
+	assert.Equal(t, []ViolationKind{HoldMismatch}, kinds(report.BalanceViolations))
+
+	var junit bytes.Buffer
+	require.NoError(t, report.Write(&junit, JUnitFormat))
+
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(junit.Bytes(), &suites))
+	assert.Equal(t, 4, suites.Tests)
+	assert.Equal(t, 3, suites.Failures)
+
+	var markdown bytes.Buffer
+	require.NoError(t, report.Write(&markdown, MarkdownFormat))
+	assert.True(t, strings.HasPrefix(markdown.String(), "# Callback test report: FAILED"))
+	assert.Contains(t, markdown.String(), "| accept | r5 |")
+
+	var html bytes.Buffer
+	require.NoError(t, report.Write(&html, HTMLFormat))
+	assert.Contains(t, html.String(), `<tr class="failed"><td>hold_mismatch</td>`)
+	assert.NotContains(t, html.String(), "http://")
+	assert.NotContains(t, html.String(), "https://")
+}
+
+func kinds(violations []Violation) []ViolationKind {
+	result := make([]ViolationKind, len(violations))
+	for i, v := range violations {
+		result[i] = v.Kind
+	}
+
+	return result
+}
+
+type testSource struct {
+	hold      *apd.Decimal
+	bets      []*callback.Data
+	exchanges []*service.Exchange
+}
+
+func (testSource) PlayerID() string {
+	return "player-1"
+}
+
+func (testSource) PlayerCurrency() currency.Currency {
+	return currency.New("EUR", 2)
+}
+
+func (s testSource) PlayerBalance() balance.Balance {
+	return balance.Balance{Currency: "EUR", Available: apd.New(90, 0), Hold: s.hold}
+}
+
+func (s testSource) Exchanges() []*storage.Document[*service.Exchange] {
+	docs := make([]*storage.Document[*service.Exchange], len(s.exchanges))
+	for i, e := range s.exchanges {
+		docs[i] = storage.NewDocument(e)
+	}
+
+	return docs
+}
+
+func (s testSource) Bets(types ...callback.RequestType) []*storage.Document[*callback.Data] {
+	docs := make([]*storage.Document[*callback.Data], 0, len(s.bets))
+
+	for _, bet := range s.bets {
+		for _, t := range types {
+			if bet.RequestType == t {
+				docs = append(docs, storage.NewDocument(bet))
+			}
+		}
+	}
+
+	return docs
+}
+
+func testRequest(requestType callback.RequestType, betID, requestID string) *callback.Data {
+	return &callback.Data{
+		RequestType:    requestType,
+		PrivateStake:   apd.New(10, 0),
+		PrivateBetType: callback.SingleBetType,
+		RequestID:      requestID,
+		BetID:          betID,
+	}
+}
+
+func testExchange(requestType callback.RequestType, betID, requestID string, statusCode int) *service.Exchange {
+	exchange := &service.Exchange{
+		Request:         testRequest(requestType, betID, requestID),
+		SentAt:          time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC),
+		Duration:        20 * time.Millisecond,
+		StatusCode:      statusCode,
+		ExpectedBalance: balance.Balance{Currency: "EUR", Available: apd.New(90, 0), Hold: apd.New(10, 0)},
+	}
+
+	if statusCode != http.StatusNoContent {
+		exchange.Error = (&callback.StatusError{StatusCode: statusCode, Body: "internal error"}).Error()
+	}
+
+	return exchange
+}
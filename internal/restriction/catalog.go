@@ -0,0 +1,250 @@
+package restriction
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/cockroachdb/apd/v3"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/currency"
+)
+
+//go:embed restrictions.json
+var defaultRestrictions []byte
+
+// DefaultName - name of the template without an explicit name
+const DefaultName = "default"
+
+// Catalog - templates of restrictions, every restriction type can have many named variants of the context
+type Catalog struct {
+	templates []*Template
+}
+
+// Template - restriction with context values, string values are text/template templates executed with Data
+type Template struct {
+	Type    callback.RestrictionType `json:"type"`
+	Name    string                   `json:"name"`
+	Context map[string]any           `json:"context"`
+
+	templates map[string]*template.Template
+}
+
+type catalogFile struct {
+	Restrictions []*Template `json:"restrictions"`
+}
+
+// DefaultCatalog returns catalog of the embedded restrictions.json
+func DefaultCatalog() (*Catalog, error) {
+	return ParseCatalog(defaultRestrictions)
+}
+
+// LoadCatalog returns default catalog extended by the file,
+// templates of the file replace default templates with the same type and name
+func LoadCatalog(path string) (*Catalog, error) {
+	catalog, err := DefaultCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	custom, err := ParseCatalog(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for _, t := range custom.templates {
+		catalog.put(t)
+	}
+
+	return catalog, nil
+}
+
+func ParseCatalog(data []byte) (*Catalog, error) {
+	var file catalogFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse restrictions: %w", err)
+	}
+
+	catalog := &Catalog{templates: make([]*Template, 0, len(file.Restrictions))}
+
+	for _, t := range file.Restrictions {
+		if err := t.parse(); err != nil {
+			return nil, err
+		}
+
+		catalog.put(t)
+	}
+
+	return catalog, nil
+}
+
+// Types returns restriction types in the order of the catalog
+func (c *Catalog) Types() []callback.RestrictionType {
+	types := make([]callback.RestrictionType, 0, len(c.templates))
+
+	for _, t := range c.templates {
+		if !slices.Contains(types, t.Type) {
+			types = append(types, t.Type)
+		}
+	}
+
+	return types
+}
+
+// Variants returns all templates of the restriction type
+func (c *Catalog) Variants(restrictionType callback.RestrictionType) []*Template {
+	variants := make([]*Template, 0, 1)
+
+	for _, t := range c.templates {
+		if t.Type == restrictionType {
+			variants = append(variants, t)
+		}
+	}
+
+	return variants
+}
+
+// Default returns the default template of the restriction type, the first variant is used if there is no default
+func (c *Catalog) Default(restrictionType callback.RestrictionType) (*Template, bool) {
+	variants := c.Variants(restrictionType)
+	if len(variants) == 0 {
+		return nil, false
+	}
+
+	for _, t := range variants {
+		if t.Name == DefaultName {
+			return t, true
+		}
+	}
+
+	return variants[0], true
+}
+
+func (c *Catalog) put(t *Template) {
+	for i, existing := range c.templates {
+		if existing.Type == t.Type && existing.Name == t.Name {
+			c.templates[i] = t
+			return
+		}
+	}
+
+	c.templates = append(c.templates, t)
+}
+
+func (t *Template) String() string {
+	return fmt.Sprintf("%s:%s", t.Type, t.Name)
+}
+
+// Render executes context templates, a value which is the single call of the "value" function keeps its type
+func (t *Template) Render(data Data) (callback.Restriction, error) {
+	restriction := callback.Restriction{
+		Type:    t.Type,
+		Context: make(map[string]interface{}, len(t.Context)),
+	}
+
+	for key, raw := range t.Context {
+		tmpl, ok := t.templates[key]
+		if !ok {
+			restriction.Context[key] = raw
+			continue
+		}
+
+		value, err := execute(tmpl, data)
+		if err != nil {
+			return restriction, fmt.Errorf("%s: context %q: %w", t, key, err)
+		}
+
+		restriction.Context[key] = value
+	}
+
+	return restriction, nil
+}
+
+func (t *Template) parse() error {
+	if t.Type == "" {
+		return fmt.Errorf("restriction %q has no type", t.Name)
+	}
+
+	if t.Name == "" {
+		t.Name = DefaultName
+	}
+
+	t.templates = make(map[string]*template.Template, len(t.Context))
+
+	for key, raw := range t.Context {
+		text, ok := raw.(string)
+		if !ok || !strings.Contains(text, "{{") {
+			continue
+		}
+
+		tmpl, err := template.New(key).Option("missingkey=error").Funcs(funcs(nil)).Parse(text)
+		if err != nil {
+			return fmt.Errorf("%s: context %q: %w", t, key, err)
+		}
+
+		t.templates[key] = tmpl
+	}
+
+	return nil
+}
+
+// execute renders the template, values passed to the "value" function are captured
+// and replaced by placeholders, so the whole value "{{ value .Odd.MatchStatus }}" keeps type of the field
+func execute(tmpl *template.Template, data Data) (any, error) {
+	var captured []any
+
+	clone, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	sb := &strings.Builder{}
+	if err := clone.Funcs(funcs(&captured)).Execute(sb, data); err != nil {
+		return nil, err
+	}
+
+	result := sb.String()
+	if len(captured) == 1 && result == placeholder(0) {
+		return captured[0], nil
+	}
+
+	for i, v := range captured {
+		result = strings.ReplaceAll(result, placeholder(i), fmt.Sprint(v))
+	}
+
+	return result, nil
+}
+
+func funcs(captured *[]any) template.FuncMap {
+	return template.FuncMap{
+		"value": func(v any) string {
+			if captured == nil {
+				return ""
+			}
+
+			*captured = append(*captured, v)
+
+			return placeholder(len(*captured) - 1)
+		},
+		"format": func(cur currency.Currency, amount *apd.Decimal) string {
+			return cur.Format(amount)
+		},
+		"decimal": func(d *apd.Decimal) string {
+			return d.Text('f')
+		},
+	}
+}
+
+func placeholder(i int) string {
+	return fmt.Sprintf("\x00value%d\x00", i)
+}
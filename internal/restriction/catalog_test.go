@@ -0,0 +1,128 @@
+package restriction
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/currency"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
+	"github.com/databet-cloud/callback-test-tool/internal/testutil/golden"
+)
+
+func TestDefaultCatalog(t *testing.T) {
+	catalog, err := DefaultCatalog()
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, callback.GetAllBetRestrictions(), catalog.Types())
+
+	data := testData(t)
+
+	for _, restrictionType := range catalog.Types() {
+		for _, variant := range catalog.Variants(restrictionType) {
+			restriction, err := variant.Render(data)
+			require.NoError(t, err, variant.String())
+
+			assert.Equal(t, restrictionType, restriction.Type)
+			assert.Equal(t, "match-1", restriction.Context["sport_event_id"], variant.String())
+		}
+	}
+}
+
+// TestDefaultCatalog_Golden compares restrictions of all default variants with the golden file
+func TestDefaultCatalog_Golden(t *testing.T) {
+	catalog, err := DefaultCatalog()
+	require.NoError(t, err)
+
+	data := testData(t)
+	restrictions := make([]callback.Restriction, 0)
+
+	for _, restrictionType := range catalog.Types() {
+		for _, variant := range catalog.Variants(restrictionType) {
+			restriction, err := variant.Render(data)
+			require.NoError(t, err, variant.String())
+
+			restrictions = append(restrictions, restriction)
+		}
+	}
+
+	payload, err := json.Marshal(restrictions)
+	require.NoError(t, err)
+
+	golden.AssertJSON(t, "restrictions", payload)
+}
+
+func TestTemplate_Render(t *testing.T) {
+	catalog, err := ParseCatalog([]byte(`{"restrictions": [{
+		"type": "custom",
+		"context": {
+			"status": "{{ value .Odd.MatchStatus }}",
+			"text": "status {{ value .Odd.MatchStatus }} of {{ .Odd.MatchId }}",
+			"stake": "{{ format .Currency .Stake }}",
+			"freebet": "{{ format .FreebetCurrency .FreebetAmount }} {{ .FreebetCurrency.Code }}",
+			"max_bet": "{{ format .Currency .MaxBet }}",
+			"is_active": true
+		}
+	}]}`))
+	require.NoError(t, err)
+
+	variants := catalog.Variants("custom")
+	require.Len(t, variants, 1)
+	assert.Equal(t, "default", variants[0].Name)
+
+	restriction, err := variants[0].Render(testData(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"status":    2,
+		"text":      "status 2 of match-1",
+		"stake":     "10.50",
+		"freebet":   "5.67 USD",
+		"max_bet":   "21.00",
+		"is_active": true,
+	}, restriction.Context)
+}
+
+func testData(t *testing.T) Data {
+	t.Helper()
+
+	rates, err := currency.DefaultRegistry()
+	require.NoError(t, err)
+
+	eur, err := rates.Currency("EUR")
+	require.NoError(t, err)
+
+	usd, err := rates.Currency("USD")
+	require.NoError(t, err)
+
+	odd := &callback.Odd{
+		OddId:       "odd-1",
+		OddRatio:    apd.New(2, 0),
+		OddStatus:   sportsbook.OddStatusNotResulted,
+		MatchId:     "match-1",
+		MatchStatus: 2,
+		MarketId:    "market-1",
+	}
+
+	bet := &callback.Data{
+		PrivateStake: apd.New(1050, -2),
+		PrivateOdds:  []*callback.Odd{odd},
+		BetType:      callback.SingleBetType,
+		BetOdds:      []*callback.Odd{odd},
+	}
+
+	data, err := NewData(bet, odd, Wallet{
+		Currency:          eur,
+		FreebetCurrency:   usd,
+		InsuranceCurrency: usd,
+		Rates:             rates,
+		Balance:           apd.New(100, 0),
+	})
+	require.NoError(t, err)
+
+	return data
+}
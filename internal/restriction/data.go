@@ -0,0 +1,77 @@
+package restriction
+
+import (
+	"github.com/cockroachdb/apd/v3"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/currency"
+)
+
+// Data - values available in context templates
+type Data struct {
+	Bet *callback.Data
+	// Odd - selection of the bet which is restricted
+	Odd   *callback.Odd
+	Stake *apd.Decimal
+	// MaxBet - stake multiplied by all odds of the bet
+	MaxBet *apd.Decimal
+	// MinBet - sum of the max bet and the stake
+	MinBet *apd.Decimal
+	// FreebetAmount - half of the stake in the freebet currency
+	FreebetAmount *apd.Decimal
+	// Balance - available balance of the player
+	Balance           *apd.Decimal
+	Currency          currency.Currency
+	FreebetCurrency   currency.Currency
+	InsuranceCurrency currency.Currency
+}
+
+// Wallet - currencies and balance of the player
+type Wallet struct {
+	Currency          currency.Currency
+	FreebetCurrency   currency.Currency
+	InsuranceCurrency currency.Currency
+	Rates             *currency.Registry
+	Balance           *apd.Decimal
+}
+
+// NewData calculates values of context templates for the odd of the bet
+func NewData(bet *callback.Data, odd *callback.Odd, wallet Wallet) (Data, error) {
+	ctx := apd.BaseContext.WithPrecision(100)
+
+	data := Data{
+		Bet:               bet,
+		Odd:               odd,
+		Stake:             bet.PrivateStake,
+		MaxBet:            new(apd.Decimal).Set(bet.PrivateStake),
+		MinBet:            new(apd.Decimal),
+		FreebetAmount:     new(apd.Decimal),
+		Balance:           wallet.Balance,
+		Currency:          wallet.Currency,
+		FreebetCurrency:   wallet.FreebetCurrency,
+		InsuranceCurrency: wallet.InsuranceCurrency,
+	}
+
+	for _, betOdd := range bet.BetOdds {
+		if _, err := ctx.Mul(data.MaxBet, data.MaxBet, betOdd.OddRatio); err != nil {
+			return data, err
+		}
+	}
+
+	if _, err := ctx.Add(data.MinBet, data.MaxBet, bet.PrivateStake); err != nil {
+		return data, err
+	}
+
+	if _, err := ctx.Quo(data.FreebetAmount, bet.PrivateStake, apd.New(2, 0)); err != nil {
+		return data, err
+	}
+
+	freebetAmount, err := wallet.Rates.Convert(data.FreebetAmount, wallet.Currency, wallet.FreebetCurrency)
+	if err != nil {
+		return data, err
+	}
+
+	data.FreebetAmount = freebetAmount
+
+	return data, nil
+}
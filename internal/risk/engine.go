@@ -0,0 +1,460 @@
+package risk
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+
+	"github.com/databet-cloud/callback-test-tool/internal/calculator/former"
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/currency"
+)
+
+// Limits - limits of the risk engine, nil and zero limits are not checked
+type Limits struct {
+	// MinStake, MaxStake - stake range of the single bet
+	MinStake *apd.Decimal
+	MaxStake *apd.Decimal
+	// PlayerLimit - maximal sum of stakes of open bets of the player
+	PlayerLimit *apd.Decimal
+	// MaxEventPayout - maximal potential payout of open bets on the sport event
+	MaxEventPayout *apd.Decimal
+	// BetInterval - minimal interval between bets of the player
+	BetInterval time.Duration
+	// MinOdd, MaxOdd - odd value range of every selection
+	MinOdd *apd.Decimal
+	MaxOdd *apd.Decimal
+	// BetTypes - allowed bet types, all bet types are allowed if empty
+	BetTypes []callback.BetType
+}
+
+// Violation - broken limit of the bet
+type Violation struct {
+	Type callback.RestrictionType
+	// Odd - selection of the bet which breaks the limit
+	Odd *callback.Odd
+	// Context - values computed from the engine state, they replace values of the restriction template
+	Context map[string]any
+}
+
+// Decision - result of the bet evaluation, the bet is accepted when no limits are broken
+type Decision struct {
+	Violations []Violation
+}
+
+func (d Decision) Accepted() bool {
+	return len(d.Violations) == 0
+}
+
+// Engine keeps exposure of open bets and evaluates new bets against limits
+type Engine struct {
+	limits   Limits
+	currency currency.Currency
+
+	mu      sync.Mutex
+	players map[string]*playerState
+	// potential payout of open bets per sport event
+	events map[string]*apd.Decimal
+	// exposure of open bets by bet ID
+	open map[string]exposure
+}
+
+type playerState struct {
+	openStake *apd.Decimal
+	lastBetAt time.Time
+}
+
+type exposure struct {
+	playerID string
+	stake    *apd.Decimal
+	payout   *apd.Decimal
+	events   []string
+}
+
+// NewEngine creates engine, amounts of limits are in the currency of the player
+func NewEngine(limits Limits, cur currency.Currency) *Engine {
+	return &Engine{
+		limits:   limits,
+		currency: cur,
+		players:  make(map[string]*playerState),
+		events:   make(map[string]*apd.Decimal),
+		open:     make(map[string]exposure),
+	}
+}
+
+func (e *Engine) Limits() Limits {
+	return e.limits
+}
+
+// Evaluate checks the placed bet against limits, the state of the engine is not changed
+func (e *Engine) Evaluate(bet *callback.Data) (Decision, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.evaluate(bet)
+}
+
+// Reserve evaluates the bet and opens the exposure of the accepted bet under the same lock,
+// so concurrent bets can not pass limits which only one of them fits.
+// The exposure is removed by Close if the bet is not accepted in the end.
+func (e *Engine) Reserve(bet *callback.Data) (Decision, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	decision, err := e.evaluate(bet)
+	if err != nil || !decision.Accepted() {
+		return decision, err
+	}
+
+	return decision, e.openBet(bet)
+}
+
+// nolint:funlen // every limit is checked in place to compute its context from the same state
+func (e *Engine) evaluate(bet *callback.Data) (Decision, error) {
+	if len(bet.PrivateOdds) == 0 {
+		return Decision{}, fmt.Errorf("bet %s has no selections", bet.BetID)
+	}
+
+	ctx := newApdCtx()
+	first := bet.PrivateOdds[0]
+	player := e.player(bet.BetPlayerID)
+
+	multiplier, err := potentialMultiplier(ctx, bet)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	maxBet, eventMaxBets, err := e.maxBet(ctx, player, bet, multiplier)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	var (
+		decision  Decision
+		maxBetStr = e.currency.Format(maxBet)
+	)
+
+	if len(e.limits.BetTypes) > 0 && !slices.Contains(e.limits.BetTypes, bet.PrivateBetType) {
+		decision.Violations = append(decision.Violations, Violation{
+			Type: callback.BetTypeRestriction,
+			Odd:  first,
+			Context: map[string]any{
+				"max_bet":  e.currency.Format(apd.New(0, 0)),
+				"bet_type": bet.PrivateBetType,
+			},
+		})
+	}
+
+	if e.limits.MinStake != nil && bet.PrivateStake.Cmp(e.limits.MinStake) < 0 {
+		decision.Violations = append(decision.Violations, Violation{
+			Type: callback.MinBetRestriction,
+			Odd:  first,
+			Context: map[string]any{
+				"max_bet": maxBetStr,
+				"min_bet": e.currency.Format(e.limits.MinStake),
+			},
+		})
+	}
+
+	if e.limits.MaxStake != nil && bet.PrivateStake.Cmp(e.limits.MaxStake) > 0 {
+		decision.Violations = append(decision.Violations, Violation{
+			Type:    callback.MaxBetRestriction,
+			Odd:     first,
+			Context: map[string]any{"max_bet": maxBetStr},
+		})
+	}
+
+	if e.limits.PlayerLimit != nil {
+		openStake := new(apd.Decimal)
+		if _, err := ctx.Add(openStake, player.openStake, bet.PrivateStake); err != nil {
+			return Decision{}, err
+		}
+
+		if openStake.Cmp(e.limits.PlayerLimit) > 0 {
+			decision.Violations = append(decision.Violations, Violation{
+				Type: callback.PlayerLimitRestriction,
+				Odd:  first,
+				Context: map[string]any{
+					"max_bet": maxBetStr,
+					"reason":  "limit_exceeded",
+				},
+			})
+		}
+	}
+
+	for _, odd := range bet.PrivateOdds {
+		eventMaxBet, ok := eventMaxBets[odd.MatchId]
+		if ok && bet.PrivateStake.Cmp(eventMaxBet) > 0 {
+			decision.Violations = append(decision.Violations, Violation{
+				Type:    callback.MaxBetRestriction,
+				Odd:     odd,
+				Context: map[string]any{"max_bet": e.currency.Format(eventMaxBet)},
+			})
+		}
+	}
+
+	if e.limits.BetInterval > 0 && bet.BetCreatedAt != nil && !player.lastBetAt.IsZero() {
+		if wait := e.limits.BetInterval - bet.BetCreatedAt.Sub(player.lastBetAt); wait > 0 {
+			decision.Violations = append(decision.Violations, Violation{
+				Type: callback.BetIntervalRestriction,
+				Odd:  first,
+				Context: map[string]any{
+					"max_bet":      maxBetStr,
+					"time_to_wait": fmt.Sprintf("%.2f", wait.Seconds()),
+				},
+			})
+		}
+	}
+
+	for _, odd := range bet.PrivateOdds {
+		if (e.limits.MinOdd != nil && odd.OddRatio.Cmp(e.limits.MinOdd) < 0) ||
+			(e.limits.MaxOdd != nil && odd.OddRatio.Cmp(e.limits.MaxOdd) > 0) {
+			decision.Violations = append(decision.Violations, Violation{
+				Type:    callback.SelectionValueRestriction,
+				Odd:     odd,
+				Context: map[string]any{"max_bet": maxBetStr},
+			})
+		}
+	}
+
+	return decision, nil
+}
+
+// Open adds the stake and the potential payout of the accepted bet to the exposure,
+// the bet time is the start of the bet interval of the player
+func (e *Engine) Open(bet *callback.Data) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.openBet(bet)
+}
+
+func (e *Engine) openBet(bet *callback.Data) error {
+	if _, ok := e.open[bet.BetID]; ok {
+		return nil
+	}
+
+	ctx := newApdCtx()
+
+	multiplier, err := potentialMultiplier(ctx, bet)
+	if err != nil {
+		return err
+	}
+
+	exp := exposure{
+		playerID: bet.BetPlayerID,
+		stake:    bet.PrivateStake,
+		payout:   new(apd.Decimal),
+	}
+
+	if _, err := ctx.Mul(exp.payout, bet.PrivateStake, multiplier); err != nil {
+		return err
+	}
+
+	for _, odd := range bet.PrivateOdds {
+		if !slices.Contains(exp.events, odd.MatchId) {
+			exp.events = append(exp.events, odd.MatchId)
+		}
+	}
+
+	player := e.player(bet.BetPlayerID)
+	if _, err := ctx.Add(player.openStake, player.openStake, exp.stake); err != nil {
+		return err
+	}
+
+	for _, event := range exp.events {
+		if _, err := ctx.Add(e.event(event), e.event(event), exp.payout); err != nil {
+			return err
+		}
+	}
+
+	if bet.BetCreatedAt != nil && bet.BetCreatedAt.After(player.lastBetAt) {
+		player.lastBetAt = *bet.BetCreatedAt
+	}
+
+	e.open[bet.BetID] = exp
+
+	return nil
+}
+
+// Close removes the exposure of the settled bet
+func (e *Engine) Close(betID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	exp, ok := e.open[betID]
+	if !ok {
+		return nil
+	}
+
+	ctx := newApdCtx()
+	player := e.player(exp.playerID)
+
+	if _, err := ctx.Sub(player.openStake, player.openStake, exp.stake); err != nil {
+		return err
+	}
+
+	for _, event := range exp.events {
+		if _, err := ctx.Sub(e.event(event), e.event(event), exp.payout); err != nil {
+			return err
+		}
+	}
+
+	delete(e.open, betID)
+
+	return nil
+}
+
+// maxBet calculates the maximal stake of the bet which passes stake limits, the stake of the bet is returned
+// when there are no stake limits, max bets of sport events are returned separately
+func (e *Engine) maxBet(
+	ctx *apd.Context,
+	player *playerState,
+	bet *callback.Data,
+	multiplier *apd.Decimal,
+) (*apd.Decimal, map[string]*apd.Decimal, error) {
+	var maxBet *apd.Decimal
+
+	lower := func(v *apd.Decimal) {
+		if maxBet == nil || v.Cmp(maxBet) < 0 {
+			maxBet = v
+		}
+	}
+
+	if e.limits.MaxStake != nil {
+		lower(e.limits.MaxStake)
+	}
+
+	if e.limits.PlayerLimit != nil {
+		remaining := new(apd.Decimal)
+		if _, err := ctx.Sub(remaining, e.limits.PlayerLimit, player.openStake); err != nil {
+			return nil, nil, err
+		}
+
+		lower(remaining)
+	}
+
+	eventMaxBets := make(map[string]*apd.Decimal)
+
+	if e.limits.MaxEventPayout != nil {
+		for _, odd := range bet.PrivateOdds {
+			remaining := new(apd.Decimal)
+			if _, err := ctx.Sub(remaining, e.limits.MaxEventPayout, e.event(odd.MatchId)); err != nil {
+				return nil, nil, err
+			}
+
+			if _, err := ctx.Quo(remaining, remaining, multiplier); err != nil {
+				return nil, nil, err
+			}
+
+			eventMaxBet, err := e.round(remaining)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			eventMaxBets[odd.MatchId] = eventMaxBet
+			lower(eventMaxBet)
+		}
+	}
+
+	if maxBet == nil {
+		return bet.PrivateStake, eventMaxBets, nil
+	}
+
+	maxBet, err := e.round(maxBet)
+
+	return maxBet, eventMaxBets, err
+}
+
+// round rounds the amount down to the currency, negative amounts are zero
+func (e *Engine) round(amount *apd.Decimal) (*apd.Decimal, error) {
+	if amount.Negative {
+		return apd.New(0, 0), nil
+	}
+
+	return e.currency.Round(amount)
+}
+
+func (e *Engine) player(playerID string) *playerState {
+	player, ok := e.players[playerID]
+	if !ok {
+		player = &playerState{openStake: new(apd.Decimal)}
+		e.players[playerID] = player
+	}
+
+	return player
+}
+
+func (e *Engine) event(sportEventID string) *apd.Decimal {
+	payout, ok := e.events[sportEventID]
+	if !ok {
+		payout = new(apd.Decimal)
+		e.events[sportEventID] = payout
+	}
+
+	return payout
+}
+
+// potentialMultiplier - payout of the unit stake of the bet when all selections win. The stake of the system
+// is split evenly between its expresses, so the multiplier is the mean product of odds of the expresses,
+// other bets and systems without expresses get the product of all odds.
+func potentialMultiplier(ctx *apd.Context, bet *callback.Data) (*apd.Decimal, error) {
+	if bet.PrivateBetType != callback.SystemBetType {
+		return oddsProduct(ctx, bet.PrivateOdds)
+	}
+
+	var (
+		multiplier = apd.New(0, 0)
+		count      = 0
+		err        error
+	)
+
+	former.EachExpress(bet.PrivateOdds, bet.PrivateBetSystemSizes, func(express []*callback.Odd) bool {
+		var product *apd.Decimal
+
+		if product, err = oddsProduct(ctx, express); err != nil {
+			return false
+		}
+
+		count++
+		_, err = ctx.Add(multiplier, multiplier, product)
+
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if count == 0 {
+		return oddsProduct(ctx, bet.PrivateOdds)
+	}
+
+	if _, err := ctx.Quo(multiplier, multiplier, apd.New(int64(count), 0)); err != nil {
+		return nil, err
+	}
+
+	return multiplier, nil
+}
+
+// oddsProduct - payout of the unit stake of the express
+func oddsProduct(ctx *apd.Context, odds []*callback.Odd) (*apd.Decimal, error) {
+	product := apd.New(1, 0)
+
+	for _, odd := range odds {
+		if _, err := ctx.Mul(product, product, odd.OddRatio); err != nil {
+			return nil, err
+		}
+	}
+
+	return product, nil
+}
+
+func newApdCtx() *apd.Context {
+	ctx := apd.BaseContext.WithPrecision(100)
+	ctx.Rounding = apd.RoundDown
+
+	return ctx
+}
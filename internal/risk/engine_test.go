@@ -0,0 +1,221 @@
+package risk
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/currency"
+)
+
+func TestEngine_Evaluate(t *testing.T) {
+	engine := NewEngine(Limits{
+		MinStake:       apd.New(1, 0),
+		MaxStake:       apd.New(100, 0),
+		PlayerLimit:    apd.New(150, 0),
+		MaxEventPayout: apd.New(300, 0),
+		BetInterval:    10 * time.Second,
+		MinOdd:         apd.New(110, -2),
+		MaxOdd:         apd.New(5, 0),
+		BetTypes:       []callback.BetType{callback.SingleBetType, callback.ExpressBetType},
+	}, currency.New("EUR", 2))
+
+	createdAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	single := testBet("bet-1", callback.SingleBetType, "50", createdAt, testOdd("match-1", "2"))
+
+	decision, err := engine.Evaluate(single)
+	require.NoError(t, err)
+	assert.True(t, decision.Accepted())
+	require.NoError(t, engine.Open(single))
+
+	express := testBet("bet-2", callback.ExpressBetType, "120", createdAt.Add(4*time.Second),
+		testOdd("match-1", "2"), testOdd("match-2", "1.05"))
+
+	decision, err = engine.Evaluate(express)
+	require.NoError(t, err)
+	assert.False(t, decision.Accepted())
+
+	// max bet is limited by the payout of the match-1: (300 - 50 * 2) / (2 * 1.05)
+	assert.Equal(t, []Violation{
+		{
+			Type:    callback.MaxBetRestriction,
+			Odd:     express.PrivateOdds[0],
+			Context: map[string]any{"max_bet": "95.23"},
+		},
+		{
+			Type:    callback.PlayerLimitRestriction,
+			Odd:     express.PrivateOdds[0],
+			Context: map[string]any{"max_bet": "95.23", "reason": "limit_exceeded"},
+		},
+		{
+			Type:    callback.MaxBetRestriction,
+			Odd:     express.PrivateOdds[0],
+			Context: map[string]any{"max_bet": "95.23"},
+		},
+		{
+			Type:    callback.BetIntervalRestriction,
+			Odd:     express.PrivateOdds[0],
+			Context: map[string]any{"max_bet": "95.23", "time_to_wait": "6.00"},
+		},
+		{
+			Type:    callback.SelectionValueRestriction,
+			Odd:     express.PrivateOdds[1],
+			Context: map[string]any{"max_bet": "95.23"},
+		},
+	}, decision.Violations)
+
+	system := testBet("bet-3", callback.SystemBetType, "0.5", createdAt.Add(time.Minute),
+		testOdd("match-3", "2"), testOdd("match-4", "2"), testOdd("match-5", "2"))
+
+	decision, err = engine.Evaluate(system)
+	require.NoError(t, err)
+	require.Len(t, decision.Violations, 2)
+	assert.Equal(t, callback.BetTypeRestriction, decision.Violations[0].Type)
+	assert.Equal(t, callback.SystemBetType, decision.Violations[0].Context["bet_type"])
+	assert.Equal(t, callback.MinBetRestriction, decision.Violations[1].Type)
+	assert.Equal(t, "1.00", decision.Violations[1].Context["min_bet"])
+
+	// exposure of the settled bet is released
+	require.NoError(t, engine.Close(single.BetID))
+
+	express = testBet("bet-4", callback.ExpressBetType, "100", createdAt.Add(time.Minute),
+		testOdd("match-1", "2"), testOdd("match-2", "1.5"))
+
+	decision, err = engine.Evaluate(express)
+	require.NoError(t, err)
+	assert.True(t, decision.Accepted(), decision.Violations)
+}
+
+func TestEngine_SystemExposure(t *testing.T) {
+	tests := []struct {
+		name   string
+		bet    *callback.Data
+		maxBet string
+	}{
+		{
+			name: "express",
+			bet: testBet("bet-1", callback.ExpressBetType, "30", time.Time{},
+				testOdd("match-1", "2"), testOdd("match-2", "2"), testOdd("match-3", "2")),
+			// (300 - 30 * 8) / 2
+			maxBet: "30.00",
+		},
+		{
+			name: "system 2 of 3",
+			bet: testSystemBet("bet-1", "30", []int{2},
+				testOdd("match-1", "2"), testOdd("match-2", "2"), testOdd("match-3", "2")),
+			// 3 expresses of 10 pay 10 * 4 each: (300 - 30 * 4) / 2
+			maxBet: "90.00",
+		},
+		{
+			name: "system 1,3 of 3",
+			bet: testSystemBet("bet-1", "40", []int{1, 3},
+				testOdd("match-1", "2"), testOdd("match-2", "2"), testOdd("match-3", "2")),
+			// 4 expresses of 10 pay 10 * 2 three times and 10 * 8 once: (300 - 40 * 3.5) / 2
+			maxBet: "80.00",
+		},
+		{
+			name: "system without sizes",
+			bet: testSystemBet("bet-1", "30", nil,
+				testOdd("match-1", "2"), testOdd("match-2", "2"), testOdd("match-3", "2")),
+			maxBet: "30.00",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewEngine(Limits{MaxEventPayout: apd.New(300, 0)}, currency.New("EUR", 2))
+			require.NoError(t, engine.Open(tt.bet))
+
+			single := testBet("bet-2", callback.SingleBetType, "1000", time.Time{}, testOdd("match-3", "2"))
+
+			decision, err := engine.Evaluate(single)
+			require.NoError(t, err)
+			require.Len(t, decision.Violations, 1)
+			assert.Equal(t, tt.maxBet, decision.Violations[0].Context["max_bet"])
+		})
+	}
+}
+
+func TestEngine_Reserve(t *testing.T) {
+	engine := NewEngine(Limits{PlayerLimit: apd.New(150, 0)}, currency.New("EUR", 2))
+
+	bets := make([]*callback.Data, 10)
+	decisions := make([]Decision, len(bets))
+
+	var wg sync.WaitGroup
+
+	for i := range bets {
+		bets[i] = testBet(fmt.Sprintf("bet-%d", i), callback.SingleBetType, "100", time.Time{}, testOdd("match-1", "2"))
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			decision, err := engine.Reserve(bets[i])
+			assert.NoError(t, err)
+
+			decisions[i] = decision
+		}()
+	}
+
+	wg.Wait()
+
+	// only one of the concurrent bets fits the player limit
+	accepted := -1
+
+	for i, decision := range decisions {
+		if decision.Accepted() {
+			require.Equal(t, -1, accepted, "bets %s and %s are both accepted", bets[i].BetID, bets[max(accepted, 0)].BetID)
+			accepted = i
+		}
+	}
+
+	require.NotEqual(t, -1, accepted)
+
+	// the released reservation is available to the next bet
+	require.NoError(t, engine.Close(bets[accepted].BetID))
+
+	decision, err := engine.Reserve(testBet("bet-10", callback.SingleBetType, "100", time.Time{}, testOdd("match-1", "2")))
+	require.NoError(t, err)
+	assert.True(t, decision.Accepted(), decision.Violations)
+}
+
+func testSystemBet(id, stake string, sizes []int, odds ...*callback.Odd) *callback.Data {
+	bet := testBet(id, callback.SystemBetType, stake, time.Time{}, odds...)
+	bet.PrivateBetSystemSizes = sizes
+
+	return bet
+}
+
+func testBet(id string, betType callback.BetType, stake string, createdAt time.Time, odds ...*callback.Odd) *callback.Data {
+	return &callback.Data{
+		RequestType:    callback.BetPlaceRequestType,
+		PrivateStake:   decimal(stake),
+		PrivateOdds:    odds,
+		PrivateBetType: betType,
+		BetID:          id,
+		BetPlayerID:    "player-1",
+		BetCreatedAt:   &createdAt,
+	}
+}
+
+func testOdd(matchID, ratio string) *callback.Odd {
+	return &callback.Odd{MatchId: matchID, MarketId: matchID + "-market", OddId: matchID + "-odd", OddRatio: decimal(ratio)}
+}
+
+func decimal(value string) *apd.Decimal {
+	d, _, err := apd.NewFromString(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
@@ -0,0 +1,414 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/cockroachdb/apd/v3"
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/currency"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
+	"github.com/databet-cloud/callback-test-tool/internal/storage"
+)
+
+type CashOutOrderState string
+
+const (
+	CashOutOrderCreated  CashOutOrderState = "created"
+	CashOutOrderAccepted CashOutOrderState = "accepted"
+	CashOutOrderDeclined CashOutOrderState = "declined"
+)
+
+// CashOutOrder - request to cash out the part of the bet stake, the bet can have many orders
+type CashOutOrder struct {
+	ID    string            `json:"id"`
+	BetID string            `json:"bet_id"`
+	State CashOutOrderState `json:"state"`
+	// Stake - part of the bet stake which is cashed out
+	Stake *apd.Decimal `json:"stake"`
+	// Amount - refund amount of the order
+	Amount *apd.Decimal `json:"amount"`
+}
+
+func (o *CashOutOrder) String() string {
+	return fmt.Sprintf("%s:%s (stake %s, amount %s)", o.ID, o.State, formatApd(o.Stake), formatApd(o.Amount))
+}
+
+func (o *CashOutOrder) withState(state CashOutOrderState) *CashOutOrder {
+	order := *o
+	order.State = state
+
+	return &order
+}
+
+// CreateCashOutOrder creates pending order for the fraction of the bet stake which is not cashed out yet
+func (s *Service) CreateCashOutOrder(ctx context.Context, betID string, fraction float64) (*CashOutOrder, error) {
+	defer s.lockBet(betID)()
+
+	bet, ok := s.bets.Get(func(d *callback.Data) bool { return d.BetID == betID && isSettleable(d) })
+	if !ok {
+		return nil, s.fail("failed to find bet", ErrBetNotFound, zap.String("id", betID))
+	}
+
+	available, err := s.availableCashOutStake(bet)
+	if err != nil {
+		return nil, s.fail("failed to calculate available stake", err, zap.String("id", betID))
+	}
+
+	stake, err := fractionOf(available, fraction, s.PlayerCurrency())
+	if err != nil {
+		return nil, s.fail("invalid cash-out amount", err, zap.String("id", betID), zap.Float64("fraction", fraction))
+	}
+
+	placedOdds, currentOdds := s.currentOdds(ctx, bet)
+
+	amount, err := s.cashOutCalc.Value(bet.PrivateBetType, bet.PrivateBetSystemSizes, stake, placedOdds, currentOdds)
+	if err != nil {
+		return nil, s.fail("failed to value cash-out", err, zap.String("id", betID))
+	}
+
+	amount, err = s.PlayerCurrency().Round(amount)
+	if err != nil {
+		return nil, s.fail("failed to round cash-out amount", err, zap.String("id", betID))
+	}
+
+	order := &CashOutOrder{
+		ID:     s.rand.UUID(),
+		BetID:  bet.BetID,
+		State:  CashOutOrderCreated,
+		Stake:  stake,
+		Amount: amount,
+	}
+
+	s.cashOuts.Insert(order)
+	s.log.Info("Cash-out order created", zap.Any("order", order))
+
+	return order, nil
+}
+
+func (s *Service) AcceptCashOutOrder(ctx context.Context, orderID string) error {
+	orderFindFunc := func(o *CashOutOrder) bool {
+		return o.ID == orderID && o.State == CashOutOrderCreated
+	}
+
+	order, ok := s.cashOuts.Get(orderFindFunc)
+	if !ok {
+		return s.fail("failed to find created cash-out order", ErrCashOutOrderNotFound, zap.String("id", orderID))
+	}
+
+	defer s.lockBet(order.BetID)()
+
+	// the order could be declined before the lock of its bet
+	if order, ok = s.cashOuts.Get(orderFindFunc); !ok {
+		return s.fail("failed to find created cash-out order", ErrCashOutOrderNotFound, zap.String("id", orderID))
+	}
+
+	betFindFunc := func(d *callback.Data) bool {
+		return d.BetID == order.BetID && isSettleable(d)
+	}
+
+	bet, ok := s.bets.Get(betFindFunc)
+	if !ok {
+		return s.fail("failed to find bet", ErrBetNotFound, zap.String("id", order.BetID))
+	}
+
+	data := &callback.Data{
+		RequestType:           callback.BetCashOutOrdersAcceptedRequestType,
+		PrivateStake:          bet.PrivateStake,
+		PrivateOdds:           bet.PrivateOdds,
+		PrivateBetType:        bet.PrivateBetType,
+		PrivateBetSystemSizes: bet.PrivateBetSystemSizes,
+		PrivateCashOutAmount:  order.Amount,
+		PrivateCashOutStake:   order.Stake,
+
+		RequestID:      s.rand.UUID(),
+		BetID:          bet.BetID,
+		CashOutOrderID: order.ID,
+		Amount:         s.formatAmount(order.Stake),
+		RefundAmount:   s.formatAmount(order.Amount),
+	}
+
+	s.log.Info("Player balance before request", zap.Any("balance", s.PlayerBalance()))
+
+	response, err := s.sendCallback(ctx, data)
+	if err != nil {
+		return s.fail("failed to send accept bet cash out win", err)
+	}
+
+	s.playerBalance.WithdrawHold(order.Stake) // remove from hold
+	s.playerBalance.Deposit(order.Amount)     // deposit
+	s.expectBalance(data.RequestID)
+
+	cashedOutBet, err := withCashOut(bet.WithRequestType(callback.BetCashOutOrdersAcceptedRequestType), order, 1)
+	if err != nil {
+		s.log.Error("failed to apply cash-out to bet", zap.String("id", bet.BetID), zap.Error(err))
+	} else {
+		s.bets.Replace(cashedOutBet, betFindFunc)
+	}
+
+	s.sentRequests.Insert(data)
+	s.cashOuts.Replace(order.withState(CashOutOrderAccepted), orderFindFunc)
+
+	s.processResponse(response)
+
+	return nil
+}
+
+// DeclineCashOutOrders declines orders of the bet in the single callback.
+// Amounts of accepted orders are rolled back, created orders are just closed.
+// nolint:funlen // extended limit of lines to handle rollback of all orders in the single function
+func (s *Service) DeclineCashOutOrders(ctx context.Context, betID string, orderIDs []string) error {
+	defer s.lockBet(betID)()
+
+	orders := s.cashOuts.GetMany(func(o *CashOutOrder) bool {
+		return o.BetID == betID && slices.Contains(orderIDs, o.ID) && o.State != CashOutOrderDeclined
+	})
+	if len(orders) == 0 || len(orders) != len(orderIDs) {
+		return s.fail("failed to find cash-out orders to decline", ErrCashOutOrderNotFound, zap.String("bet_id", betID), zap.Strings("ids", orderIDs))
+	}
+
+	betFindFunc := func(d *callback.Data) bool {
+		return d.BetID == betID
+	}
+
+	bet, ok := s.bets.Get(betFindFunc)
+	if !ok {
+		return s.fail("failed to find bet", ErrBetNotFound, zap.String("id", betID))
+	}
+
+	hasAccepted := slices.ContainsFunc(orders, func(o *CashOutOrder) bool { return o.State == CashOutOrderAccepted })
+	if hasAccepted && !isSettleable(bet) {
+		return s.fail("accepted cash-out orders can not be declined after bet settlement", ErrBetSettled, zap.String("id", betID))
+	}
+
+	restoredBet := bet.WithRequestType(callback.BetCashOutOrdersDeclinedRequestType)
+	ids := make([]string, len(orders))
+
+	for i, order := range orders {
+		ids[i] = order.ID
+
+		if order.State != CashOutOrderAccepted {
+			continue
+		}
+
+		var err error
+
+		restoredBet, err = withCashOut(restoredBet, order, -1)
+		if err != nil {
+			return s.fail("failed to roll back cash-out of bet", err, zap.String("id", betID))
+		}
+	}
+
+	data := &callback.Data{
+		RequestType:           callback.BetCashOutOrdersDeclinedRequestType,
+		PrivateStake:          bet.PrivateStake,
+		PrivateOdds:           bet.PrivateOdds,
+		PrivateBetType:        bet.PrivateBetType,
+		PrivateBetSystemSizes: bet.PrivateBetSystemSizes,
+
+		RequestID:       s.rand.UUID(),
+		BetID:           bet.BetID,
+		CashOutOrderIDs: ids,
+	}
+
+	s.log.Info("Player balance before request", zap.Any("balance", s.PlayerBalance()))
+
+	response, err := s.sendCallback(ctx, data)
+	if err != nil {
+		return s.fail("failed to send decline bet cash out win", err)
+	}
+
+	for _, order := range orders {
+		if order.State == CashOutOrderAccepted {
+			s.playerBalance.DepositHold(order.Stake)
+			s.playerBalance.Withdraw(order.Amount)
+		}
+
+		s.cashOuts.Replace(order.withState(CashOutOrderDeclined), func(o *CashOutOrder) bool { return o.ID == order.ID })
+	}
+
+	s.expectBalance(data.RequestID)
+
+	if isSettleable(bet) {
+		s.bets.Replace(restoredBet, betFindFunc)
+	}
+
+	s.sentRequests.Insert(data)
+
+	s.processResponse(response)
+
+	return nil
+}
+
+// CashOutOrders returns orders of the bet, empty betID means orders of all bets
+func (s *Service) CashOutOrders(betID string, states ...CashOutOrderState) []*storage.Document[*CashOutOrder] {
+	docs := s.cashOuts.GetDocuments(func(o *CashOutOrder) bool {
+		return (betID == "" || o.BetID == betID) && (len(states) == 0 || slices.Contains(states, o.State))
+	})
+
+	slices.Reverse(docs)
+
+	return docs
+}
+
+// availableCashOutStake returns part of the stake which is neither cashed out nor reserved by created orders
+func (s *Service) availableCashOutStake(bet *callback.Data) (*apd.Decimal, error) {
+	available, err := remainingStake(bet)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := newApdCtx()
+
+	for _, order := range s.cashOuts.GetMany(func(o *CashOutOrder) bool {
+		return o.BetID == bet.BetID && o.State == CashOutOrderCreated
+	}) {
+		if _, err := ctx.Sub(available, available, order.Stake); err != nil {
+			return nil, err
+		}
+	}
+
+	return available, nil
+}
+
+// currentOdds returns placed and actual values of the bet odds.
+// The placed value is used when the odd is not available in the sportsbook anymore.
+func (s *Service) currentOdds(ctx context.Context, bet *callback.Data) ([]*apd.Decimal, []*apd.Decimal) {
+	placed := make([]*apd.Decimal, len(bet.PrivateOdds))
+	current := make([]*apd.Decimal, len(bet.PrivateOdds))
+	ids := make([]string, 0, len(bet.PrivateOdds))
+
+	for i, odd := range bet.PrivateOdds {
+		placed[i] = odd.OddRatio
+		current[i] = odd.OddRatio
+
+		if !slices.Contains(ids, odd.MatchId) {
+			ids = append(ids, odd.MatchId)
+		}
+	}
+
+	sportEvents, err := s.odds.SportEventsByIDs(ctx, ids)
+	if err != nil {
+		s.log.Warn("failed to get actual odds, placed odds are used", zap.Error(err))
+		return placed, current
+	}
+
+	for i, odd := range bet.PrivateOdds {
+		value, ok := actualOddValue(sportEvents, odd)
+		if !ok {
+			s.log.Warn("odd is not available, placed odd is used", zap.String("odd_id", odd.OddId))
+			continue
+		}
+
+		current[i] = value
+	}
+
+	return placed, current
+}
+
+func actualOddValue(sportEvents []sportsbook.SportEvent, odd *callback.Odd) (*apd.Decimal, bool) {
+	index := slices.IndexFunc(sportEvents, func(e sportsbook.SportEvent) bool { return e.ID == odd.MatchId })
+	if index == -1 {
+		return nil, false
+	}
+
+	market, ok := sportEvents[index].GetMarket(odd.MarketId)
+	if !ok {
+		return nil, false
+	}
+
+	actual, ok := market.GetOdd(odd.OddId)
+	if !ok || actual.Value == nil || actual.Value.Sign() <= 0 {
+		return nil, false
+	}
+
+	return actual.Value, true
+}
+
+// fractionOf returns the fraction of the available stake rounded down to minor units of the currency
+func fractionOf(available *apd.Decimal, fraction float64, cur currency.Currency) (*apd.Decimal, error) {
+	if fraction <= 0 || fraction > 1 {
+		return nil, errors.New("fraction must be in range (0, 1]")
+	}
+
+	if available.Sign() <= 0 {
+		return nil, errors.New("the whole stake is already cashed out")
+	}
+
+	decimalFraction, err := apd.New(0, 0).SetFloat64(fraction)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := newApdCtx()
+	amount := new(apd.Decimal)
+
+	if _, err := ctx.Mul(amount, available, decimalFraction); err != nil {
+		return nil, err
+	}
+
+	amount, err = cur.Round(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount.IsZero() {
+		return nil, errors.New("cash-out amount is too small")
+	}
+
+	return amount, nil
+}
+
+// remainingStake returns part of the stake which is not cashed out
+func remainingStake(bet *callback.Data) (*apd.Decimal, error) {
+	remaining := new(apd.Decimal).Set(bet.PrivateStake)
+	if bet.PrivateCashOutStake == nil {
+		return remaining, nil
+	}
+
+	_, err := newApdCtx().Sub(remaining, remaining, bet.PrivateCashOutStake)
+
+	return remaining, err
+}
+
+// withCashOut adds (sign 1) or removes (sign -1) amounts of the cash-out order to the bet totals
+func withCashOut(bet *callback.Data, order *CashOutOrder, sign int64) (*callback.Data, error) {
+	ctx := newApdCtx()
+	cashOutStake := apd.New(0, 0)
+	cashOutAmount := apd.New(0, 0)
+
+	if bet.PrivateCashOutStake != nil {
+		cashOutStake.Set(bet.PrivateCashOutStake)
+	}
+
+	if bet.PrivateCashOutAmount != nil {
+		cashOutAmount.Set(bet.PrivateCashOutAmount)
+	}
+
+	orderStake := new(apd.Decimal)
+	if _, err := ctx.Mul(orderStake, order.Stake, apd.New(sign, 0)); err != nil {
+		return nil, err
+	}
+
+	orderAmount := new(apd.Decimal)
+	if _, err := ctx.Mul(orderAmount, order.Amount, apd.New(sign, 0)); err != nil {
+		return nil, err
+	}
+
+	if _, err := ctx.Add(cashOutStake, cashOutStake, orderStake); err != nil {
+		return nil, err
+	}
+
+	if _, err := ctx.Add(cashOutAmount, cashOutAmount, orderAmount); err != nil {
+		return nil, err
+	}
+
+	bet.PrivateCashOutStake = cashOutStake
+	bet.PrivateCashOutAmount = cashOutAmount
+
+	return bet, nil
+}
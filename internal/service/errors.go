@@ -0,0 +1,23 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidBetType       = errors.New("invalid bet type")
+	ErrInvalidAmount        = errors.New("invalid amount")
+	ErrBetNotFound          = errors.New("bet not found")
+	ErrCashOutOrderNotFound = errors.New("cash-out order not found")
+	ErrBetSettled           = errors.New("bet is settled")
+)
+
+// fail logs the failed action and returns its error, so actions are reported both in the log and to the caller
+func (s *Service) fail(msg string, err error, fields ...zap.Field) error {
+	s.log.Error(msg, append(fields, zap.Error(err))...)
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/balance"
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/storage"
+)
+
+// Exchange - callback sent to the callback server and its result
+type Exchange struct {
+	Request  *callback.Data
+	SentAt   time.Time
+	Duration time.Duration
+	// StatusCode - status code of the response, zero if the response is not received
+	StatusCode int
+	Error      string
+	// ResponseBody - body of the rejecting response, accepted requests are answered without the body
+	ResponseBody string
+	// ExpectedBalance - balance of the player which the callback server is expected to have after the request
+	ExpectedBalance balance.Balance
+}
+
+// Succeeded reports whether the callback server accepted the request
+func (e *Exchange) Succeeded() bool {
+	return e.Error == "" && e.StatusCode == http.StatusNoContent
+}
+
+// Exchanges returns all sent callbacks in the order of sending
+func (s *Service) Exchanges() []*storage.Document[*Exchange] {
+	return s.exchanges.All()
+}
+
+// sendCallback sends the request and records the exchange, the balance is not changed by failed requests
+func (s *Service) sendCallback(ctx context.Context, data *callback.Data) (*http.Response, error) {
+	// wall time is used, reading of the session clock would change times of generated requests
+	exchange := &Exchange{
+		Request:         data,
+		SentAt:          time.Now().UTC(),
+		ExpectedBalance: s.PlayerBalance(),
+	}
+
+	response, err := s.callbackClient.SendCallback(ctx, data)
+
+	exchange.Duration = time.Since(exchange.SentAt)
+
+	var statusErr *callback.StatusError
+
+	switch {
+	case err == nil:
+		exchange.StatusCode = response.StatusCode
+	case errors.As(err, &statusErr):
+		exchange.StatusCode = statusErr.StatusCode
+		exchange.Error = err.Error()
+		exchange.ResponseBody = statusErr.Body
+	default:
+		exchange.Error = err.Error()
+	}
+
+	s.exchanges.Insert(exchange)
+
+	return response, err
+}
+
+// expectBalance records the balance after the successful request
+func (s *Service) expectBalance(requestID string) {
+	state := s.PlayerBalance()
+
+	s.log.Info("Expect balance after request", zap.Any("balance", state))
+
+	findFunc := func(e *Exchange) bool { return e.Request.RequestID == requestID }
+
+	exchange, ok := s.exchanges.Get(findFunc)
+	if !ok {
+		return
+	}
+
+	updated := *exchange
+	updated.ExpectedBalance = state
+
+	s.exchanges.Replace(&updated, findFunc)
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/random"
+	"github.com/databet-cloud/callback-test-tool/internal/restriction"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
+)
+
+// Policy - automatic answer of the operator to placed bets, it completes the lifecycle of the bet without a human
+type Policy struct {
+	// AcceptProbability - probability of the accept, the bet is declined otherwise
+	AcceptProbability float64
+	// MinDelay, MaxDelay - range of the random delay between place and accept or decline
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	// DeclineWeights - weights of restriction types of declines
+	DeclineWeights map[callback.RestrictionType]int
+	// MatchDuration - delay between accept and settlement, zero disables auto-settlement
+	MatchDuration time.Duration
+	// ResultWeights - weights of odd statuses declared on auto-settlement
+	ResultWeights map[sportsbook.OddStatus]int
+}
+
+// Validate checks the policy against the catalog of restrictions
+func (p *Policy) Validate(catalog *restriction.Catalog) error {
+	if p.AcceptProbability < 0 || p.AcceptProbability > 1 {
+		return fmt.Errorf("accept probability %v is out of [0, 1]", p.AcceptProbability)
+	}
+
+	if p.MinDelay < 0 || p.MaxDelay < p.MinDelay {
+		return fmt.Errorf("invalid delay range [%s, %s]", p.MinDelay, p.MaxDelay)
+	}
+
+	if p.AcceptProbability < 1 {
+		if err := validateWeights(p.DeclineWeights); err != nil {
+			return fmt.Errorf("decline weights: %w", err)
+		}
+
+		for restrictionType := range p.DeclineWeights {
+			if _, ok := catalog.Default(restrictionType); !ok {
+				return fmt.Errorf("decline weights: unknown restriction %s", restrictionType)
+			}
+		}
+	}
+
+	if p.MatchDuration > 0 {
+		if err := validateWeights(p.ResultWeights); err != nil {
+			return fmt.Errorf("result weights: %w", err)
+		}
+
+		for status := range p.ResultWeights {
+			if !slices.Contains(resultStatuses, status) {
+				return fmt.Errorf("result weights: invalid odd status %s", status)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resultStatuses - statuses which can be declared on auto-settlement
+var resultStatuses = []sportsbook.OddStatus{
+	sportsbook.OddStatusWin,
+	sportsbook.OddStatusLoss,
+	sportsbook.OddStatusHalfWin,
+	sportsbook.OddStatusHalfLoss,
+	sportsbook.OddStatusRefunded,
+	sportsbook.OddStatusCancelled,
+}
+
+// AnswersAutomatically reports whether placed bets are answered by the policy or the risk engine
+func (s *Service) AnswersAutomatically() bool {
+	return s.policy != nil || s.risk != nil
+}
+
+// applyPolicy answers the placed bet after the delay, the risk engine declines the bet before the policy.
+// Every bet has its own source of random values, IDs and time, so decisions and requests
+// do not depend on the order of concurrent bets and manual actions
+func (s *Service) applyPolicy(ctx context.Context, bet *callback.Data, src source) {
+	rnd := src.rand
+
+	if !sleep(ctx, randDuration(rnd, s.policy.MinDelay, s.policy.MaxDelay)) {
+		return
+	}
+
+	if s.risk != nil {
+		restrictions, err := s.riskRestrictions(bet)
+		if err != nil {
+			s.log.Error("failed to evaluate bet risk", zap.String("id", bet.BetID), zap.Error(err))
+			return
+		}
+
+		if len(restrictions) > 0 {
+			s.log.Info("Risk engine declines bet", zap.String("id", bet.BetID), zap.Any("restrictions", restrictions))
+
+			if err := s.declineBet(ctx, bet.BetID, restrictions, src); err != nil {
+				s.log.Error("policy failed to decline bet", zap.String("id", bet.BetID), zap.Error(err))
+			}
+
+			return
+		}
+
+		// the exposure is reserved by the risk engine until the bet is accepted
+		defer s.releaseRisk(bet.BetID)
+	}
+
+	if rnd.Float64() >= s.policy.AcceptProbability {
+		restrictionType := weightedSelect(rnd, s.policy.DeclineWeights)
+
+		r, err := s.renderRestriction(bet, restrictionType, randSelect(rnd, bet.PrivateOdds))
+		if err != nil {
+			s.log.Error("failed to render restriction", zap.String("id", bet.BetID), zap.Error(err))
+			return
+		}
+
+		s.log.Info("Policy declines bet", zap.String("id", bet.BetID), zap.Stringer("restriction", restrictionType))
+
+		if err := s.declineBet(ctx, bet.BetID, []callback.Restriction{r}, src); err != nil {
+			s.log.Error("policy failed to decline bet", zap.String("id", bet.BetID), zap.Error(err))
+		}
+
+		return
+	}
+
+	s.log.Info("Policy accepts bet", zap.String("id", bet.BetID))
+
+	if err := s.acceptBet(ctx, bet.BetID, src); err != nil {
+		s.log.Error("policy failed to accept bet", zap.String("id", bet.BetID), zap.Error(err))
+		return
+	}
+
+	if s.policy.MatchDuration <= 0 {
+		return
+	}
+
+	if _, ok := s.bets.Get(func(d *callback.Data) bool {
+		return d.BetID == bet.BetID && d.RequestType == callback.BetAcceptRequestType
+	}); !ok {
+		return
+	}
+
+	if !sleep(ctx, s.policy.MatchDuration) {
+		return
+	}
+
+	if err := s.resolveSportEvent(ctx, s.randomResults(bet, rnd), src); err != nil {
+		s.log.Error("failed to resolve sport events of bet", zap.String("id", bet.BetID), zap.Error(err))
+	}
+}
+
+// randomResults declares random statuses of legs of the bet, declared results of legs are kept
+func (s *Service) randomResults(bet *callback.Data, rnd *random.Rand) []*OddResult {
+	results := make([]*OddResult, 0, len(bet.PrivateOdds))
+
+	for _, odd := range bet.PrivateOdds {
+		declared, ok := s.oddResults.Get(func(r *OddResult) bool { return r.matches(odd) })
+		if ok && declared.Status != sportsbook.OddStatusNotResulted {
+			results = append(results, declared)
+			continue
+		}
+
+		results = append(results, &OddResult{
+			MatchID:  odd.MatchId,
+			MarketID: odd.MarketId,
+			OddID:    odd.OddId,
+			Status:   weightedSelect(rnd, s.policy.ResultWeights),
+		})
+	}
+
+	return results
+}
+
+func validateWeights[K comparable](weights map[K]int) error {
+	total := 0
+
+	for key, weight := range weights {
+		if weight < 0 {
+			return fmt.Errorf("negative weight of %v", key)
+		}
+
+		total += weight
+	}
+
+	if total == 0 {
+		return fmt.Errorf("no positive weights")
+	}
+
+	return nil
+}
+
+// weightedSelect selects a random key with the probability proportional to its weight
+func weightedSelect[K cmp.Ordered](rnd *random.Rand, weights map[K]int) K {
+	keys := make([]K, 0, len(weights))
+	total := 0
+
+	for key, weight := range weights {
+		keys = append(keys, key)
+		total += weight
+	}
+
+	// map order is random, sorted keys make the selection depend on the random source only
+	slices.Sort(keys)
+
+	var selected K
+
+	if total <= 0 {
+		return selected
+	}
+
+	n := rnd.Intn(total)
+
+	for _, key := range keys {
+		if n < weights[key] {
+			return key
+		}
+
+		n -= weights[key]
+	}
+
+	return selected
+}
+
+func randDuration(rnd *random.Rand, min, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+
+	return min + time.Duration(rnd.Int63n(int64(max-min)))
+}
+
+// sleep waits the duration, false is returned if the context is done
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
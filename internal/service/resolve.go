@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
+)
+
+// OddResult - declared result of the odd, the same odd can be a leg of many bets
+type OddResult struct {
+	MatchID  string               `json:"match_id"`
+	MarketID string               `json:"market_id"`
+	OddID    string               `json:"odd_id"`
+	Status   sportsbook.OddStatus `json:"status"`
+}
+
+func (r *OddResult) matches(odd *callback.Odd) bool {
+	return r.MatchID == odd.MatchId && r.MarketID == odd.MarketId && r.OddID == odd.OddId
+}
+
+// OpenSelections returns distinct odds of bets which can be settled
+func (s *Service) OpenSelections() []*callback.Odd {
+	odds := make([]*callback.Odd, 0)
+
+	for _, bet := range s.bets.GetMany(isSettleable) {
+		for _, odd := range bet.PrivateOdds {
+			exists := slices.ContainsFunc(odds, func(o *callback.Odd) bool {
+				return o.MatchId == odd.MatchId && o.MarketId == odd.MarketId && o.OddId == odd.OddId
+			})
+
+			if !exists {
+				odds = append(odds, odd)
+			}
+		}
+	}
+
+	return odds
+}
+
+// ResolveSportEvent declares results of odds and settles every open bet which has all legs resolved.
+// Bets with unresolved legs stay open until the rest of results is declared.
+// Errors of all failed settlements are returned together, other bets are still settled.
+func (s *Service) ResolveSportEvent(ctx context.Context, results []*OddResult) error {
+	return s.resolveSportEvent(ctx, results, s.source())
+}
+
+func (s *Service) resolveSportEvent(ctx context.Context, results []*OddResult, src source) error {
+	for _, result := range results {
+		findFunc := func(r *OddResult) bool {
+			return r.MatchID == result.MatchID && r.MarketID == result.MarketID && r.OddID == result.OddID
+		}
+
+		if !s.oddResults.Replace(result, findFunc) {
+			s.oddResults.Insert(result)
+		}
+	}
+
+	var errs []error
+
+	for _, bet := range s.bets.GetMany(isSettleable) {
+		affected := slices.ContainsFunc(bet.PrivateOdds, func(odd *callback.Odd) bool {
+			return slices.ContainsFunc(results, func(r *OddResult) bool { return r.matches(odd) })
+		})
+		if !affected {
+			continue
+		}
+
+		odds, unresolved := s.resolvedOdds(bet, src)
+		if unresolved > 0 {
+			s.log.Info("Bet stays open", zap.String("id", bet.BetID), zap.Int("unresolved_legs", unresolved))
+			continue
+		}
+
+		if err := s.settleBet(ctx, bet.BetID, odds, src); err != nil {
+			errs = append(errs, fmt.Errorf("settle bet %s: %w", bet.BetID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// resolvedOdds returns bet odds with declared statuses and count of legs without result
+func (s *Service) resolvedOdds(bet *callback.Data, src source) ([]*callback.Odd, int) {
+	odds := make([]*callback.Odd, len(bet.PrivateOdds))
+	unresolved := 0
+
+	for i, odd := range bet.PrivateOdds {
+		result, ok := s.oddResults.Get(func(r *OddResult) bool { return r.matches(odd) })
+		if !ok || result.Status == sportsbook.OddStatusNotResulted {
+			odds[i] = odd
+			unresolved++
+
+			continue
+		}
+
+		odds[i] = odd.WithStatus(result.Status, src.clock.Now().UTC())
+	}
+
+	return odds, unresolved
+}
+
+func isSettleable(d *callback.Data) bool {
+	return slices.Contains(SettleableRequestTypes(), d.RequestType)
+}
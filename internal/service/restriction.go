@@ -1,235 +1,56 @@
 package service
 
 import (
-	"errors"
-
-	"github.com/cockroachdb/apd/v3"
+	"fmt"
+	"slices"
 
 	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/restriction"
 )
 
-// nolint:funlen,gocyclo // its ok, because we generate all types of restrictions
-func generateRestriction(t callback.RestrictionType, bet *callback.Data) (restriction callback.Restriction, err error) {
-	odd := randSelect(bet.PrivateOdds)
-	ctx := apd.BaseContext.WithPrecision(100)
+// Restrictions returns catalog of restriction templates
+func (s *Service) Restrictions() *restriction.Catalog {
+	return s.restrictions
+}
 
-	maxBet := apd.New(bet.PrivateStake.Coeff.Int64(), bet.PrivateStake.Exponent)
-	for _, betOdd := range bet.BetOdds {
-		_, err = ctx.Mul(maxBet, maxBet, betOdd.OddRatio)
-		if err != nil {
-			return restriction, err
-		}
+// DraftRestriction renders the restriction template for the leg of the bet, the result can be edited before the decline
+func (s *Service) DraftRestriction(
+	betID string,
+	tmpl *restriction.Template,
+	leg *callback.Odd,
+) (callback.Restriction, error) {
+	bet, ok := s.bets.Get(isDeclinable(betID))
+	if !ok {
+		return callback.Restriction{}, fmt.Errorf("failed to find bet %s: %w", betID, ErrBetNotFound)
 	}
 
-	switch t {
-	case callback.MaxBetRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(bet.PrivateStake),
-				"sport_event_id": odd.MatchId,
-			},
-		}
-	case callback.BetTypeRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"bet_type":       bet.BetType,
-			},
-		}
-	case callback.BetIntervalRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"time_to_wait":   "10.00",
-			},
-		}
-	case callback.SelectionValueRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"market_id":      odd.MarketId,
-				"odd_id":         odd.OddId,
-				"value":          odd.OddRatio.Text('f'),
-			},
-		}
-	case callback.SportEventStatusRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"status":         odd.MatchStatus,
-			},
-		}
-	case callback.SportEventExistenceRestriction, callback.SportEventBetStopRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-			},
-		}
-	case callback.MarketStatusRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"market_id":      odd.MarketId,
-				"status":         odd.MatchStatus,
-			},
-		}
-	case callback.MarketExistenceRestriction, callback.MarketDefectiveRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"market_id":      odd.MarketId,
-			},
-		}
-	case callback.OddStatusRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"market_id":      odd.MarketId,
-				"odd_id":         odd.OddId,
-				"status":         odd.OddStatus,
-				"is_active":      true,
-			},
-		}
-	case callback.OddExistenceRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"market_id":      odd.MarketId,
-				"odd_id":         odd.OddId,
-			},
-		}
-	case callback.PlayerLimitRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"reason":         "limit_exceeded",
-			},
-		}
-	case callback.FreebetNotApplicableRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"reason":         "not_found", // Змінити відповідно до даних
-			},
-		}
-	case callback.FreebetStatusRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"status":         3,
-			},
-		}
-	case callback.FreebetAmountRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":          formatApd(maxBet),
-				"sport_event_id":   odd.MatchId,
-				"freebet_amount":   "1.2",
-				"freebet_currency": "USD",
-				"bet_stake":        bet.PrivateStake.Text('f'),
-				"bet_currency":     "EUR",
-			},
-		}
-	case callback.InsuranceNotApplicableRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"reason":         "not_found",
-			},
-		}
-	case callback.InsuranceStatusRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"status":         "used",
-			},
-		}
-	case callback.InsuranceValueRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":            formatApd(maxBet),
-				"sport_event_id":     odd.MatchId,
-				"given_version":      "sfjgfd89844234h23l",
-				"actual_version":     "eqwrqw89844234h23l",
-				"bet_currency":       "USD",
-				"insurance_currency": "EUR",
-			},
-		}
-	case callback.InternalErrorRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"code":           "place_retry_limit_reached",
-			},
-		}
-	case callback.MinBetRestriction:
-		minBet := apd.New(0, 0)
-		if _, err := ctx.Add(minBet, maxBet, bet.PrivateStake); err != nil {
-			return restriction, err
-		}
+	if !slices.ContainsFunc(bet.PrivateOdds, func(odd *callback.Odd) bool {
+		return odd.MatchId == leg.MatchId && odd.MarketId == leg.MarketId && odd.OddId == leg.OddId
+	}) {
+		return callback.Restriction{}, fmt.Errorf("odd %s is not a leg of the bet %s", leg.OddId, betID)
+	}
 
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"min_bet":        formatApd(minBet),
-			},
-		}
-	case callback.NotEnoughBalanceRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"actual_balance": "1.5",
-			},
-		}
-	case callback.WLDefinedRestriction:
-		restriction = callback.Restriction{
-			Type: t,
-			Context: map[string]interface{}{
-				"max_bet":        formatApd(maxBet),
-				"sport_event_id": odd.MatchId,
-				"code":           "player_limit_reached",
-			},
-		}
-	default:
-		err = errors.New("unknown restriction type")
+	data, err := restriction.NewData(bet, leg, s.wallet())
+	if err != nil {
+		return callback.Restriction{}, err
 	}
 
-	return restriction, err
+	return tmpl.Render(data)
+}
+
+func (s *Service) wallet() restriction.Wallet {
+	return restriction.Wallet{
+		Currency:          s.PlayerCurrency(),
+		FreebetCurrency:   s.currencies.Freebet,
+		InsuranceCurrency: s.currencies.Insurance,
+		Rates:             s.currencies.Rates,
+		Balance:           s.PlayerBalance().Available,
+	}
+}
+
+func isDeclinable(betID string) func(d *callback.Data) bool {
+	return func(d *callback.Data) bool {
+		return d.BetID == betID &&
+			(d.RequestType == callback.BetPlaceRequestType || d.RequestType == callback.BetAcceptRequestType)
+	}
 }
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/restriction"
+	"github.com/databet-cloud/callback-test-tool/internal/risk"
+)
+
+// RiskEnabled reports whether placed bets are accepted or declined by the risk engine
+func (s *Service) RiskEnabled() bool {
+	return s.risk != nil
+}
+
+// decideBet accepts the placed bet or declines it with restrictions of broken limits
+func (s *Service) decideBet(ctx context.Context, bet *callback.Data) {
+	restrictions, err := s.riskRestrictions(bet)
+	if err != nil {
+		s.log.Error("failed to evaluate bet risk", zap.String("id", bet.BetID), zap.Error(err))
+		return
+	}
+
+	if len(restrictions) == 0 {
+		s.log.Info("Risk engine accepts bet", zap.String("id", bet.BetID))
+
+		if err := s.AcceptBet(ctx, bet.BetID); err != nil {
+			s.releaseRisk(bet.BetID)
+			s.log.Error("risk engine failed to accept bet", zap.String("id", bet.BetID), zap.Error(err))
+		}
+
+		return
+	}
+
+	s.log.Info("Risk engine declines bet", zap.String("id", bet.BetID), zap.Any("restrictions", restrictions))
+
+	if err := s.DeclineBet(ctx, bet.BetID, restrictions); err != nil {
+		s.log.Error("risk engine failed to decline bet", zap.String("id", bet.BetID), zap.Error(err))
+	}
+}
+
+// riskRestrictions evaluates the bet by the risk engine and returns restrictions of broken limits,
+// the exposure of the bet without restrictions is reserved, so concurrent bets are checked against it
+func (s *Service) riskRestrictions(bet *callback.Data) ([]callback.Restriction, error) {
+	decision, err := s.risk.Reserve(bet)
+	if err != nil {
+		return nil, err
+	}
+
+	restrictions := make([]callback.Restriction, 0, len(decision.Violations))
+
+	for _, violation := range decision.Violations {
+		r, err := s.violationRestriction(bet, violation)
+		if err != nil {
+			return nil, err
+		}
+
+		restrictions = append(restrictions, r)
+	}
+
+	return restrictions, nil
+}
+
+// violationRestriction renders the default template of the restriction type,
+// context values computed by the engine replace rendered values
+func (s *Service) violationRestriction(bet *callback.Data, violation risk.Violation) (callback.Restriction, error) {
+	r, err := s.renderRestriction(bet, violation.Type, violation.Odd)
+	if err != nil {
+		return r, err
+	}
+
+	for key, value := range violation.Context {
+		r.Context[key] = value
+	}
+
+	return r, nil
+}
+
+// renderRestriction renders the default template of the restriction type for the leg of the bet
+func (s *Service) renderRestriction(
+	bet *callback.Data,
+	restrictionType callback.RestrictionType,
+	leg *callback.Odd,
+) (callback.Restriction, error) {
+	tmpl, ok := s.restrictions.Default(restrictionType)
+	if !ok {
+		return callback.Restriction{}, fmt.Errorf("no templates of the restriction %s", restrictionType)
+	}
+
+	data, err := restriction.NewData(bet, leg, s.wallet())
+	if err != nil {
+		return callback.Restriction{}, err
+	}
+
+	return tmpl.Render(data)
+}
+
+// openRisk adds the accepted bet to the exposure of the risk engine
+func (s *Service) openRisk(bet *callback.Data) {
+	if s.risk == nil {
+		return
+	}
+
+	if err := s.risk.Open(bet); err != nil {
+		s.log.Error("failed to open bet risk", zap.String("id", bet.BetID), zap.Error(err))
+	}
+}
+
+// releaseRisk removes the exposure reserved for the bet which is not accepted
+func (s *Service) releaseRisk(betID string) {
+	defer s.lockBet(betID)()
+
+	if _, ok := s.bets.Get(func(d *callback.Data) bool {
+		return d.BetID == betID && d.RequestType != callback.BetPlaceRequestType && d.RequestType != callback.BetDeclineRequestType
+	}); ok {
+		return
+	}
+
+	s.closeRisk(betID)
+}
+
+// closeRisk removes the settled bet from the exposure of the risk engine
+func (s *Service) closeRisk(betID string) {
+	if s.risk == nil {
+		return
+	}
+
+	if err := s.risk.Close(betID); err != nil {
+		s.log.Error("failed to close bet risk", zap.String("id", betID), zap.Error(err))
+	}
+}
@@ -2,37 +2,79 @@ package service
 
 import (
 	"context"
-	"math/rand"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httputil"
 	"slices"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/cockroachdb/apd/v3"
-	"github.com/google/uuid"
-	"github.com/rs/xid"
 	"go.uber.org/zap"
 
 	"github.com/databet-cloud/callback-test-tool/internal/balance"
+	"github.com/databet-cloud/callback-test-tool/internal/betting"
 	"github.com/databet-cloud/callback-test-tool/internal/calculator"
 	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/clock"
+	"github.com/databet-cloud/callback-test-tool/internal/currency"
+	"github.com/databet-cloud/callback-test-tool/internal/random"
+	"github.com/databet-cloud/callback-test-tool/internal/restriction"
+	"github.com/databet-cloud/callback-test-tool/internal/risk"
 	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
 	"github.com/databet-cloud/callback-test-tool/internal/storage"
 )
 
+// SportEventSource provides sport events to place bets on
+type SportEventSource interface {
+	SportEvents(ctx context.Context, count int) ([]sportsbook.SportEvent, error)
+}
+
+// Currencies - currencies of the player bonuses and exchange rates between all currencies
+type Currencies struct {
+	Rates     *currency.Registry
+	Freebet   currency.Currency
+	Insurance currency.Currency
+}
+
+// OddsSource provides actual state of sport events to value cash-outs
+type OddsSource interface {
+	SportEventsByIDs(ctx context.Context, ids []string) ([]sportsbook.SportEvent, error)
+}
+
 type Service struct {
-	playerID         string
-	playerBalance    *balance.Service
-	sportsBookClient *sportsbook.Client
-	callbackClient   *callback.Client
-	calculator       *calculator.Calculator
+	playerID       string
+	playerBalance  *balance.Service
+	tokens         *betting.TokenSource
+	sportEvents    SportEventSource
+	odds           OddsSource
+	callbackClient *callback.Client
+	calculator     *calculator.Calculator
+	cashOutCalc    *calculator.CashOutCalc
+	currencies     Currencies
+	restrictions   *restriction.Catalog
+	// risk decides on placed bets, bets are decided manually if nil
+	risk *risk.Engine
+	// policy answers placed bets after a delay, the risk engine answers immediately if nil
+	policy *Policy
+	// rand and clock - sources of random values, IDs and time of generated requests
+	rand  *random.Rand
+	clock clock.Clock
 
 	// list of bets in actual state
 	bets     *storage.Storage[*callback.Data]
-	cashOuts *storage.Storage[*callback.Data]
+	cashOuts *storage.Storage[*CashOutOrder]
 	// all sent requests
 	sentRequests *storage.Storage[*callback.Data]
+	// declared results of odds
+	oddResults *storage.Storage[*OddResult]
+	// all sent callbacks with results
+	exchanges *storage.Storage[*Exchange]
+	// betLocks - mutexes by bet ID, actions of the same bet are serialized,
+	// so the policy and manual actions do not answer the bet in the same state twice
+	betLocks sync.Map
 
 	log *zap.Logger
 }
@@ -40,25 +82,81 @@ type Service struct {
 func NewService(
 	playerID string,
 	playerBalance *balance.Service,
-	sportsBookClient *sportsbook.Client,
+	tokens *betting.TokenSource,
+	sportEvents SportEventSource,
+	odds OddsSource,
 	callbackClient *callback.Client,
 	calc *calculator.Calculator,
+	cashOutCalc *calculator.CashOutCalc,
+	currencies Currencies,
+	restrictions *restriction.Catalog,
+	riskEngine *risk.Engine,
+	policy *Policy,
+	rnd *random.Rand,
+	clk clock.Clock,
 	log *zap.Logger,
 ) *Service {
 	return &Service{
-		playerID:         playerID,
-		playerBalance:    playerBalance,
-		sportsBookClient: sportsBookClient,
-		callbackClient:   callbackClient,
-		calculator:       calc,
-		bets:             storage.New[*callback.Data](100),
-		cashOuts:         storage.New[*callback.Data](100),
-		sentRequests:     storage.New[*callback.Data](400),
-		log:              log,
+		playerID:       playerID,
+		playerBalance:  playerBalance,
+		tokens:         tokens,
+		sportEvents:    sportEvents,
+		odds:           odds,
+		callbackClient: callbackClient,
+		calculator:     calc,
+		cashOutCalc:    cashOutCalc,
+		currencies:     currencies,
+		restrictions:   restrictions,
+		risk:           riskEngine,
+		policy:         policy,
+		rand:           rnd,
+		clock:          clk,
+		bets:           storage.New[*callback.Data](100),
+		cashOuts:       storage.New[*CashOutOrder](100),
+		sentRequests:   storage.New[*callback.Data](400),
+		oddResults:     storage.New[*OddResult](100),
+		exchanges:      storage.New[*Exchange](400),
+		log:            log,
+	}
+}
+
+// source - random values, IDs and time of generated requests. Actions of the policy use the source forked
+// for the bet, so they do not change the sequence of the session from background goroutines
+type source struct {
+	rand  *random.Rand
+	clock clock.Clock
+}
+
+// source returns the source of the session
+func (s *Service) source() source {
+	return source{rand: s.rand, clock: s.clock}
+}
+
+// forkSource returns the independent source which is seeded by the source of the session
+func (s *Service) forkSource() source {
+	return source{rand: s.rand.Fork(), clock: s.clock.Fork()}
+}
+
+// lockBet locks actions of the bet until the returned function is called
+func (s *Service) lockBet(betID string) (unlock func()) {
+	mu, _ := s.betLocks.LoadOrStore(betID, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+
+	return mu.(*sync.Mutex).Unlock
+}
+
+// SettleableRequestTypes - states of bets which can be settled
+func SettleableRequestTypes() []callback.RequestType {
+	return []callback.RequestType{
+		callback.BetAcceptRequestType,
+		callback.BetUnSettleRequestType,
+		callback.BetCashOutOrdersAcceptedRequestType,
+		callback.BetCashOutOrdersDeclinedRequestType,
 	}
 }
 
-func (s *Service) PlaceBet(ctx context.Context, betType callback.BetType, amount float64) {
+// PlaceBet places bet with the stake in the currency of the player
+func (s *Service) PlaceBet(ctx context.Context, betType callback.BetType, amount *apd.Decimal) (*callback.Data, error) {
 	sportEventsCount := 0
 
 	switch betType {
@@ -71,58 +169,73 @@ func (s *Service) PlaceBet(ctx context.Context, betType callback.BetType, amount
 	}
 
 	if sportEventsCount == 0 {
-		s.log.Error("invalid bet type")
-		return
+		return nil, s.fail("invalid bet type", ErrInvalidBetType)
 	}
 
-	decimalAmount, err := apd.New(0, 0).SetFloat64(amount)
-	if err != nil {
-		s.log.Error("invalid amount", zap.Float64("amount", amount), zap.Error(err))
-		return
+	amountField := zap.String("amount", amount.Text('f'))
+
+	if err := s.PlayerCurrency().Validate(amount); err != nil {
+		return nil, s.fail("failed to place bet", fmt.Errorf("%w: %w", ErrInvalidAmount, err), amountField)
 	}
 
-	sportEvents, err := s.sportsBookClient.SportEventsByFilter(ctx, 0, sportEventsCount)
+	if amount.IsZero() {
+		return nil, s.fail("failed to place bet", fmt.Errorf("%w: stake is zero", ErrInvalidAmount), amountField)
+	}
+
+	sportEvents, err := s.sportEvents.SportEvents(ctx, sportEventsCount)
 	if err != nil {
-		s.log.Error("failed to get sport events", zap.Error(err))
-		return
+		return nil, s.fail("failed to get sport events", err)
 	}
 
-	data := s.generatePlaceBetData(betType, decimalAmount, sportEvents)
+	data := s.generatePlaceBetData(betType, amount, sportEvents)
 
 	s.log.Info("Player balance before request", zap.Any("balance", s.PlayerBalance()))
 
-	response, err := s.callbackClient.SendCallback(ctx, data)
+	response, err := s.sendCallback(ctx, data)
 	if err != nil {
-		s.log.Error("failed to send bet place", zap.Error(err))
-		return
+		return nil, s.fail("failed to send bet place", err)
 	}
 
-	s.playerBalance.Hold(decimalAmount)
+	s.playerBalance.Hold(amount)
 	s.sentRequests.Insert(data)
 	s.bets.Insert(data)
 
 	s.processResponse(response)
 
-	s.log.Info("Expect balance after request", zap.Any("balance", s.PlayerBalance()))
+	s.expectBalance(data.RequestID)
+
+	switch {
+	case s.policy != nil:
+		// the policy answers after the place request is finished, so its context is not canceled with the request
+		go s.applyPolicy(context.WithoutCancel(ctx), data, s.forkSource())
+	case s.risk != nil:
+		s.decideBet(ctx, data)
+	}
+
+	return data, nil
 }
 
-func (s *Service) AcceptBet(ctx context.Context, betID string) {
+func (s *Service) AcceptBet(ctx context.Context, betID string) error {
+	return s.acceptBet(ctx, betID, s.source())
+}
+
+func (s *Service) acceptBet(ctx context.Context, betID string, src source) error {
+	defer s.lockBet(betID)()
+
 	placedBetFunc := func(d *callback.Data) bool {
 		return d.BetID == betID && d.RequestType == callback.BetPlaceRequestType
 	}
 
 	bet, ok := s.bets.Get(placedBetFunc)
 	if !ok {
-		s.log.Error("failed to find placed bet", zap.String("id", betID))
-		return
+		return s.fail("failed to find placed bet", ErrBetNotFound, zap.String("id", betID))
 	}
 
-	acceptedBet := bet.WithRequestType(callback.BetAcceptRequestType).WithRequestID(uuid.New().String())
+	acceptedBet := bet.WithRequestType(callback.BetAcceptRequestType).WithRequestID(src.rand.UUID())
 
-	response, err := s.callbackClient.SendCallback(ctx, acceptedBet)
+	response, err := s.sendCallback(ctx, acceptedBet)
 	if err != nil {
-		s.log.Error("failed to send bet accept", zap.Error(err))
-		return
+		return s.fail("failed to send bet accept", err)
 	}
 
 	s.sentRequests.Insert(acceptedBet)
@@ -131,25 +244,26 @@ func (s *Service) AcceptBet(ctx context.Context, betID string) {
 		s.log.Error("failed to replace placed bet", zap.String("id", betID))
 	}
 
+	s.openRisk(acceptedBet)
+
 	s.processResponse(response)
+
+	return nil
 }
 
-func (s *Service) DeclineBet(ctx context.Context, betID string, restrictionType callback.RestrictionType) {
-	betFindFunc := func(d *callback.Data) bool {
-		return d.BetID == betID &&
-			(d.RequestType == callback.BetPlaceRequestType || d.RequestType == callback.BetAcceptRequestType)
-	}
+// DeclineBet declines the bet with restrictions drafted by DraftRestriction
+func (s *Service) DeclineBet(ctx context.Context, betID string, restrictions []callback.Restriction) error {
+	return s.declineBet(ctx, betID, restrictions, s.source())
+}
+
+func (s *Service) declineBet(ctx context.Context, betID string, restrictions []callback.Restriction, src source) error {
+	defer s.lockBet(betID)()
+
+	betFindFunc := isDeclinable(betID)
 
 	bet, ok := s.bets.Get(betFindFunc)
 	if !ok {
-		s.log.Error("failed to find bet", zap.String("id", betID))
-		return
-	}
-
-	restriction, err := generateRestriction(restrictionType, bet)
-	if err != nil {
-		s.log.Error("failed to generate restriction", zap.Error(err))
-		return
+		return s.fail("failed to find bet", ErrBetNotFound, zap.String("id", betID))
 	}
 
 	data := &callback.Data{
@@ -160,58 +274,78 @@ func (s *Service) DeclineBet(ctx context.Context, betID string, restrictionType
 		PrivateBetSystemSizes: bet.PrivateBetSystemSizes,
 		PrivateCashOutAmount:  bet.PrivateCashOutAmount,
 
-		RequestID:    uuid.NewString(),
+		RequestID:    src.rand.UUID(),
 		BetID:        bet.BetID,
 		BetPlayerID:  s.playerID,
-		Restrictions: []callback.Restriction{restriction},
+		Restrictions: restrictions,
 	}
 
 	s.log.Info("Player balance before request", zap.Any("balance", s.PlayerBalance()))
 
-	response, err := s.callbackClient.SendCallback(ctx, data)
+	response, err := s.sendCallback(ctx, data)
 	if err != nil {
-		s.log.Error("failed to send bet decline", zap.Error(err))
-		return
+		return s.fail("failed to send bet decline", err)
 	}
 
 	s.playerBalance.UnHold(bet.PrivateStake)
-	s.log.Info("Expect balance after request", zap.Any("balance", s.PlayerBalance()))
+	s.expectBalance(data.RequestID)
 
 	s.sentRequests.Insert(data)
 	s.bets.Replace(data, betFindFunc)
 
 	s.processResponse(response)
+
+	return nil
+}
+
+func (s *Service) SettleBet(ctx context.Context, betID string, odds []*callback.Odd) error {
+	return s.settleBet(ctx, betID, odds, s.source())
 }
 
 // nolint:funlen // extended limit of lines to handle all possible ways in the single function
-func (s *Service) SettleBet(ctx context.Context, betID string, odds []*callback.Odd) {
+func (s *Service) settleBet(ctx context.Context, betID string, odds []*callback.Odd, src source) error {
+	defer s.lockBet(betID)()
+
 	betFindFunc := func(d *callback.Data) bool {
-		return d.BetID == betID &&
-			(d.RequestType == callback.BetAcceptRequestType ||
-				d.RequestType == callback.BetUnSettleRequestType ||
-				d.RequestType == callback.BetCashOutOrdersAcceptedRequestType ||
-				d.RequestType == callback.BetCashOutOrdersDeclinedRequestType)
+		return d.BetID == betID && isSettleable(d)
 	}
 
 	bet, ok := s.bets.Get(betFindFunc)
 	if !ok {
-		s.log.Error("failed to find bet", zap.String("id", betID))
-		return
+		return s.fail("failed to find bet", ErrBetNotFound, zap.String("id", betID))
+	}
+
+	// part of the stake which is not cashed out
+	stake, err := remainingStake(bet)
+	if err != nil {
+		return s.fail("failed to calculate remaining stake", err, zap.String("id", betID))
 	}
 
 	settleAmount, settleType, err := s.calculator.Settle(
 		bet.PrivateBetType,
 		bet.PrivateBetSystemSizes,
-		bet.PrivateStake,
+		stake,
 		odds,
 	)
+	if errors.Is(err, calculator.ErrNotResulted) {
+		s.log.Warn("bet has not resulted odds, settlement is blocked", zap.String("id", betID))
+		return err
+	}
+
 	if err != nil {
-		s.log.Error("failed to settle bet", zap.String("id", betID), zap.Error(err))
-		return
+		return s.fail("failed to settle bet", err, zap.String("id", betID))
 	}
 
-	// patch values after cash-out
-	if bet.RequestType == callback.BetCashOutOrdersAcceptedRequestType {
+	settleAmount, err = s.PlayerCurrency().Round(settleAmount)
+	if err != nil {
+		return s.fail("failed to round settle amount", err, zap.String("id", betID))
+	}
+
+	// settle type depends on the amount which is paid in the currency
+	settleType = calculator.SettleTypeOf(settleAmount, stake)
+
+	// patch values after full cash-out
+	if stake.IsZero() {
 		settleType = callback.LossSettleType
 		settleAmount = apd.New(0, 0)
 	}
@@ -223,58 +357,66 @@ func (s *Service) SettleBet(ctx context.Context, betID string, odds []*callback.
 		PrivateBetType:        bet.PrivateBetType,
 		PrivateBetSystemSizes: bet.PrivateBetSystemSizes,
 		PrivateCashOutAmount:  bet.PrivateCashOutAmount,
+		PrivateCashOutStake:   bet.PrivateCashOutStake,
 
-		RequestID:    uuid.NewString(),
+		RequestID:    src.rand.UUID(),
 		BetID:        bet.BetID,
 		BetPlayerID:  s.playerID,
 		BetOdds:      odds,
-		SettleAmount: formatApd(settleAmount),
+		SettleAmount: s.formatAmount(settleAmount),
 		SettleType:   settleType,
 	}
 
 	s.log.Info("Player balance before request", zap.Any("balance", s.PlayerBalance()))
 
-	response, err := s.callbackClient.SendCallback(ctx, data)
+	response, err := s.sendCallback(ctx, data)
 	if err != nil {
-		s.log.Error("failed to send bet settle win", zap.Error(err))
-		return
+		return s.fail("failed to send bet settle win", err)
 	}
 
 	switch {
-	case bet.RequestType == callback.BetCashOutOrdersAcceptedRequestType:
-		// do nothing
+	case stake.IsZero():
+		// do nothing, the whole stake is cashed out
 	case settleType == callback.WinSettleType:
-		s.playerBalance.WithdrawHold(bet.PrivateStake) // remove stake from hold
-		s.playerBalance.Deposit(settleAmount)          // accrual
+		s.playerBalance.WithdrawHold(stake)   // remove stake from hold
+		s.playerBalance.Deposit(settleAmount) // accrual
 	case settleType == callback.RefundSettleType:
-		s.playerBalance.UnHold(bet.PrivateStake) // return stake
+		s.playerBalance.UnHold(stake) // return stake
 	case settleType == callback.LossSettleType:
-		s.playerBalance.WithdrawHold(bet.PrivateStake) // remove stake
+		s.playerBalance.WithdrawHold(stake) // remove stake
 	}
 
-	s.log.Info("Expect balance after request", zap.Any("balance", s.PlayerBalance()))
+	s.expectBalance(data.RequestID)
 
 	s.sentRequests.Insert(data)
 	s.bets.Replace(data, betFindFunc)
+	s.closeRisk(data.BetID)
 
 	s.processResponse(response)
+
+	return nil
 }
 
-func (s *Service) UnSettleBet(ctx context.Context, betID string) {
+func (s *Service) UnSettleBet(ctx context.Context, betID string) error {
+	defer s.lockBet(betID)()
+
 	betFindFunc := func(d *callback.Data) bool {
 		return d.BetID == betID && d.RequestType == callback.BetSettleRequestType
 	}
 
 	bet, ok := s.bets.Get(betFindFunc)
 	if !ok {
-		s.log.Error("failed to find bet", zap.String("id", betID))
-		return
+		return s.fail("failed to find bet", ErrBetNotFound, zap.String("id", betID))
 	}
 
 	settleAmount, _, err := apd.NewFromString(bet.SettleAmount)
 	if err != nil {
-		s.log.Error("failed to parse settle amount", zap.String("id", betID))
-		return
+		return s.fail("failed to parse settle amount", err, zap.String("id", betID))
+	}
+
+	stake, err := remainingStake(bet)
+	if err != nil {
+		return s.fail("failed to calculate remaining stake", err, zap.String("id", betID))
 	}
 
 	data := &callback.Data{
@@ -283,8 +425,9 @@ func (s *Service) UnSettleBet(ctx context.Context, betID string) {
 		PrivateOdds:           bet.PrivateOdds,
 		PrivateBetType:        bet.PrivateBetType,
 		PrivateBetSystemSizes: bet.PrivateBetSystemSizes,
-		PrivateCashOutAmount:  nil,
-		RequestID:             uuid.NewString(),
+		PrivateCashOutAmount:  bet.PrivateCashOutAmount,
+		PrivateCashOutStake:   bet.PrivateCashOutStake,
+		RequestID:             s.rand.UUID(),
 		BetID:                 bet.BetID,
 		BetPlayerID:           s.playerID,
 		UnSettleAmount:        bet.SettleAmount,
@@ -292,154 +435,58 @@ func (s *Service) UnSettleBet(ctx context.Context, betID string) {
 
 	s.log.Info("Player balance before request", zap.Any("balance", s.PlayerBalance()))
 
-	response, err := s.callbackClient.SendCallback(ctx, data)
+	response, err := s.sendCallback(ctx, data)
 	if err != nil {
-		s.log.Error("failed to send bet unsettle", zap.Error(err))
-		return
+		return s.fail("failed to send bet unsettle", err)
 	}
 
+	// cash-outs are not affected by unsettle, only the remaining stake returns to hold
 	switch {
-	case bet.PrivateCashOutAmount != nil:
-		s.playerBalance.DepositHold(bet.PrivateStake)
-		s.playerBalance.Withdraw(bet.PrivateCashOutAmount)
+	case stake.IsZero():
+		// do nothing, the whole stake is cashed out
 	case bet.SettleType == callback.WinSettleType:
 		s.playerBalance.Withdraw(settleAmount)
-		s.playerBalance.DepositHold(bet.PrivateStake)
+		s.playerBalance.DepositHold(stake)
 	case bet.SettleType == callback.RefundSettleType:
-		s.playerBalance.Hold(bet.PrivateStake)
+		s.playerBalance.Hold(stake)
 	case bet.SettleType == callback.LossSettleType:
-		s.playerBalance.DepositHold(bet.PrivateStake)
+		s.playerBalance.DepositHold(stake)
 	}
 
-	s.log.Info("Expect balance after request", zap.Any("balance", s.PlayerBalance()))
+	s.expectBalance(data.RequestID)
 
 	s.sentRequests.Insert(data)
 	s.bets.Replace(data, betFindFunc)
+	s.openRisk(data)
 
 	s.processResponse(response)
-}
-
-func (s *Service) AcceptBetCashOut(ctx context.Context, betID string) {
-	betFindFunc := func(d *callback.Data) bool {
-		return d.BetID == betID &&
-			(d.RequestType == callback.BetAcceptRequestType || d.RequestType == callback.BetUnSettleRequestType)
-	}
-
-	bet, ok := s.bets.Get(betFindFunc)
-	if !ok {
-		s.log.Error("failed to find bet", zap.String("id", betID))
-		return
-	}
-
-	// settle bet as half win
-	cashOutAmount, err := s.settleOddsAs(bet, sportsbook.OddStatusHalfWin)
-	if err != nil {
-		s.log.Error(
-			"failed to settle odds as half win",
-			zap.String("id", betID),
-			zap.Error(err),
-		)
-
-		return
-	}
-
-	data := &callback.Data{
-		RequestType:           callback.BetCashOutOrdersAcceptedRequestType,
-		PrivateStake:          bet.PrivateStake,
-		PrivateOdds:           bet.PrivateOdds,
-		PrivateBetType:        bet.PrivateBetType,
-		PrivateBetSystemSizes: bet.PrivateBetSystemSizes,
-		PrivateCashOutAmount:  cashOutAmount,
-
-		RequestID:      uuid.NewString(),
-		BetID:          bet.BetID,
-		CashOutOrderID: uuid.NewString(),
-		Amount:         formatApd(bet.PrivateStake),
-		RefundAmount:   formatApd(cashOutAmount),
-	}
-
-	s.log.Info("Player balance before request", zap.Any("balance", s.PlayerBalance()))
-
-	response, err := s.callbackClient.SendCallback(ctx, data)
-	if err != nil {
-		s.log.Error("failed to send accept bet cash out win", zap.Error(err))
-		return
-	}
-
-	s.playerBalance.WithdrawHold(bet.PrivateStake) // remove from hold
-	s.playerBalance.Deposit(cashOutAmount)         // deposit
-	s.log.Info("Expect balance after request", zap.Any("balance", s.PlayerBalance()))
-
-	s.sentRequests.Insert(data)
-	s.bets.Replace(bet, betFindFunc)
-	s.cashOuts.Insert(data)
-
-	s.processResponse(response)
-}
-
-func (s *Service) DeclineBetCashOut(ctx context.Context, betID, cashOutOrderID string) {
-	betFindFunc := func(d *callback.Data) bool {
-		return d.BetID == betID
-	}
-	cashOutFindFunc := func(d *callback.Data) bool {
-		return d.CashOutOrderID == cashOutOrderID
-	}
-
-	bet, ok := s.bets.Get(betFindFunc)
-	if !ok {
-		s.log.Error("failed to find bet", zap.String("id", betID))
-		return
-	}
 
-	cashOut, ok := s.cashOuts.Get(cashOutFindFunc)
-	if !ok {
-		s.log.Error("failed to find cash-out", zap.String("id", cashOutOrderID))
-		return
-	}
-
-	data := &callback.Data{
-		RequestType:           callback.BetCashOutOrdersDeclinedRequestType,
-		PrivateStake:          bet.PrivateStake,
-		PrivateOdds:           bet.PrivateOdds,
-		PrivateBetType:        bet.PrivateBetType,
-		PrivateBetSystemSizes: bet.PrivateBetSystemSizes,
-		PrivateCashOutAmount:  nil,
-
-		RequestID:       uuid.NewString(),
-		BetID:           bet.BetID,
-		CashOutOrderIDs: []string{cashOut.CashOutOrderID},
-	}
-
-	s.log.Info("Player balance before request", zap.Any("balance", s.PlayerBalance()))
-
-	response, err := s.callbackClient.SendCallback(ctx, data)
-	if err != nil {
-		s.log.Error("failed to send decline bet cash out win", zap.Error(err))
-		return
-	}
-
-	s.playerBalance.DepositHold(cashOut.PrivateStake)
-	s.playerBalance.Withdraw(cashOut.PrivateCashOutAmount)
-	s.log.Info("Expect balance after request", zap.Any("balance", s.PlayerBalance()))
-
-	data.CashOutOrderID = cashOutOrderID
-	s.sentRequests.Insert(data)
-	s.bets.Replace(bet, betFindFunc)
-	s.cashOuts.Replace(data, cashOutFindFunc)
-
-	s.processResponse(response)
+	return nil
 }
 
 func (s *Service) PlayerBalance() balance.Balance {
 	return s.playerBalance.State()
 }
 
+// Now returns current time of the session clock in UTC
+func (s *Service) Now() time.Time {
+	return s.clock.Now().UTC()
+}
+
 func (s *Service) PlayerID() string {
 	return s.playerID
 }
 
-func (s *Service) PlayerToken() string {
-	return s.sportsBookClient.Token()
+func (s *Service) PlayerCurrency() currency.Currency {
+	return s.playerBalance.Currency()
+}
+
+func (s *Service) PlayerToken() betting.Token {
+	return s.tokens.Current()
+}
+
+func (s *Service) RefreshPlayerToken(ctx context.Context) (betting.Token, error) {
+	return s.tokens.Refresh(ctx)
 }
 
 func (s *Service) SentRequests(types ...callback.RequestType) []*storage.Document[*callback.Data] {
@@ -462,20 +509,11 @@ func (s *Service) Bets(types ...callback.RequestType) []*storage.Document[*callb
 	return docs
 }
 
-func (s *Service) CashOuts(types ...callback.RequestType) []*storage.Document[*callback.Data] {
-	docs := s.cashOuts.GetDocuments(func(data *callback.Data) bool {
-		return len(types) == 0 || slices.Contains(types, data.RequestType)
-	})
-
-	slices.Reverse(docs)
-
-	return docs
-}
-
-func (s *Service) ReplayCallback(ctx context.Context, data *callback.Data) {
-	response, err := s.callbackClient.SendCallback(ctx, data)
+// ReplayCallback sends the request again with the same request ID, the callback server is expected to ignore it
+func (s *Service) ReplayCallback(ctx context.Context, data *callback.Data) error {
+	response, err := s.sendCallback(ctx, data)
 	if err != nil {
-		s.log.Error("failed to replay callback", zap.Any("data", data), zap.Error(err))
+		return s.fail("failed to replay callback", err, zap.Any("data", data))
 	}
 
 	s.sentRequests.Replace(data, func(d *callback.Data) bool {
@@ -483,6 +521,8 @@ func (s *Service) ReplayCallback(ctx context.Context, data *callback.Data) {
 	})
 
 	s.processResponse(response)
+
+	return nil
 }
 
 func (s *Service) processResponse(response *http.Response) {
@@ -501,12 +541,13 @@ func (s *Service) generatePlaceBetData(
 	amount *apd.Decimal,
 	sportEvents []sportsbook.SportEvent,
 ) *callback.Data {
+	betCreatedAt := s.clock.Now().UTC()
 	odds := make([]*callback.Odd, 0, len(sportEvents))
 	allCompetitors := make([]callback.Competitor, 0, len(sportEvents))
 
 	for _, sportEvent := range sportEvents {
-		market := randSelect(sportEvent.Markets)
-		odd := randSelect(market.Odds)
+		market := randSelect(s.rand, sportEvent.Markets)
+		odd := randSelect(s.rand, market.Odds)
 
 		competitors := make([]callback.Competitor, 0, len(sportEvent.Fixture.Competitors))
 		for _, cmp := range sportEvent.Fixture.Competitors {
@@ -528,7 +569,7 @@ func (s *Service) generatePlaceBetData(
 			MatchId:      sportEvent.ID,
 			MatchStatus:  sportEvent.Fixture.Status.Int(),
 			MarketId:     market.ID,
-			OddUpdatedAt: time.Now().UTC().Add(-time.Hour),
+			OddUpdatedAt: betCreatedAt.Add(-time.Hour),
 			Meta: callback.OddMeta{
 				MarketType:                 strconv.Itoa(market.TypeId),
 				ProviderID:                 sportEvent.ProviderId,
@@ -551,8 +592,6 @@ func (s *Service) generatePlaceBetData(
 		systemSize--
 	}
 
-	betCreatedAt := time.Now().UTC()
-
 	return &callback.Data{
 		RequestType:           callback.BetPlaceRequestType,
 		PrivateStake:          amount,
@@ -560,11 +599,11 @@ func (s *Service) generatePlaceBetData(
 		PrivateBetType:        betType,
 		PrivateBetSystemSizes: []int{systemSize},
 
-		RequestID:      uuid.NewString(),
-		BetID:          xid.New().String(),
+		RequestID:      s.rand.UUID(),
+		BetID:          s.rand.XID(betCreatedAt),
 		BetPlayerID:    s.playerID,
 		BetType:        betType,
-		BetStake:       formatApd(amount),
+		BetStake:       s.formatAmount(amount),
 		BetOdds:        odds,
 		BetSystemSizes: []int{systemSize},
 		BetCreatedAt:   &betCreatedAt,
@@ -572,30 +611,28 @@ func (s *Service) generatePlaceBetData(
 	}
 }
 
-func (s *Service) settleOddsAs(bet *callback.Data, oddStatus sportsbook.OddStatus) (*apd.Decimal, error) {
-	odds := make([]*callback.Odd, len(bet.PrivateOdds))
-	for i, odd := range bet.PrivateOdds {
-		odds[i] = odd.WithStatus(oddStatus)
-	}
+func newApdCtx() *apd.Context {
+	ctx := apd.BaseContext.WithPrecision(100)
+	ctx.Rounding = apd.RoundDown
 
-	settleAmount, _, err := s.calculator.Settle(bet.PrivateBetType, bet.PrivateBetSystemSizes, bet.PrivateStake, odds)
-	if err != nil {
-		return nil, err
-	}
+	return ctx
+}
 
-	return settleAmount, err
+// formatAmount formats amount in the currency of the player
+func (s *Service) formatAmount(v *apd.Decimal) string {
+	return s.PlayerCurrency().Format(v)
 }
 
 func formatApd(v *apd.Decimal) string {
 	return v.Text('f')
 }
 
-func randSelect[T any](values []T) T {
+func randSelect[T any](rnd *random.Rand, values []T) T {
 	if len(values) == 0 {
 		var t T
 
 		return t
 	}
 
-	return values[rand.Intn(len(values))]
+	return values[rnd.Intn(len(values))]
 }
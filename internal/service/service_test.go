@@ -0,0 +1,262 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+	"github.com/databet-cloud/callback-test-tool/internal/servicetest"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
+	"github.com/databet-cloud/callback-test-tool/internal/testutil/golden"
+)
+
+// TestService_Payloads sends every request type and compares bodies with golden files
+func TestService_Payloads(t *testing.T) {
+	ctx := context.Background()
+	callbacks := newCallbackRecorder(t)
+	sv := servicetest.NewService(t, callbacks.URL)
+
+	placed, err := sv.PlaceBet(ctx, callback.ExpressBetType, apd.New(1000, -2))
+	require.NoError(t, err)
+
+	betID := placed.BetID
+	require.NoError(t, sv.AcceptBet(ctx, betID))
+
+	_, err = sv.CreateCashOutOrder(ctx, betID, 0.5)
+	require.NoError(t, err)
+	require.NoError(t, sv.AcceptCashOutOrder(ctx, createdCashOutOrderID(t, sv, betID)))
+
+	_, err = sv.CreateCashOutOrder(ctx, betID, 0.5)
+	require.NoError(t, err)
+	require.NoError(t, sv.DeclineCashOutOrders(ctx, betID, []string{createdCashOutOrderID(t, sv, betID)}))
+
+	bet := sv.Bets()[0].Value
+	require.NoError(t, sv.SettleBet(ctx, betID, []*callback.Odd{
+		bet.PrivateOdds[0].WithStatus(sportsbook.OddStatusWin, sv.Now()),
+		bet.PrivateOdds[1].WithStatus(sportsbook.OddStatusHalfWin, sv.Now()),
+	}))
+
+	require.NoError(t, sv.UnSettleBet(ctx, betID))
+
+	placed, err = sv.PlaceBet(ctx, callback.SingleBetType, apd.New(500, -2))
+	require.NoError(t, err)
+
+	tmpl, ok := sv.Restrictions().Default(callback.MaxBetRestriction)
+	require.True(t, ok)
+
+	r, err := sv.DraftRestriction(placed.BetID, tmpl, placed.PrivateOdds[0])
+	require.NoError(t, err)
+
+	require.NoError(t, sv.DeclineBet(ctx, placed.BetID, []callback.Restriction{r}))
+
+	expected := []string{
+		"/bet/place",
+		"/bet/accept",
+		"/bet/cash-out-orders/accepted",
+		"/bet/cash-out-orders/declined",
+		"/bet/settle",
+		"/bet/unsettle",
+		"/bet/place",
+		"/bet/decline",
+	}
+
+	requests := callbacks.Requests()
+	require.Len(t, requests, len(expected))
+
+	for i, req := range requests {
+		assert.Equal(t, expected[i], req.path)
+		name := strings.ReplaceAll(strings.TrimPrefix(req.path, "/bet/"), "/", "_")
+		golden.AssertJSON(t, fmt.Sprintf("payloads/%02d_%s", i+1, name), req.body)
+	}
+}
+
+// TestService_Reproducible checks that the same seed and clock produce the same payloads
+func TestService_Reproducible(t *testing.T) {
+	session := func() []recordedRequest {
+		callbacks := newCallbackRecorder(t)
+		sv := servicetest.NewService(t, callbacks.URL)
+
+		bet, err := sv.PlaceBet(context.Background(), callback.SystemBetType, apd.New(10, 0))
+		require.NoError(t, err)
+		require.NoError(t, sv.AcceptBet(context.Background(), bet.BetID))
+
+		return callbacks.Requests()
+	}
+
+	assert.Equal(t, session(), session())
+}
+
+func TestService_PlaceBet_InvalidAmount(t *testing.T) {
+	callbacks := newCallbackRecorder(t)
+	sv := servicetest.NewService(t, callbacks.URL)
+
+	for _, amount := range []*apd.Decimal{apd.New(0, 0), apd.New(-1, 0), apd.New(1, -3)} {
+		_, err := sv.PlaceBet(context.Background(), callback.SingleBetType, amount)
+		require.ErrorIs(t, err, service.ErrInvalidAmount, amount.String())
+		assert.NotContains(t, err.Error(), "%!", amount.String())
+	}
+
+	assert.Empty(t, callbacks.Requests())
+}
+
+// TestService_SettlementBreakdown checks that the breakdown calculated for the sent settle request explains its amount
+func TestService_SettlementBreakdown(t *testing.T) {
+	ctx := context.Background()
+	sv := servicetest.NewService(t, servicetest.NewCallbackServer(t))
+
+	placed, err := sv.PlaceBet(ctx, callback.SystemBetType, apd.New(10, 0))
+	require.NoError(t, err)
+	require.NoError(t, sv.AcceptBet(ctx, placed.BetID))
+
+	_, err = sv.CreateCashOutOrder(ctx, placed.BetID, 0.5)
+	require.NoError(t, err)
+	require.NoError(t, sv.AcceptCashOutOrder(ctx, createdCashOutOrderID(t, sv, placed.BetID)))
+
+	odds := make([]*callback.Odd, len(placed.PrivateOdds))
+	for i, odd := range placed.PrivateOdds {
+		odds[i] = odd.WithStatus(sportsbook.OddStatusWin, sv.Now())
+	}
+
+	require.NoError(t, sv.SettleBet(ctx, placed.BetID, odds))
+
+	settled := sv.Bets()[0].Value
+
+	breakdown, ok, err := sv.SettlementBreakdown(settled.RequestID)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	assert.Equal(t, "5.00", breakdown.Stake.String(), "stake which is not cashed out")
+	assert.Equal(t, settled.SettleAmount, sv.PlayerCurrency().Format(breakdown.Amount))
+	assert.NotEmpty(t, breakdown.Expresses)
+
+	_, ok, err = sv.SettlementBreakdown(placed.RequestID)
+	require.NoError(t, err)
+	assert.False(t, ok, "place request")
+
+	_, err = sv.ExplainSettlement("unknown", odds)
+	require.ErrorIs(t, err, service.ErrBetNotFound)
+}
+
+// TestService_ReproduciblePolicy checks that declines of the policy do not change requests of the session
+// while bets are placed concurrently with them
+func TestService_ReproduciblePolicy(t *testing.T) {
+	const bets = 5
+
+	policy := &service.Policy{
+		MaxDelay:       time.Millisecond,
+		DeclineWeights: map[callback.RestrictionType]int{callback.MaxBetRestriction: 1},
+	}
+
+	session := func() []string {
+		callbacks := newCallbackRecorder(t)
+		sv := servicetest.NewPolicyService(t, callbacks.URL, policy)
+
+		for range bets {
+			_, err := sv.PlaceBet(context.Background(), callback.SingleBetType, apd.New(10, 0))
+			require.NoError(t, err)
+		}
+
+		require.Eventually(t, func() bool {
+			return len(sv.Bets(callback.BetDeclineRequestType)) == bets
+		}, time.Second, time.Millisecond)
+
+		bodies := make([]string, 0, 2*bets)
+		for _, req := range callbacks.Requests() {
+			bodies = append(bodies, string(req.body))
+		}
+
+		// requests of the policy are sent in the order of delays
+		slices.Sort(bodies)
+
+		return bodies
+	}
+
+	assert.Equal(t, session(), session())
+}
+
+// TestService_ConcurrentAnswers accepts the bet by two actions at once like the policy and the player,
+// only one accept is sent
+func TestService_ConcurrentAnswers(t *testing.T) {
+	ctx := context.Background()
+	callbacks := newCallbackRecorder(t)
+	sv := servicetest.NewService(t, callbacks.URL)
+
+	bet, err := sv.PlaceBet(ctx, callback.SingleBetType, apd.New(10, 0))
+	require.NoError(t, err)
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, 2)
+	)
+
+	for i := range errs {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			errs[i] = sv.AcceptBet(ctx, bet.BetID)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Len(t, callbacks.Requests(), 2)
+	assert.Len(t, slices.DeleteFunc(errs, func(err error) bool { return err == nil }), 1)
+}
+
+type recordedRequest struct {
+	path string
+	body []byte
+}
+
+type callbackRecorder struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+func newCallbackRecorder(t *testing.T) *callbackRecorder {
+	recorder := &callbackRecorder{}
+	recorder.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		recorder.mu.Lock()
+		recorder.requests = append(recorder.requests, recordedRequest{path: r.URL.Path, body: body})
+		recorder.mu.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(recorder.Close)
+
+	return recorder
+}
+
+func (r *callbackRecorder) Requests() []recordedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]recordedRequest(nil), r.requests...)
+}
+
+func createdCashOutOrderID(t *testing.T, sv *service.Service, betID string) string {
+	orders := sv.CashOutOrders(betID, service.CashOutOrderCreated)
+	require.Len(t, orders, 1)
+
+	return orders[0].Value.ID
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/databet-cloud/callback-test-tool/internal/calculator"
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+)
+
+// ExplainSettlement calculates breakdown of the bet settlement without sending the request
+func (s *Service) ExplainSettlement(betID string, odds []*callback.Odd) (*calculator.Breakdown, error) {
+	bet, ok := s.bets.Get(func(d *callback.Data) bool {
+		return d.BetID == betID && isSettleable(d)
+	})
+	if !ok {
+		return nil, fmt.Errorf("failed to find bet %s: %w", betID, ErrBetNotFound)
+	}
+
+	return s.explain(bet, odds)
+}
+
+// SettlementBreakdown calculates breakdown of the settle amount sent in the request again,
+// breakdowns are not kept because expresses of large systems take a lot of memory
+func (s *Service) SettlementBreakdown(requestID string) (*calculator.Breakdown, bool, error) {
+	data, ok := s.sentRequests.Get(func(d *callback.Data) bool {
+		return d.RequestID == requestID && d.RequestType == callback.BetSettleRequestType
+	})
+	if !ok {
+		return nil, false, nil
+	}
+
+	breakdown, err := s.explain(data, data.BetOdds)
+
+	return breakdown, true, err
+}
+
+// explain calculates breakdown of the settlement of the stake which is not cashed out
+func (s *Service) explain(bet *callback.Data, odds []*callback.Odd) (*calculator.Breakdown, error) {
+	stake, err := remainingStake(bet)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, breakdown, err := s.calculator.SettleWithBreakdown(
+		bet.PrivateBetType,
+		bet.PrivateBetSystemSizes,
+		stake,
+		odds,
+	)
+
+	return breakdown, err
+}
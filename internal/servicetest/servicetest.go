@@ -0,0 +1,144 @@
+// Package servicetest provides the service with fixed sport events, seed and clock for tests of the service and its clients
+package servicetest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/balance"
+	"github.com/databet-cloud/callback-test-tool/internal/betting"
+	"github.com/databet-cloud/callback-test-tool/internal/calculator"
+	"github.com/databet-cloud/callback-test-tool/internal/calculator/former"
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/clock"
+	"github.com/databet-cloud/callback-test-tool/internal/currency"
+	"github.com/databet-cloud/callback-test-tool/internal/random"
+	"github.com/databet-cloud/callback-test-tool/internal/restriction"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
+)
+
+// NewService creates service of the EUR player with 1000 on the balance which sends callbacks to the URL,
+// the seed and the stepping clock make payloads of the session reproducible
+func NewService(t *testing.T, callbackURL string) *service.Service {
+	return NewPolicyService(t, callbackURL, nil)
+}
+
+// NewPolicyService creates service of NewService which answers placed bets by the policy
+func NewPolicyService(t *testing.T, callbackURL string, policy *service.Policy) *service.Service {
+	log := zap.NewNop()
+
+	rates, err := currency.DefaultRegistry()
+	require.NoError(t, err)
+
+	eur, err := rates.Currency("EUR")
+	require.NoError(t, err)
+
+	usd, err := rates.Currency("USD")
+	require.NoError(t, err)
+
+	restrictions, err := restriction.DefaultCatalog()
+	require.NoError(t, err)
+
+	playerBalance := balance.NewService(eur, log)
+	require.NoError(t, playerBalance.DepositString("1000"))
+
+	refundCalc := calculator.NewRefundCalc(log, former.EachExpressIndexes)
+	events := SportEvents{}
+
+	return service.NewService(
+		"player-1",
+		playerBalance,
+		// the token is never issued, tests do not call the betting API
+		betting.NewTokenSource(nil, nil, time.Hour, log),
+		events,
+		events,
+		callback.NewClient(callbackURL, map[string]any{"site": "test"}, http.DefaultClient, log),
+		calculator.NewCalculator(refundCalc, log),
+		calculator.NewCashOutCalc(refundCalc, apd.New(5, -2), log),
+		service.Currencies{Rates: rates, Freebet: usd, Insurance: eur},
+		restrictions,
+		nil,
+		policy,
+		random.New(42),
+		clock.NewStepping(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), time.Second),
+		log,
+	)
+}
+
+// SportEvents - not started sport events, odds of the placement start from 1.5 and odds of the cash-out are 1.2
+type SportEvents struct{}
+
+func (SportEvents) SportEvents(_ context.Context, count int) ([]sportsbook.SportEvent, error) {
+	events := make([]sportsbook.SportEvent, count)
+
+	for i := range events {
+		events[i] = sportEvent(fmt.Sprintf("match-%d", i+1), apd.New(int64(150+i*25), -2))
+	}
+
+	return events, nil
+}
+
+func (SportEvents) SportEventsByIDs(_ context.Context, ids []string) ([]sportsbook.SportEvent, error) {
+	events := make([]sportsbook.SportEvent, len(ids))
+
+	for i, id := range ids {
+		// odds are shorter than on placement, so the cash-out is valued higher than the stake
+		events[i] = sportEvent(id, apd.New(120, -2))
+	}
+
+	return events, nil
+}
+
+func sportEvent(id string, value *apd.Decimal) sportsbook.SportEvent {
+	event := sportsbook.SportEvent{
+		ID:         id,
+		ProviderId: "provider-1",
+		Fixture: sportsbook.Fixture{
+			StartTime: time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC),
+			SportId:   "sport-1",
+			Status:    sportsbook.MatchStatusNotStarted,
+			Competitors: []*sportsbook.Competitor{
+				{Id: id + "-home", Type: "TEAM"},
+				{Id: id + "-away", Type: "TEAM"},
+			},
+		},
+		Markets: []sportsbook.Market{{
+			ID:     id + "-market",
+			Status: "ACTIVE",
+			TypeId: 1,
+			Odds: []sportsbook.Odd{{
+				ID:     id + "-odd",
+				Value:  value,
+				Status: sportsbook.OddStatusNotResulted,
+			}},
+		}},
+	}
+	event.Fixture.Tournament.Id = "tournament-1"
+	event.Fixture.Tournament.SportId = "sport-1"
+
+	return event
+}
+
+// NewCallbackServer starts the callback server which answers all callbacks with 204
+func NewCallbackServer(t *testing.T) string {
+	return NewCallbackServerFunc(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// NewCallbackServerFunc starts the callback server with the handler, it is closed on cleanup of the test
+func NewCallbackServerFunc(t *testing.T, handler http.HandlerFunc) string {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
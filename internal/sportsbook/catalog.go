@@ -0,0 +1,224 @@
+package sportsbook
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/random"
+)
+
+type CatalogStats struct {
+	Size int `json:"size"`
+	// Hits - count of requests served from memory
+	Hits uint64 `json:"hits"`
+	// Misses - count of requests which required synchronous refresh
+	Misses              uint64        `json:"misses"`
+	Refreshes           uint64        `json:"refreshes"`
+	RefreshErrors       uint64        `json:"refresh_errors"`
+	Evicted             uint64        `json:"evicted"`
+	LastRefreshAt       time.Time     `json:"last_refresh_at"`
+	LastRefreshDuration time.Duration `json:"last_refresh_duration"`
+	LastError           string        `json:"last_error,omitempty"`
+}
+
+// Catalog keeps sport events in memory, so bets are generated without requests to the sportsbook.
+// The catalog is refreshed in the background, ended or suspended sport events are dropped on refresh
+// and counted as evicted.
+type Catalog struct {
+	client   *Client
+	filter   Filter
+	pageSize int
+	maxSize  int
+	rand     *random.Rand
+
+	mu          sync.RWMutex
+	sportEvents []SportEvent
+	stats       CatalogStats
+
+	// refreshMu - only one refresh can be in progress
+	refreshMu sync.Mutex
+
+	log *zap.Logger
+}
+
+func NewCatalog(
+	client *Client,
+	filter Filter,
+	pageSize, maxSize int,
+	rnd *random.Rand,
+	log *zap.Logger,
+) *Catalog {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	return &Catalog{
+		client:   client,
+		filter:   filter,
+		pageSize: pageSize,
+		maxSize:  maxSize,
+		rand:     rnd,
+		log:      log,
+	}
+}
+
+// Run refreshes the catalog every interval until the context is done
+func (c *Catalog) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Refresh(ctx); err != nil {
+				c.log.Warn("failed to refresh sport events catalog", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Refresh fetches sport events from the sportsbook and replaces the catalog content
+func (c *Catalog) Refresh(ctx context.Context) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	startedAt := time.Now()
+
+	sportEvents, err := c.fetch(ctx)
+	if err != nil {
+		c.mu.Lock()
+		c.stats.RefreshErrors++
+		c.stats.LastError = err.Error()
+		c.mu.Unlock()
+
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, sportEvent := range c.sportEvents {
+		if !slices.ContainsFunc(sportEvents, func(e SportEvent) bool { return e.ID == sportEvent.ID }) {
+			c.stats.Evicted++
+		}
+	}
+
+	c.sportEvents = sportEvents
+	c.stats.Refreshes++
+	c.stats.LastRefreshAt = startedAt.UTC()
+	c.stats.LastRefreshDuration = time.Since(startedAt)
+	c.stats.LastError = ""
+
+	c.log.Debug("sport events catalog refreshed", zap.Int("size", len(sportEvents)))
+
+	return nil
+}
+
+// SportEvents returns count of random distinct sport events from the catalog
+func (c *Catalog) SportEvents(ctx context.Context, count int) ([]SportEvent, error) {
+	if sportEvents, ok := c.pick(count); ok {
+		return sportEvents, nil
+	}
+
+	c.mu.Lock()
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	if err := c.Refresh(ctx); err != nil {
+		return nil, fmt.Errorf("refresh catalog: %w", err)
+	}
+
+	if sportEvents, ok := c.pick(count); ok {
+		return sportEvents, nil
+	}
+
+	return nil, ErrNotEnoughSportEvents
+}
+
+// SportEvent returns the sport event from the catalog by id
+func (c *Catalog) SportEvent(id string) (SportEvent, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	index := slices.IndexFunc(c.sportEvents, func(e SportEvent) bool { return e.ID == id })
+	if index == -1 {
+		return SportEvent{}, false
+	}
+
+	return c.sportEvents[index], true
+}
+
+func (c *Catalog) Stats() CatalogStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := c.stats
+	stats.Size = len(c.sportEvents)
+
+	return stats
+}
+
+func (c *Catalog) pick(count int) ([]SportEvent, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.sportEvents) < count {
+		return nil, false
+	}
+
+	c.stats.Hits++
+
+	result := make([]SportEvent, 0, count)
+	for _, i := range c.rand.Perm(len(c.sportEvents))[:count] {
+		result = append(result, c.sportEvents[i])
+	}
+
+	return result, true
+}
+
+func (c *Catalog) fetch(ctx context.Context) ([]SportEvent, error) {
+	sportEvents := make([]SportEvent, 0, c.maxSize)
+	cursor := Cursor{Offset: 0, Limit: c.pageSize}
+
+	for c.maxSize <= 0 || len(sportEvents) < c.maxSize {
+		page, err := c.client.SportEventsByFilter(ctx, c.filter, cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, sportEvent := range page.SportEvents {
+			duplicate := slices.ContainsFunc(sportEvents, func(e SportEvent) bool { return e.ID == sportEvent.ID })
+			if !duplicate && !isFinished(sportEvent.Fixture.Status) {
+				sportEvents = append(sportEvents, sportEvent)
+			}
+		}
+
+		if page.Last {
+			break
+		}
+
+		cursor = page.Next
+	}
+
+	if c.maxSize > 0 && len(sportEvents) > c.maxSize {
+		sportEvents = sportEvents[:c.maxSize]
+	}
+
+	return sportEvents, nil
+}
+
+func isFinished(status MatchStatus) bool {
+	switch status {
+	case MatchStatusSuspended, MatchStatusEnded, MatchStatusClosed, MatchStatusCancelled, MatchStatusAbandoned:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,139 @@
+package sportsbook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/databet-cloud/callback-test-tool/internal/random"
+)
+
+func TestCatalog_Refresh(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxSize     int
+		sportEvents []SportEvent
+		expected    []string
+		requests    int
+	}{
+		{
+			name: "all pages",
+			sportEvents: []SportEvent{
+				testSportEvent("match-1", MatchStatusNotStarted),
+				testSportEvent("match-2", MatchStatusLive),
+				testSportEvent("match-3", MatchStatusNotStarted),
+			},
+			expected: []string{"match-1", "match-2", "match-3"},
+			requests: 2,
+		},
+		{
+			name:    "max size",
+			maxSize: 3,
+			sportEvents: []SportEvent{
+				testSportEvent("match-1", MatchStatusNotStarted),
+				testSportEvent("match-2", MatchStatusNotStarted),
+				testSportEvent("match-3", MatchStatusNotStarted),
+				testSportEvent("match-4", MatchStatusNotStarted),
+				testSportEvent("match-5", MatchStatusNotStarted),
+			},
+			expected: []string{"match-1", "match-2", "match-3"},
+			requests: 2,
+		},
+		{
+			name: "duplicates and finished",
+			sportEvents: []SportEvent{
+				testSportEvent("match-1", MatchStatusNotStarted),
+				testSportEvent("match-2", MatchStatusEnded),
+				testSportEvent("match-1", MatchStatusNotStarted),
+				testSportEvent("match-3", MatchStatusSuspended),
+				testSportEvent("match-4", MatchStatusLive),
+			},
+			expected: []string{"match-1", "match-4"},
+			requests: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sportsbook := newTestSportsbook(t, tt.sportEvents...)
+			catalog := newTestCatalog(sportsbook, tt.maxSize)
+
+			require.NoError(t, catalog.Refresh(context.Background()))
+
+			assert.Equal(t, tt.expected, sportEventIDs(catalog.sportEvents))
+			assert.Equal(t, tt.requests, sportsbook.requestCount())
+
+			stats := catalog.Stats()
+			assert.Equal(t, len(tt.expected), stats.Size)
+			assert.Equal(t, uint64(1), stats.Refreshes)
+			assert.Zero(t, stats.Evicted)
+		})
+	}
+}
+
+func TestCatalog_Evicted(t *testing.T) {
+	sportsbook := newTestSportsbook(t,
+		testSportEvent("match-1", MatchStatusNotStarted),
+		testSportEvent("match-2", MatchStatusLive),
+		testSportEvent("match-3", MatchStatusNotStarted),
+	)
+	catalog := newTestCatalog(sportsbook, 0)
+
+	require.NoError(t, catalog.Refresh(context.Background()))
+
+	// match-2 ends and match-3 is removed from the sportsbook between refreshes
+	sportsbook.set(
+		testSportEvent("match-1", MatchStatusNotStarted),
+		testSportEvent("match-2", MatchStatusEnded),
+		testSportEvent("match-4", MatchStatusNotStarted),
+	)
+
+	require.NoError(t, catalog.Refresh(context.Background()))
+
+	assert.Equal(t, []string{"match-1", "match-4"}, sportEventIDs(catalog.sportEvents))
+
+	_, ok := catalog.SportEvent("match-2")
+	assert.False(t, ok)
+
+	stats := catalog.Stats()
+	assert.Equal(t, uint64(2), stats.Evicted)
+	assert.Equal(t, uint64(2), stats.Refreshes)
+}
+
+func TestCatalog_SportEvents(t *testing.T) {
+	sportsbook := newTestSportsbook(t,
+		testSportEvent("match-1", MatchStatusNotStarted),
+		testSportEvent("match-2", MatchStatusNotStarted),
+	)
+	catalog := newTestCatalog(sportsbook, 0)
+
+	// the empty catalog is refreshed on the miss
+	sportEvents, err := catalog.SportEvents(context.Background(), 2)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"match-1", "match-2"}, sportEventIDs(sportEvents))
+
+	sportEvents, err = catalog.SportEvents(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Len(t, sportEvents, 1)
+
+	requests := sportsbook.requestCount()
+
+	_, err = catalog.SportEvents(context.Background(), 3)
+	require.ErrorIs(t, err, ErrNotEnoughSportEvents)
+
+	stats := catalog.Stats()
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+	assert.Equal(t, uint64(2), stats.Refreshes)
+	// the miss refreshes the catalog before the error
+	assert.Greater(t, sportsbook.requestCount(), requests)
+}
+
+// newTestCatalog returns the catalog which fetches pages of 2 sport events of any status,
+// so only the catalog drops finished sport events
+func newTestCatalog(sportsbook *testSportsbook, maxSize int) *Catalog {
+	return NewCatalog(sportsbook.client, Filter{}, 2, maxSize, random.New(42), zap.NewNop())
+}
@@ -1,9 +1,15 @@
 package sportsbook
 
 import (
+	"bytes"
 	"context"
 	_ "embed"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"slices"
 
 	"go.uber.org/zap"
 
@@ -13,47 +19,209 @@ import (
 var (
 	//go:embed sportEventListByFilters.gql
 	sportEventListByFiltersBody []byte
+	//go:embed sportEventListByIds.gql
+	sportEventListByIdsBody []byte
 )
 
+// ErrUnauthorized - the sportsbook rejected the auth token
+var ErrUnauthorized = errors.New("sportsbook rejected auth token")
+
+// authErrorCodes - codes of extensions of GraphQL errors which are caused by the auth token
+var authErrorCodes = []string{"UNAUTHENTICATED", "UNAUTHORIZED", "FORBIDDEN"}
+
+// Cursor points to the page of sport events list
+type Cursor struct {
+	Offset int
+	Limit  int
+}
+
+func (c Cursor) Next() Cursor {
+	return Cursor{Offset: c.Offset + c.Limit, Limit: c.Limit}
+}
+
+type Page struct {
+	// SportEvents - sport events of the page matched by the filter
+	SportEvents []SportEvent
+	// Next - cursor of the next page
+	Next Cursor
+	// Last - there are no more sport events after this page
+	Last bool
+}
+
+// TokenSource provides auth token for the sportsbook requests
+type TokenSource interface {
+	AuthToken(ctx context.Context) (string, error)
+	RefreshAuthToken(ctx context.Context) (string, error)
+}
+
 type Client struct {
 	gqlClient *graphql.Client
-	token     string
+	tokens    TokenSource
 	log       *zap.Logger
 }
 
+// NewGraphQLClient creates the GraphQL client of the sportsbook, responses with 401 or 403 status
+// and GraphQL errors with auth codes are reported as ErrUnauthorized
+func NewGraphQLClient(endpoint string, httpClient *http.Client, log *zap.Logger) *graphql.Client {
+	authClient := *httpClient
+	authClient.Transport = authTransport{base: httpClient.Transport}
+
+	return graphql.NewClient(endpoint, graphql.WithHTTPClient(&authClient), func(client *graphql.Client) {
+		client.Log = func(s string) {
+			log.Sugar().Debug(s)
+		}
+	})
+}
+
 func NewSportsBookClient(
 	client *graphql.Client,
-	token string,
+	tokens TokenSource,
 	log *zap.Logger,
 ) *Client {
 	return &Client{
 		gqlClient: client,
-		token:     token,
+		tokens:    tokens,
 		log:       log,
 	}
 }
 
-func (c *Client) SportEventsByFilter(ctx context.Context, offset, limit int) ([]SportEvent, error) {
+func (c *Client) SportEventsByFilter(ctx context.Context, filter Filter, cursor Cursor) (Page, error) {
 	reg := graphql.NewRequest(string(sportEventListByFiltersBody))
 
-	reg.Var("offset", offset)
-	reg.Var("limit", limit)
+	matchStatuses := filter.MatchStatuses
+	if len(matchStatuses) == 0 {
+		matchStatuses = DefaultFilter().MatchStatuses
+	}
+
+	marketStatuses := filter.MarketStatuses
+	if len(marketStatuses) == 0 {
+		marketStatuses = DefaultFilter().MarketStatuses
+	}
+
+	reg.Var("matchStatuses", matchStatuses)
+	reg.Var("marketStatuses", marketStatuses)
+	reg.Var("offset", cursor.Offset)
+	reg.Var("limit", cursor.Limit)
 
 	result := SportEventListByFilters{}
 
 	if err := c.send(ctx, reg, &result); err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return Page{}, fmt.Errorf("failed to send request: %w", err)
 	}
 
-	return result.SportEventListByFilters.SportEvents, nil
+	sportEvents := result.SportEventListByFilters.SportEvents
+
+	return Page{
+		SportEvents: filter.Apply(sportEvents),
+		Next:        cursor.Next(),
+		Last:        len(sportEvents) < cursor.Limit,
+	}, nil
 }
 
-func (c *Client) Token() string {
-	return c.token
+// SportEventsByIDs returns actual state of sport events, unknown ids are skipped
+func (c *Client) SportEventsByIDs(ctx context.Context, ids []string) ([]SportEvent, error) {
+	reg := graphql.NewRequest(string(sportEventListByIdsBody))
+
+	reg.Var("ids", ids)
+
+	result := SportEventListByIds{}
+
+	if err := c.send(ctx, reg, &result); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	return result.SportEventListByIds.SportEvents, nil
 }
 
+// send runs the request, on auth failure the token is re-issued and the request is retried once
 func (c *Client) send(ctx context.Context, req *graphql.Request, resp any) error {
-	req.Header.Add("X-Auth-Token", c.token)
+	token, err := c.tokens.AuthToken(ctx)
+	if err != nil {
+		return fmt.Errorf("get auth token: %w", err)
+	}
+
+	req.Header.Set("X-Auth-Token", token)
+
+	err = c.gqlClient.Run(ctx, req, resp)
+	if err == nil || !isAuthError(err) {
+		return err
+	}
+
+	c.log.Warn("sportsbook rejected auth token, re-issue token", zap.Error(err))
+
+	token, err = c.tokens.RefreshAuthToken(ctx)
+	if err != nil {
+		return fmt.Errorf("refresh auth token: %w", err)
+	}
+
+	req.Header.Set("X-Auth-Token", token)
 
 	return c.gqlClient.Run(ctx, req, resp)
 }
+
+func isAuthError(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// authTransport detects rejected auth tokens, the GraphQL client exposes neither the status of the response
+// nor extensions of errors
+type authTransport struct {
+	base http.RoundTripper
+}
+
+func (t authTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	response, err := base.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden {
+		response.Body.Close()
+
+		return nil, fmt.Errorf("%w: %s", ErrUnauthorized, response.Status)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+
+	if code, ok := authErrorCode(body); ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnauthorized, code)
+	}
+
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	return response, nil
+}
+
+// authErrorCode returns the code of the first GraphQL error which is caused by the auth token
+func authErrorCode(body []byte) (string, bool) {
+	var result struct {
+		Errors []struct {
+			Extensions struct {
+				Code string `json:"code"`
+			} `json:"extensions"`
+		} `json:"errors"`
+	}
+
+	// bodies which are not GraphQL responses are reported by the GraphQL client
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", false
+	}
+
+	for _, e := range result.Errors {
+		if slices.Contains(authErrorCodes, e.Extensions.Code) {
+			return e.Extensions.Code, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,248 @@
+package sportsbook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestClient_RefreshesRejectedToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		refresh  int
+		rejected bool
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "unauthorized status", status: http.StatusUnauthorized, body: `{}`, refresh: 1},
+		{name: "forbidden status", status: http.StatusForbidden, refresh: 1},
+		{
+			name:    "unauthenticated error",
+			status:  http.StatusOK,
+			body:    `{"errors": [{"message": "expired", "extensions": {"code": "UNAUTHENTICATED"}}]}`,
+			refresh: 1,
+		},
+		{
+			name:     "error about token",
+			status:   http.StatusOK,
+			body:     `{"errors": [{"message": "invalid token of the page"}]}`,
+			rejected: true,
+		},
+		{name: "server error 401", status: http.StatusInternalServerError, body: `{"errors": [{"message": "401"}]}`, rejected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := &testTokens{}
+			response := sportEventsResponse(t, testSportEvent("match-1", MatchStatusNotStarted))
+
+			body := tt.body
+			if body == "" && tt.status == http.StatusOK {
+				body = response
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// the refreshed token is accepted
+				if r.Header.Get("X-Auth-Token") == "token-2" {
+					_, _ = w.Write([]byte(response))
+					return
+				}
+
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(body))
+			}))
+			t.Cleanup(server.Close)
+
+			client := NewSportsBookClient(NewGraphQLClient(server.URL, server.Client(), zap.NewNop()), tokens, zap.NewNop())
+
+			page, err := client.SportEventsByFilter(context.Background(), DefaultFilter(), Cursor{Offset: 0, Limit: 10})
+			assert.Equal(t, tt.refresh, tokens.refreshed)
+
+			if tt.rejected {
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, ErrUnauthorized)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, []string{"match-1"}, sportEventIDs(page.SportEvents))
+		})
+	}
+}
+
+// testTokens issues token-1, every refresh issues the next token
+type testTokens struct {
+	refreshed int
+}
+
+func (s *testTokens) AuthToken(_ context.Context) (string, error) {
+	return s.token(), nil
+}
+
+func (s *testTokens) RefreshAuthToken(_ context.Context) (string, error) {
+	s.refreshed++
+
+	return s.token(), nil
+}
+
+func (s *testTokens) token() string {
+	return "token-" + strconv.Itoa(s.refreshed+1)
+}
+
+func TestClient_SportEventsByFilter(t *testing.T) {
+	sportsbook := newTestSportsbook(t,
+		testSportEvent("match-1", MatchStatusNotStarted),
+		testSportEvent("match-2", MatchStatusEnded),
+		testSportEvent("match-3", MatchStatusLive),
+		testSportEvent("match-4", MatchStatusNotStarted),
+		testSportEvent("match-5", MatchStatusNotStarted),
+	)
+
+	cursor := Cursor{Offset: 0, Limit: 2}
+	pages := make([][]string, 0)
+
+	for {
+		page, err := sportsbook.client.SportEventsByFilter(context.Background(), DefaultFilter(), cursor)
+		require.NoError(t, err)
+
+		pages = append(pages, sportEventIDs(page.SportEvents))
+
+		if page.Last {
+			break
+		}
+
+		assert.Equal(t, cursor.Next(), page.Next)
+		cursor = page.Next
+	}
+
+	// the ended sport event is dropped by the filter, the page is shorter than the limit
+	assert.Equal(t, [][]string{{"match-1"}, {"match-3", "match-4"}, {"match-5"}}, pages)
+}
+
+// testSportsbook - GraphQL server of the sportsbook which returns pages of the list of sport events
+type testSportsbook struct {
+	client *Client
+
+	mu          sync.Mutex
+	sportEvents []SportEvent
+	requests    int
+}
+
+func newTestSportsbook(t *testing.T, sportEvents ...SportEvent) *testSportsbook {
+	sportsbook := &testSportsbook{sportEvents: sportEvents}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Variables struct {
+				Offset int `json:"offset"`
+				Limit  int `json:"limit"`
+			} `json:"variables"`
+		}
+
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&request)) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		sportsbook.mu.Lock()
+		sportsbook.requests++
+		all := sportsbook.sportEvents
+		sportsbook.mu.Unlock()
+
+		from := min(request.Variables.Offset, len(all))
+		to := min(from+request.Variables.Limit, len(all))
+
+		_, _ = w.Write([]byte(sportEventsResponse(t, all[from:to]...)))
+	}))
+	t.Cleanup(server.Close)
+
+	graphqlClient := NewGraphQLClient(server.URL, server.Client(), zap.NewNop())
+	sportsbook.client = NewSportsBookClient(graphqlClient, &testTokens{}, zap.NewNop())
+
+	return sportsbook
+}
+
+// set replaces sport events of the sportsbook
+func (s *testSportsbook) set(sportEvents ...SportEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sportEvents = sportEvents
+}
+
+func (s *testSportsbook) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// testSportEvent returns the sport event with the single active odd 1.5
+func testSportEvent(id string, status MatchStatus) SportEvent {
+	return SportEvent{
+		ID: id,
+		Fixture: Fixture{
+			StartTime: time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC),
+			SportId:   "sport-1",
+			Status:    status,
+		},
+		Markets: []Market{{
+			ID:     id + "-market",
+			Status: MarketStatusActive,
+			TypeId: 1,
+			Odds:   []Odd{{ID: id + "-odd", Value: apd.New(150, -2), Status: OddStatusNotResulted}},
+		}},
+	}
+}
+
+// sportEventsResponse returns the response of the sportsbook to the request of the list of sport events
+func sportEventsResponse(t *testing.T, sportEvents ...SportEvent) string {
+	wire := make([]any, 0, len(sportEvents))
+	for _, sportEvent := range sportEvents {
+		wire = append(wire, wireSportEvent(sportEvent))
+	}
+
+	response, err := json.Marshal(map[string]any{
+		"data": map[string]any{"sportEventListByFilters": map[string]any{"sportEvents": wire}},
+	})
+	assert.NoError(t, err)
+
+	return string(response)
+}
+
+// wireSportEvent returns the sport event as the sportsbook sends it, statuses of odds are names rather than ints
+func wireSportEvent(sportEvent SportEvent) map[string]any {
+	markets := make([]any, len(sportEvent.Markets))
+
+	for i, market := range sportEvent.Markets {
+		odds := make([]any, len(market.Odds))
+		for j, odd := range market.Odds {
+			odds[j] = map[string]any{"id": odd.ID, "value": odd.Value, "status": string(odd.Status)}
+		}
+
+		markets[i] = map[string]any{"id": market.ID, "status": market.Status, "typeId": market.TypeId, "odds": odds}
+	}
+
+	return map[string]any{"id": sportEvent.ID, "fixture": sportEvent.Fixture, "markets": markets}
+}
+
+func sportEventIDs(sportEvents []SportEvent) []string {
+	ids := make([]string, len(sportEvents))
+	for i, sportEvent := range sportEvents {
+		ids[i] = sportEvent.ID
+	}
+
+	return ids
+}
@@ -0,0 +1,86 @@
+package sportsbook
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+const defaultPageSize = 50
+
+var ErrNotEnoughSportEvents = errors.New("not enough sport events matched by the filter")
+
+// Feed walks through the sport events list page by page, so every next bet gets the next sport events.
+// When the end of the list is reached the feed starts from the first page again.
+type Feed struct {
+	client *Client
+	filter Filter
+
+	mu     sync.Mutex
+	cursor Cursor
+	buffer []SportEvent
+}
+
+func NewFeed(client *Client, filter Filter, pageSize int) *Feed {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	return &Feed{
+		client: client,
+		filter: filter,
+		cursor: Cursor{Offset: 0, Limit: pageSize},
+	}
+}
+
+// SportEvents returns count of distinct sport events
+func (f *Feed) SportEvents(ctx context.Context, count int) ([]SportEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := make([]SportEvent, 0, count)
+	startOffset := f.cursor.Offset
+	wrapped := false
+
+	for len(result) < count {
+		if len(f.buffer) == 0 {
+			if wrapped && f.cursor.Offset >= startOffset {
+				return nil, ErrNotEnoughSportEvents
+			}
+
+			if err := f.fetch(ctx); err != nil {
+				return nil, err
+			}
+
+			wrapped = wrapped || f.cursor.Offset == 0
+
+			continue
+		}
+
+		sportEvent := f.buffer[0]
+		f.buffer = f.buffer[1:]
+
+		if !slices.ContainsFunc(result, func(e SportEvent) bool { return e.ID == sportEvent.ID }) {
+			result = append(result, sportEvent)
+		}
+	}
+
+	return result, nil
+}
+
+func (f *Feed) fetch(ctx context.Context) error {
+	page, err := f.client.SportEventsByFilter(ctx, f.filter, f.cursor)
+	if err != nil {
+		return err
+	}
+
+	f.buffer = append(f.buffer, page.SportEvents...)
+	f.cursor = page.Next
+
+	if page.Last {
+		f.cursor.Offset = 0
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package sportsbook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_Next(t *testing.T) {
+	assert.Equal(t, Cursor{Offset: 20, Limit: 10}, Cursor{Offset: 10, Limit: 10}.Next())
+}
+
+func TestFeed_SportEvents(t *testing.T) {
+	sportsbook := newTestSportsbook(t,
+		testSportEvent("match-1", MatchStatusNotStarted),
+		testSportEvent("match-2", MatchStatusNotStarted),
+		testSportEvent("match-3", MatchStatusNotStarted),
+	)
+
+	feed := NewFeed(sportsbook.client, DefaultFilter(), 2)
+
+	tests := []struct {
+		name     string
+		count    int
+		expected []string
+	}{
+		{name: "first page", count: 2, expected: []string{"match-1", "match-2"}},
+		{name: "wraps to the first page", count: 2, expected: []string{"match-3", "match-1"}},
+		{name: "buffered", count: 2, expected: []string{"match-2", "match-3"}},
+	}
+
+	for _, tt := range tests {
+		sportEvents, err := feed.SportEvents(context.Background(), tt.count)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.expected, sportEventIDs(sportEvents), tt.name)
+	}
+}
+
+func TestFeed_NotEnoughSportEvents(t *testing.T) {
+	sportsbook := newTestSportsbook(t,
+		testSportEvent("match-1", MatchStatusNotStarted),
+		testSportEvent("match-2", MatchStatusEnded),
+		testSportEvent("match-3", MatchStatusNotStarted),
+	)
+
+	feed := NewFeed(sportsbook.client, DefaultFilter(), 2)
+
+	_, err := feed.SportEvents(context.Background(), 3)
+	require.ErrorIs(t, err, ErrNotEnoughSportEvents)
+
+	// the whole list is walked once before the error
+	assert.Equal(t, 2, sportsbook.requestCount())
+}
@@ -0,0 +1,117 @@
+package sportsbook
+
+import (
+	"slices"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+)
+
+const MarketStatusActive = "ACTIVE"
+
+// Filter describes which sport events, markets and odds can be used to generate bets.
+// MatchStatuses and MarketStatuses are sent to the sportsbook, other fields are applied to the received events.
+// Zero values of the fields mean "no restriction".
+type Filter struct {
+	MatchStatuses  []MatchStatus
+	MarketStatuses []string
+	SportIDs       []string
+	TournamentIDs  []string
+	StartTimeFrom  time.Time
+	StartTimeTo    time.Time
+	MarketTypeIDs  []int
+	MinOdd         *apd.Decimal
+	MaxOdd         *apd.Decimal
+}
+
+func DefaultFilter() Filter {
+	return Filter{
+		MatchStatuses:  []MatchStatus{MatchStatusNotStarted, MatchStatusLive},
+		MarketStatuses: []string{MarketStatusActive},
+	}
+}
+
+// Apply returns sport events matched by the filter. Markets and odds which are not matched are removed,
+// sport events without any active market with active odd are skipped.
+func (f Filter) Apply(sportEvents []SportEvent) []SportEvent {
+	result := make([]SportEvent, 0, len(sportEvents))
+
+	for _, sportEvent := range sportEvents {
+		if !f.matchSportEvent(sportEvent) {
+			continue
+		}
+
+		markets := make([]Market, 0, len(sportEvent.Markets))
+
+		for _, market := range sportEvent.Markets {
+			if !f.matchMarket(market) {
+				continue
+			}
+
+			market.Odds = slices.DeleteFunc(slices.Clone(market.Odds), func(odd Odd) bool {
+				return !f.matchOdd(odd)
+			})
+
+			if len(market.Odds) > 0 {
+				markets = append(markets, market)
+			}
+		}
+
+		if len(markets) == 0 {
+			continue
+		}
+
+		sportEvent.Markets = markets
+		result = append(result, sportEvent)
+	}
+
+	return result
+}
+
+func (f Filter) matchSportEvent(sportEvent SportEvent) bool {
+	fixture := sportEvent.Fixture
+
+	switch {
+	case len(f.MatchStatuses) > 0 && !slices.Contains(f.MatchStatuses, fixture.Status):
+		return false
+	case len(f.SportIDs) > 0 && !slices.Contains(f.SportIDs, fixture.SportId):
+		return false
+	case len(f.TournamentIDs) > 0 && !slices.Contains(f.TournamentIDs, fixture.Tournament.Id):
+		return false
+	case !f.StartTimeFrom.IsZero() && fixture.StartTime.Before(f.StartTimeFrom):
+		return false
+	case !f.StartTimeTo.IsZero() && fixture.StartTime.After(f.StartTimeTo):
+		return false
+	}
+
+	return true
+}
+
+func (f Filter) matchMarket(market Market) bool {
+	statuses := f.MarketStatuses
+	if len(statuses) == 0 {
+		statuses = []string{MarketStatusActive}
+	}
+
+	if !slices.Contains(statuses, market.Status) {
+		return false
+	}
+
+	return len(f.MarketTypeIDs) == 0 || slices.Contains(f.MarketTypeIDs, market.TypeId)
+}
+
+// matchOdd - odd is active when it is not resulted yet and has a value
+func (f Filter) matchOdd(odd Odd) bool {
+	switch {
+	case odd.ID == "" || odd.Value == nil:
+		return false
+	case odd.Status != OddStatusNotResulted && odd.Status != "":
+		return false
+	case f.MinOdd != nil && odd.Value.Cmp(f.MinOdd) < 0:
+		return false
+	case f.MaxOdd != nil && odd.Value.Cmp(f.MaxOdd) > 0:
+		return false
+	}
+
+	return true
+}
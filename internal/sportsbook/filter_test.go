@@ -0,0 +1,128 @@
+package sportsbook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/apd/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter_Apply(t *testing.T) {
+	withOdds := func(sportEvent SportEvent, odds ...Odd) SportEvent {
+		sportEvent.Markets[0].Odds = odds
+		return sportEvent
+	}
+
+	withMarket := func(sportEvent SportEvent, status string, typeID int) SportEvent {
+		sportEvent.Markets[0].Status = status
+		sportEvent.Markets[0].TypeId = typeID
+
+		return sportEvent
+	}
+
+	startsAt := func(sportEvent SportEvent, startTime time.Time) SportEvent {
+		sportEvent.Fixture.StartTime = startTime
+		return sportEvent
+	}
+
+	inTournament := func(sportEvent SportEvent, sportID, tournamentID string) SportEvent {
+		sportEvent.Fixture.SportId = sportID
+		sportEvent.Fixture.Tournament.Id = tournamentID
+
+		return sportEvent
+	}
+
+	odd := func(id string, value int64, status OddStatus) Odd {
+		return Odd{ID: id, Value: apd.New(value, -2), Status: status}
+	}
+
+	noon := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		filter      Filter
+		sportEvents []SportEvent
+		expected    []string
+		odds        []string
+	}{
+		{
+			name:   "match statuses",
+			filter: DefaultFilter(),
+			sportEvents: []SportEvent{
+				testSportEvent("match-1", MatchStatusNotStarted),
+				testSportEvent("match-2", MatchStatusEnded),
+				testSportEvent("match-3", MatchStatusLive),
+			},
+			expected: []string{"match-1", "match-3"},
+		},
+		{
+			name:   "sports and tournaments",
+			filter: Filter{SportIDs: []string{"sport-1"}, TournamentIDs: []string{"tournament-1"}},
+			sportEvents: []SportEvent{
+				inTournament(testSportEvent("match-1", MatchStatusNotStarted), "sport-1", "tournament-1"),
+				inTournament(testSportEvent("match-2", MatchStatusNotStarted), "sport-1", "tournament-2"),
+				inTournament(testSportEvent("match-3", MatchStatusNotStarted), "sport-2", "tournament-1"),
+			},
+			expected: []string{"match-1"},
+		},
+		{
+			name:   "start time",
+			filter: Filter{StartTimeFrom: noon, StartTimeTo: noon.Add(time.Hour)},
+			sportEvents: []SportEvent{
+				startsAt(testSportEvent("match-1", MatchStatusNotStarted), noon.Add(-time.Minute)),
+				startsAt(testSportEvent("match-2", MatchStatusNotStarted), noon),
+				startsAt(testSportEvent("match-3", MatchStatusNotStarted), noon.Add(time.Hour)),
+				startsAt(testSportEvent("match-4", MatchStatusNotStarted), noon.Add(time.Hour+time.Minute)),
+			},
+			expected: []string{"match-2", "match-3"},
+		},
+		{
+			name:   "markets",
+			filter: Filter{MarketTypeIDs: []int{1}},
+			sportEvents: []SportEvent{
+				withMarket(testSportEvent("match-1", MatchStatusNotStarted), MarketStatusActive, 1),
+				withMarket(testSportEvent("match-2", MatchStatusNotStarted), MarketStatusActive, 2),
+				withMarket(testSportEvent("match-3", MatchStatusNotStarted), "SUSPENDED", 1),
+			},
+			expected: []string{"match-1"},
+		},
+		{
+			name:   "odds",
+			filter: Filter{MinOdd: apd.New(140, -2), MaxOdd: apd.New(200, -2)},
+			sportEvents: []SportEvent{
+				withOdds(testSportEvent("match-1", MatchStatusNotStarted),
+					odd("low", 130, OddStatusNotResulted),
+					odd("min", 140, OddStatusNotResulted),
+					odd("max", 200, ""),
+					odd("high", 210, OddStatusNotResulted),
+					odd("resulted", 150, OddStatusWin),
+					Odd{ID: "without value", Status: OddStatusNotResulted},
+				),
+				withOdds(testSportEvent("match-2", MatchStatusNotStarted), odd("low", 120, OddStatusNotResulted)),
+			},
+			expected: []string{"match-1"},
+			odds:     []string{"min", "max"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sportEvents := tt.filter.Apply(tt.sportEvents)
+			assert.Equal(t, tt.expected, sportEventIDs(sportEvents))
+
+			if tt.odds == nil {
+				return
+			}
+
+			odds := make([]string, 0)
+			for _, o := range sportEvents[0].Markets[0].Odds {
+				odds = append(odds, o.ID)
+			}
+
+			assert.Equal(t, tt.odds, odds)
+			// odds of the received sport events are not changed
+			assert.Len(t, tt.sportEvents[0].Markets[0].Odds, 6)
+		})
+	}
+}
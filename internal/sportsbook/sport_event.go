@@ -141,6 +141,16 @@ type Odd struct {
 	CompetitorIds []string     `json:"competitorIds"`
 }
 
+func (m *Market) GetOdd(id string) (Odd, bool) {
+	for _, odd := range m.Odds {
+		if odd.ID == id {
+			return odd, true
+		}
+	}
+
+	return Odd{}, false
+}
+
 type SportEvent struct {
 	ID         string   `json:"ID"`
 	ProviderId string   `json:"providerId"`
@@ -148,8 +158,24 @@ type SportEvent struct {
 	Markets    []Market `json:"markets"`
 }
 
+func (e *SportEvent) GetMarket(id string) (Market, bool) {
+	for _, market := range e.Markets {
+		if market.ID == id {
+			return market, true
+		}
+	}
+
+	return Market{}, false
+}
+
 type SportEventListByFilters struct {
 	SportEventListByFilters struct {
 		SportEvents []SportEvent `json:"sportEvents"`
 	} `json:"sportEventListByFilters"`
 }
+
+type SportEventListByIds struct {
+	SportEventListByIds struct {
+		SportEvents []SportEvent `json:"sportEvents"`
+	} `json:"sportEventListByIds"`
+}
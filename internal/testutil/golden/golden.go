@@ -0,0 +1,42 @@
+// Package golden compares JSON payloads of tests with committed golden files.
+// It registers the -update flag, so it is imported only by tests and stays out of binaries of the tool
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with actual payloads")
+
+// AssertJSON compares indented JSON with testdata/<name>.golden.json,
+// run tests with -update to write actual payloads to golden files
+func AssertJSON(t *testing.T, name string, actual []byte) {
+	t.Helper()
+
+	var indented bytes.Buffer
+
+	require.NoError(t, json.Indent(&indented, actual, "", "  "), name)
+	indented.WriteByte('\n')
+
+	path := filepath.Join("testdata", name+".golden.json")
+
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, indented.Bytes(), 0o600))
+
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err, "run tests with -update to create golden files")
+
+	assert.Equal(t, string(expected), indented.String(), path)
+}
@@ -0,0 +1,282 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// draw draws all panes, the bottom line is the status line or the input line
+func (a *App) draw() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.writeTraffic()
+	a.screen.Clear()
+
+	width, height := a.screen.Size()
+	logHeight := max(6, (height-1)*2/5)
+	topHeight := height - 1 - logHeight
+	commandsHeight := max(3, topHeight-balanceHeight)
+
+	a.drawCommands(0, 0, leftWidth, commandsHeight)
+	a.drawBalance(0, commandsHeight, leftWidth, topHeight-commandsHeight)
+	a.drawBets(leftWidth, 0, width-leftWidth, topHeight)
+	a.drawLog(0, topHeight, width, logHeight)
+	a.drawStatus(0, height-1, width)
+
+	if a.picker != nil {
+		a.picker.draw(a.screen, width, height)
+	}
+
+	a.screen.Show()
+}
+
+// shortcut - line of the command pane
+type shortcut struct {
+	key   string
+	label string
+}
+
+// navigation - keys of panes which are shown after shortcuts of the command pane
+var navigation = []shortcut{
+	{"↑↓ jk", "select bet or scroll log"},
+	{"Tab", "focus bets or log"},
+	{"PgUp/Dn", "scroll log"},
+	{"End", "follow log"},
+	{"Ctrl-C", "quit"},
+}
+
+func (a *App) drawCommands(x, y, width, height int) {
+	a.box(x, y, width, height, "Commands", false)
+
+	shortcuts := convert(a.keys, func(b binding) shortcut { return shortcut{key: b.name(), label: b.label} })
+	shortcuts = append(shortcuts, navigation...)
+
+	for i, s := range shortcuts {
+		if i >= height-2 {
+			break
+		}
+
+		a.text(x+2, y+1+i, 7, s.key, keyStyle)
+		a.text(x+10, y+1+i, width-11, s.label, defaultStyle)
+	}
+}
+
+func (a *App) drawBalance(x, y, width, height int) {
+	a.box(x, y, width, height, "Balance", false)
+
+	var (
+		state     = a.sv.PlayerBalance()
+		exchanges = a.sv.Exchanges()
+		failed    = 0
+	)
+
+	for _, doc := range exchanges {
+		if !doc.Value.Succeeded() {
+			failed++
+		}
+	}
+
+	lines := []string{
+		fmt.Sprintf("Player:    %s", a.sv.PlayerID()),
+		fmt.Sprintf("Available: %s %s", state.Available.Text('f'), state.Currency),
+		fmt.Sprintf("Hold:      %s %s", state.Hold.Text('f'), state.Currency),
+		fmt.Sprintf("Callbacks: %d (%d failed)", len(exchanges), failed),
+		fmt.Sprintf("Stake:     %s", a.stake),
+	}
+
+	if token := a.sv.PlayerToken(); !token.ExpiresAt.IsZero() {
+		lines = append(lines, fmt.Sprintf("Token:     expires in %s", time.Until(token.ExpiresAt).Round(time.Second)))
+	}
+
+	for i, line := range lines {
+		if i < height-2 {
+			a.text(x+2, y+1+i, width-3, line, defaultStyle)
+		}
+	}
+}
+
+func (a *App) drawBets(x, y, width, height int) {
+	bets := a.bets()
+	a.selectedBet()
+
+	a.box(x, y, width, height, fmt.Sprintf("Bets: %s (%d)", filterNames[a.filter], len(bets)), !a.logFocus)
+
+	rows := height - 3
+	a.text(x+2, y+1, width-3, fmt.Sprintf("%-20s %-8s %10s  %-18s %s", "ID", "TYPE", "STAKE", "STATE", "UPDATED"), titleStyle)
+
+	selected := 0
+
+	for i, doc := range bets {
+		if doc.Value.BetID == a.selectedID {
+			selected = i
+		}
+	}
+
+	// the list is scrolled to keep the selected bet visible
+	offset := max(0, selected-rows+1)
+	cur := a.sv.PlayerCurrency()
+
+	for i := offset; i < len(bets) && i-offset < rows; i++ {
+		bet := bets[i].Value
+
+		style := defaultStyle
+		if bet.BetID == a.selectedID {
+			style = selectedStyle
+		}
+
+		line := fmt.Sprintf(
+			"%-20s %-8s %10s  %-18s %s",
+			bet.BetID,
+			bet.PrivateBetType,
+			cur.Format(bet.PrivateStake),
+			bet.RequestType,
+			bets[i].UpdatedAt.Format(time.TimeOnly),
+		)
+
+		a.text(x+2, y+2+i-offset, width-3, line, style)
+	}
+}
+
+func (a *App) drawLog(x, y, width, height int) {
+	title := "Log and callbacks"
+	if a.scroll > 0 {
+		title = fmt.Sprintf("%s (scrolled up %d lines, End to follow)", title, a.scroll)
+	}
+
+	a.box(x, y, width, height, title, a.logFocus)
+
+	rows := height - 2
+	lines := wrap(a.log.Lines(), width-3)
+
+	a.scroll = max(0, min(a.scroll, len(lines)-rows))
+	end := len(lines) - a.scroll
+
+	for i, line := range lines[max(0, end-rows):end] {
+		a.text(x+2, y+1+i, width-3, line.text, lineStyle(line.head))
+	}
+}
+
+func (a *App) drawStatus(x, y, width int) {
+	switch {
+	case a.input != nil:
+		a.text(x, y, width, fmt.Sprintf("%s: %s█", a.input.label, a.input.value), keyStyle)
+	case a.busy != "":
+		a.text(x, y, width, fmt.Sprintf("running %s...", a.busy), focusStyle)
+	case a.failed:
+		a.text(x, y, width, a.status, errorStyle)
+	default:
+		a.text(x, y, width, a.status, defaultStyle)
+	}
+}
+
+// box draws the border with the title
+func (a *App) box(x, y, width, height int, title string, focused bool) {
+	style := borderStyle
+	if focused {
+		style = focusStyle
+	}
+
+	drawBox(a.screen, x, y, width, height, title, style)
+}
+
+func drawBox(screen tcell.Screen, x, y, width, height int, title string, style tcell.Style) {
+	for i := x + 1; i < x+width-1; i++ {
+		screen.SetContent(i, y, tcell.RuneHLine, nil, style)
+		screen.SetContent(i, y+height-1, tcell.RuneHLine, nil, style)
+	}
+
+	for j := y + 1; j < y+height-1; j++ {
+		screen.SetContent(x, j, tcell.RuneVLine, nil, style)
+		screen.SetContent(x+width-1, j, tcell.RuneVLine, nil, style)
+	}
+
+	screen.SetContent(x, y, tcell.RuneULCorner, nil, style)
+	screen.SetContent(x+width-1, y, tcell.RuneURCorner, nil, style)
+	screen.SetContent(x, y+height-1, tcell.RuneLLCorner, nil, style)
+	screen.SetContent(x+width-1, y+height-1, tcell.RuneLRCorner, nil, style)
+
+	drawText(screen, x+2, y, width-4, " "+title+" ", titleStyle)
+}
+
+// text draws the line clipped to the width
+func (a *App) text(x, y, width int, s string, style tcell.Style) {
+	drawText(a.screen, x, y, width, s, style)
+}
+
+func drawText(screen tcell.Screen, x, y, width int, s string, style tcell.Style) {
+	i := 0
+
+	for _, r := range s {
+		if i >= width {
+			return
+		}
+
+		screen.SetContent(x+i, y, r, nil, style)
+		i++
+	}
+}
+
+// wrappedLine - part of the log line, the head is the whole line which defines the style
+type wrappedLine struct {
+	text string
+	head string
+}
+
+// wrap splits lines which are longer than the width
+func wrap(lines []string, width int) []wrappedLine {
+	wrapped := make([]wrappedLine, 0, len(lines))
+
+	for _, line := range lines {
+		runes := []rune(line)
+
+		for len(runes) > width && width > 0 {
+			wrapped = append(wrapped, wrappedLine{text: string(runes[:width]), head: line})
+			runes = runes[width:]
+		}
+
+		wrapped = append(wrapped, wrappedLine{text: string(runes), head: line})
+	}
+
+	return wrapped
+}
+
+// picker - overlay with the list of options, Enter picks the option and Esc closes the overlay
+type picker struct {
+	label    string
+	options  []string
+	selected int
+	pick     func(i int)
+}
+
+func (p *picker) draw(screen tcell.Screen, width, height int) {
+	w := len(p.label) + 6
+	for _, o := range p.options {
+		w = max(w, len(o)+6)
+	}
+
+	w = min(w, width)
+	h := min(len(p.options)+2, height-2)
+	x, y := (width-w)/2, (height-h)/2
+
+	for j := y; j < y+h; j++ {
+		drawText(screen, x, j, w, strings.Repeat(" ", w), defaultStyle)
+	}
+
+	drawBox(screen, x, y, w, h, p.label, focusStyle)
+
+	rows := h - 2
+	offset := max(0, p.selected-rows+1)
+
+	for i := offset; i < len(p.options) && i-offset < rows; i++ {
+		style := defaultStyle
+		if i == p.selected {
+			style = selectedStyle
+		}
+
+		drawText(screen, x+2, y+1+i-offset, w-4, p.options[i], style)
+	}
+}
@@ -0,0 +1,361 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+	"github.com/databet-cloud/callback-test-tool/internal/sportsbook"
+	"github.com/databet-cloud/callback-test-tool/internal/storage"
+)
+
+var errNoBet = errors.New("no bet is selected")
+
+// settleStatuses - statuses which can be declared for all legs of the selected bet
+var settleStatuses = []sportsbook.OddStatus{
+	sportsbook.OddStatusWin,
+	sportsbook.OddStatusLoss,
+	sportsbook.OddStatusHalfWin,
+	sportsbook.OddStatusHalfLoss,
+	sportsbook.OddStatusRefunded,
+	sportsbook.OddStatusCancelled,
+}
+
+// binding - shortcut of the command pane, the action is called with the lock of the UI held,
+// so it reads the state of the UI and starts background actions
+type binding struct {
+	key    rune
+	label  string
+	action func(ctx context.Context, a *App)
+}
+
+func (b binding) name() string {
+	return string(b.key)
+}
+
+// nolint:funlen // shortcuts are declared in one place to be shown by the command pane in the same order
+func bindings() []binding {
+	return []binding{
+		{key: '1', label: "place single bet", action: placeBet(callback.SingleBetType)},
+		{key: '2', label: "place express bet", action: placeBet(callback.ExpressBetType)},
+		{key: '3', label: "place system bet", action: placeBet(callback.SystemBetType)},
+		{key: '$', label: "change stake", action: func(_ context.Context, a *App) {
+			a.input = &input{label: "Stake", value: a.stake, submit: func(value string) error {
+				if _, err := a.sv.PlayerCurrency().Parse(value); err != nil {
+					return err
+				}
+
+				a.stake = value
+
+				return nil
+			}}
+		}},
+		{key: 'a', label: "accept bet", action: withBet(func(ctx context.Context, a *App, bet *callback.Data) {
+			a.start("accept "+bet.BetID, func() (string, error) {
+				return "accepted", a.sv.AcceptBet(ctx, bet.BetID)
+			})
+		})},
+		{key: 'd', label: "decline bet", action: withBet(declineBet)},
+		{key: 's', label: "settle bet", action: withBet(settleBet)},
+		{key: 'u', label: "unsettle bet", action: withBet(func(ctx context.Context, a *App, bet *callback.Data) {
+			a.start("unsettle "+bet.BetID, func() (string, error) {
+				return "unsettled", a.sv.UnSettleBet(ctx, bet.BetID)
+			})
+		})},
+		{key: 'c', label: "create cash-out", action: withBet(createCashOut)},
+		{key: 'o', label: "accept cash-out", action: withBet(acceptCashOut)},
+		{key: 'x', label: "decline cash-outs", action: withBet(declineCashOuts)},
+		{key: 'r', label: "replay last request", action: withBet(replay)},
+		{key: 't', label: "refresh token", action: func(ctx context.Context, a *App) {
+			a.start("refresh token", func() (string, error) {
+				token, err := a.sv.RefreshPlayerToken(ctx)
+
+				return fmt.Sprintf("expires at %s", token.ExpiresAt.Format("15:04:05")), err
+			})
+		}},
+		{key: 'f', label: "filter bets", action: func(_ context.Context, a *App) {
+			a.filter = (a.filter + 1) % len(filters)
+		}},
+		{key: 'q', label: "quit"},
+	}
+}
+
+// handleKey handles the key of the event and reports whether the UI is closed
+func (a *App) handleKey(ctx context.Context, ev *tcell.EventKey) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch {
+	case a.input != nil:
+		a.handleInput(ev)
+		return false
+	case a.picker != nil:
+		a.handlePicker(ev)
+		return false
+	}
+
+	switch ev.Key() {
+	case tcell.KeyCtrlC:
+		return true
+	case tcell.KeyTab:
+		a.logFocus = !a.logFocus
+	case tcell.KeyUp:
+		a.move(-1)
+	case tcell.KeyDown:
+		a.move(1)
+	case tcell.KeyPgUp:
+		a.scroll += 10
+	case tcell.KeyPgDn:
+		a.scroll = max(0, a.scroll-10)
+	case tcell.KeyEnd:
+		a.scroll = 0
+	case tcell.KeyRune:
+		switch ev.Rune() {
+		case 'k':
+			a.move(-1)
+		case 'j':
+			a.move(1)
+		case 'q':
+			return true
+		}
+
+		for _, b := range a.keys {
+			if b.key == ev.Rune() && b.action != nil {
+				b.action(ctx, a)
+			}
+		}
+	}
+
+	return false
+}
+
+// move moves the selection of the focused pane
+func (a *App) move(delta int) {
+	if a.logFocus {
+		a.scroll = max(0, a.scroll-delta)
+		return
+	}
+
+	a.moveSelection(delta)
+}
+
+// input - line of the status bar which is edited instead of the shortcuts, the value is applied by Enter
+type input struct {
+	label  string
+	value  string
+	submit func(value string) error
+}
+
+func (a *App) handleInput(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		a.input = nil
+	case tcell.KeyEnter:
+		in := a.input
+		a.input = nil
+
+		if err := in.submit(in.value); err != nil {
+			a.status, a.failed = fmt.Sprintf("%s: %s", in.label, err), true
+		}
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if runes := []rune(a.input.value); len(runes) > 0 {
+			a.input.value = string(runes[:len(runes)-1])
+		}
+	case tcell.KeyRune:
+		a.input.value += string(ev.Rune())
+	}
+}
+
+func (a *App) handlePicker(ev *tcell.EventKey) {
+	p := a.picker
+
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		a.picker = nil
+	case tcell.KeyUp:
+		p.selected = max(0, p.selected-1)
+	case tcell.KeyDown:
+		p.selected = min(len(p.options)-1, p.selected+1)
+	case tcell.KeyEnter:
+		a.picker = nil
+		p.pick(p.selected)
+	case tcell.KeyRune:
+		switch ev.Rune() {
+		case 'k':
+			p.selected = max(0, p.selected-1)
+		case 'j':
+			p.selected = min(len(p.options)-1, p.selected+1)
+		}
+	}
+}
+
+// withBet calls the action with the selected bet
+func withBet(action func(ctx context.Context, a *App, bet *callback.Data)) func(ctx context.Context, a *App) {
+	return func(ctx context.Context, a *App) {
+		bet, ok := a.selectedBet()
+		if !ok {
+			a.status, a.failed = errNoBet.Error(), true
+			return
+		}
+
+		action(ctx, a, bet)
+	}
+}
+
+func placeBet(betType callback.BetType) func(ctx context.Context, a *App) {
+	return func(ctx context.Context, a *App) {
+		stake, err := a.sv.PlayerCurrency().Parse(a.stake)
+		if err != nil {
+			a.status, a.failed = fmt.Sprintf("invalid stake: %s", err), true
+			return
+		}
+
+		a.start(fmt.Sprintf("place %s bet", betType), func() (string, error) {
+			bet, err := a.sv.PlaceBet(ctx, betType, stake)
+			if err != nil {
+				return "", err
+			}
+
+			a.mu.Lock()
+			a.selectedID = bet.BetID
+			a.mu.Unlock()
+
+			return "placed " + bet.BetID, nil
+		})
+	}
+}
+
+// declineBet picks the restriction type, the bet is declined with its default template for the first leg
+func declineBet(ctx context.Context, a *App, bet *callback.Data) {
+	types := a.sv.Restrictions().Types()
+
+	a.picker = &picker{
+		label:   "Decline with restriction",
+		options: convert(types, func(t callback.RestrictionType) string { return string(t) }),
+		pick: func(i int) {
+			tmpl, ok := a.sv.Restrictions().Default(types[i])
+			if !ok {
+				a.status, a.failed = fmt.Sprintf("no templates of the restriction %s", types[i]), true
+				return
+			}
+
+			a.start("decline "+bet.BetID, func() (string, error) {
+				r, err := a.sv.DraftRestriction(bet.BetID, tmpl, bet.PrivateOdds[0])
+				if err != nil {
+					return "", err
+				}
+
+				return fmt.Sprintf("declined with %s", r.Type), a.sv.DeclineBet(ctx, bet.BetID, []callback.Restriction{r})
+			})
+		},
+	}
+}
+
+// settleBet picks the status which is declared for all legs of the bet
+func settleBet(ctx context.Context, a *App, bet *callback.Data) {
+	a.picker = &picker{
+		label:   "Settle all legs as",
+		options: convert(settleStatuses, func(s sportsbook.OddStatus) string { return string(s) }),
+		pick: func(i int) {
+			status := settleStatuses[i]
+
+			a.start("settle "+bet.BetID, func() (string, error) {
+				odds := make([]*callback.Odd, len(bet.PrivateOdds))
+				for j, odd := range bet.PrivateOdds {
+					odds[j] = odd.WithStatus(status, a.sv.Now())
+				}
+
+				return fmt.Sprintf("settled as %s", status), a.sv.SettleBet(ctx, bet.BetID, odds)
+			})
+		},
+	}
+}
+
+func createCashOut(ctx context.Context, a *App, bet *callback.Data) {
+	a.input = &input{label: "Cash-out fraction of the remaining stake", value: "0.5", submit: func(value string) error {
+		fraction, err := strconv.ParseFloat(value, 64)
+		if err != nil || fraction <= 0 || fraction > 1 {
+			return fmt.Errorf("fraction %q is out of (0, 1]", value)
+		}
+
+		a.start("cash-out "+bet.BetID, func() (string, error) {
+			order, err := a.sv.CreateCashOutOrder(ctx, bet.BetID, fraction)
+			if err != nil {
+				return "", err
+			}
+
+			return "created " + order.String(), nil
+		})
+
+		return nil
+	}}
+}
+
+// acceptCashOut picks the created cash-out order of the bet
+func acceptCashOut(ctx context.Context, a *App, bet *callback.Data) {
+	orders := a.sv.CashOutOrders(bet.BetID, service.CashOutOrderCreated)
+	if len(orders) == 0 {
+		a.status, a.failed = fmt.Sprintf("bet %s has no created cash-out orders", bet.BetID), true
+		return
+	}
+
+	a.picker = &picker{
+		label: "Accept cash-out order",
+		options: convert(orders, func(d *storage.Document[*service.CashOutOrder]) string {
+			return d.Value.String()
+		}),
+		pick: func(i int) {
+			orderID := orders[i].Value.ID
+
+			a.start("accept cash-out "+orderID, func() (string, error) {
+				return "accepted", a.sv.AcceptCashOutOrder(ctx, orderID)
+			})
+		},
+	}
+}
+
+// declineCashOuts declines all created cash-out orders of the bet
+func declineCashOuts(ctx context.Context, a *App, bet *callback.Data) {
+	orders := a.sv.CashOutOrders(bet.BetID, service.CashOutOrderCreated)
+	if len(orders) == 0 {
+		a.status, a.failed = fmt.Sprintf("bet %s has no created cash-out orders", bet.BetID), true
+		return
+	}
+
+	orderIDs := convert(orders, func(d *storage.Document[*service.CashOutOrder]) string { return d.Value.ID })
+
+	a.start("decline cash-outs of "+bet.BetID, func() (string, error) {
+		return fmt.Sprintf("declined %d orders", len(orderIDs)), a.sv.DeclineCashOutOrders(ctx, bet.BetID, orderIDs)
+	})
+}
+
+// replay sends the last sent request of the bet again
+func replay(ctx context.Context, a *App, bet *callback.Data) {
+	exchanges := a.sv.Exchanges()
+
+	for i := len(exchanges) - 1; i >= 0; i-- {
+		if request := exchanges[i].Value.Request; request.BetID == bet.BetID {
+			a.start(fmt.Sprintf("replay %s %s", request.RequestType, request.RequestID), func() (string, error) {
+				return "replayed", a.sv.ReplayCallback(ctx, request)
+			})
+
+			return
+		}
+	}
+
+	a.status, a.failed = fmt.Sprintf("no requests of the bet %s", bet.BetID), true
+}
+
+func convert[T, V any](values []V, f func(V) T) []T {
+	result := make([]T, len(values))
+	for i := range values {
+		result[i] = f(values[i])
+	}
+
+	return result
+}
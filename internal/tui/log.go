@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Log - lines of the log pane, it is written by the logger and by the traffic of callbacks,
+// the oldest lines are dropped over the limit
+type Log struct {
+	mu      sync.Mutex
+	lines   []string
+	partial []byte
+	limit   int
+	notify  func()
+	// terminal - the logger writes to the terminal while the screen is not shown,
+	// so errors of the startup and the shutdown are not lost
+	terminal io.Writer
+	shown    bool
+}
+
+func NewLog(limit int, terminal io.Writer) *Log {
+	return &Log{limit: limit, terminal: terminal}
+}
+
+// Write appends complete lines of p, so the log can be the sink of the logger
+func (l *Log) Write(p []byte) (int, error) {
+	l.mu.Lock()
+
+	l.partial = append(l.partial, p...)
+
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		l.append(string(l.partial[:i]))
+		l.partial = l.partial[i+1:]
+	}
+
+	notify := l.notify
+	terminal := l.terminal
+
+	if l.shown {
+		terminal = nil
+	}
+	l.mu.Unlock()
+
+	if terminal != nil {
+		if _, err := terminal.Write(p); err != nil {
+			return 0, err
+		}
+	}
+
+	if notify != nil {
+		notify()
+	}
+
+	return len(p), nil
+}
+
+func (l *Log) Sync() error {
+	return nil
+}
+
+// Append appends lines, lines with line breaks are split
+func (l *Log) Append(lines ...string) {
+	l.mu.Lock()
+
+	for _, line := range lines {
+		for _, part := range strings.Split(line, "\n") {
+			l.append(part)
+		}
+	}
+
+	notify := l.notify
+	l.mu.Unlock()
+
+	if notify != nil {
+		notify()
+	}
+}
+
+// Lines returns a copy of all lines
+func (l *Log) Lines() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lines := make([]string, len(l.lines))
+	copy(lines, l.lines)
+
+	return lines
+}
+
+// setShown switches the logger between the log pane and the terminal
+func (l *Log) setShown(shown bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.shown = shown
+}
+
+func (l *Log) setNotify(notify func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.notify = notify
+}
+
+func (l *Log) append(line string) {
+	// tabs of the console encoder are not expanded by the screen
+	l.lines = append(l.lines, strings.ReplaceAll(line, "\t", "  "))
+
+	if over := len(l.lines) - l.limit; over > 0 {
+		l.lines = l.lines[over:]
+	}
+}
@@ -0,0 +1,267 @@
+// Package tui is the full-screen terminal UI of the session: the command pane with keyboard shortcuts,
+// the bet list, the balance and the scrolling pane of logs and callbacks
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/service"
+	"github.com/databet-cloud/callback-test-tool/internal/storage"
+)
+
+const (
+	// LogLimit - count of lines which are kept by the log pane
+	LogLimit = 5000
+	// refreshInterval - interval of redrawing, callbacks can be sent by the policy and the risk engine without keys
+	refreshInterval = 500 * time.Millisecond
+	// leftWidth - width of the command and the balance panes
+	leftWidth = 36
+	// balanceHeight - height of the balance pane with borders
+	balanceHeight = 9
+)
+
+var (
+	defaultStyle  = tcell.StyleDefault
+	borderStyle   = defaultStyle.Foreground(tcell.ColorGray)
+	focusStyle    = defaultStyle.Foreground(tcell.ColorYellow)
+	titleStyle    = defaultStyle.Bold(true)
+	keyStyle      = defaultStyle.Foreground(tcell.ColorAqua).Bold(true)
+	selectedStyle = defaultStyle.Reverse(true)
+	errorStyle    = defaultStyle.Foreground(tcell.ColorRed)
+	trafficStyle  = defaultStyle.Foreground(tcell.ColorGreen)
+	failedStyle   = defaultStyle.Foreground(tcell.ColorRed).Bold(true)
+)
+
+// filters - states of the bet list, the empty filter shows all bets
+var filters = [][]callback.RequestType{
+	nil,
+	{callback.BetPlaceRequestType},
+	{callback.BetAcceptRequestType, callback.BetUnSettleRequestType, callback.BetCashOutOrdersAcceptedRequestType,
+		callback.BetCashOutOrdersDeclinedRequestType},
+	{callback.BetSettleRequestType},
+	{callback.BetDeclineRequestType},
+}
+
+var filterNames = []string{"all", "placed", "open", "settled", "declined"}
+
+// App - state of the UI, actions run in the background one at a time and the screen is redrawn after them
+type App struct {
+	sv     *service.Service
+	log    *Log
+	screen tcell.Screen
+	keys   []binding
+
+	mu         sync.Mutex
+	stake      string
+	selectedID string
+	filter     int
+	// scroll - count of lines which the log pane is scrolled up from the latest line
+	scroll int
+	// logFocus - arrow keys scroll the log pane instead of the bet list
+	logFocus bool
+	// busy - name of the running action
+	busy   string
+	status string
+	failed bool
+	input  *input
+	picker *picker
+	// written - count of exchanges which are written to the log pane
+	written int
+
+	running sync.WaitGroup
+}
+
+// New creates the UI for the screen, the log is the sink of the logger of the service
+func New(sv *service.Service, log *Log, screen tcell.Screen, stake string) *App {
+	a := &App{sv: sv, log: log, screen: screen, stake: stake}
+	a.keys = bindings()
+
+	return a
+}
+
+// Run shows the UI until the quit key is pressed
+func (a *App) Run(ctx context.Context) error {
+	if err := a.screen.Init(); err != nil {
+		return fmt.Errorf("failed to init screen: %w", err)
+	}
+
+	// the terminal is restored before logs are written to it again
+	defer a.log.setShown(false)
+	defer a.screen.Fini()
+
+	a.log.setShown(true)
+
+	a.log.setNotify(a.redraw)
+	defer a.log.setNotify(nil)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				a.redraw()
+			}
+		}
+	}()
+
+	for {
+		a.draw()
+
+		switch ev := a.screen.PollEvent().(type) {
+		case nil:
+			return nil
+		case *tcell.EventResize:
+			a.screen.Sync()
+		case *tcell.EventKey:
+			if a.handleKey(ctx, ev) {
+				a.running.Wait()
+				return nil
+			}
+		}
+	}
+}
+
+// redraw wakes the event loop up, the event is dropped if the queue is full because the screen is drawn anyway
+func (a *App) redraw() {
+	_ = a.screen.PostEvent(tcell.NewEventInterrupt(nil))
+}
+
+// start runs the action in the background, the action is rejected while another one is running.
+// It is called by key handlers with the lock of the UI held.
+func (a *App) start(name string, action func() (string, error)) {
+	if a.busy != "" {
+		a.status, a.failed = fmt.Sprintf("%s is running", a.busy), true
+		return
+	}
+
+	a.busy, a.status, a.failed = name, "", false
+	a.running.Add(1)
+
+	go func() {
+		defer a.running.Done()
+
+		result, err := action()
+
+		a.mu.Lock()
+		a.busy = ""
+
+		if err != nil {
+			a.status, a.failed = fmt.Sprintf("%s: %s", name, err), true
+		} else {
+			a.status = fmt.Sprintf("%s: %s", name, result)
+		}
+		a.mu.Unlock()
+
+		a.redraw()
+	}()
+}
+
+// bets returns bets of the current filter, newest first
+func (a *App) bets() []*storage.Document[*callback.Data] {
+	return a.sv.Bets(filters[a.filter]...)
+}
+
+// selectedBet returns the selected bet, the first bet is selected if the selected one is filtered out
+func (a *App) selectedBet() (*callback.Data, bool) {
+	bets := a.bets()
+	if len(bets) == 0 {
+		return nil, false
+	}
+
+	for _, doc := range bets {
+		if doc.Value.BetID == a.selectedID {
+			return doc.Value, true
+		}
+	}
+
+	a.selectedID = bets[0].Value.BetID
+
+	return bets[0].Value, true
+}
+
+// moveSelection moves the selection by delta rows of the bet list
+func (a *App) moveSelection(delta int) {
+	bets := a.bets()
+	if len(bets) == 0 {
+		return
+	}
+
+	i := 0
+
+	for j, doc := range bets {
+		if doc.Value.BetID == a.selectedID {
+			i = j
+		}
+	}
+
+	a.selectedID = bets[max(0, min(len(bets)-1, i+delta))].Value.BetID
+}
+
+// writeTraffic writes exchanges which are sent since the last call to the log pane
+func (a *App) writeTraffic() {
+	exchanges := a.sv.Exchanges()
+
+	for ; a.written < len(exchanges); a.written++ {
+		a.log.Append(trafficLines(exchanges[a.written])...)
+	}
+}
+
+// trafficLines formats the exchange with the pretty-printed body of the request
+func trafficLines(doc *storage.Document[*service.Exchange]) []string {
+	exchange := doc.Value
+	request := exchange.Request
+
+	result := fmt.Sprintf("%d", exchange.StatusCode)
+	if !exchange.Succeeded() {
+		result = fmt.Sprintf("FAILED %d %s", exchange.StatusCode, exchange.Error)
+	}
+
+	lines := []string{fmt.Sprintf(
+		"── %s %s %s → %s (%d ms)",
+		exchange.SentAt.Format(time.TimeOnly),
+		request.RequestType,
+		request.BetID,
+		result,
+		exchange.Duration.Milliseconds(),
+	)}
+
+	body, err := json.MarshalIndent(request, "", "  ")
+	if err != nil {
+		body = []byte(err.Error())
+	}
+
+	lines = append(lines, string(body))
+
+	if exchange.ResponseBody != "" {
+		lines = append(lines, "response: "+exchange.ResponseBody)
+	}
+
+	return lines
+}
+
+// lineStyle highlights headers of exchanges in the log pane
+func lineStyle(line string) tcell.Style {
+	switch {
+	case strings.HasPrefix(line, "── ") && strings.Contains(line, "→ FAILED"):
+		return failedStyle
+	case strings.HasPrefix(line, "── "):
+		return trafficStyle
+	default:
+		return defaultStyle
+	}
+}
@@ -0,0 +1,192 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/databet-cloud/callback-test-tool/internal/callback"
+	"github.com/databet-cloud/callback-test-tool/internal/servicetest"
+)
+
+func TestApp_Lifecycle(t *testing.T) {
+	app, screen := newTestApp(t)
+
+	app.press(runes("$")...)
+	app.press(tcell.KeyBackspace2, tcell.KeyBackspace2)
+	app.press(runes("25")...)
+	app.press(tcell.KeyEnter)
+	assert.Equal(t, "25", app.stake)
+
+	app.press(runes("1a")...)
+	app.press('s', tcell.KeyEnter)
+
+	bets := app.sv.Bets()
+	require.Len(t, bets, 1)
+
+	bet := bets[0].Value
+	assert.Equal(t, callback.BetSettleRequestType, bet.RequestType)
+	assert.Equal(t, callback.WinSettleType, bet.SettleType)
+	assert.Equal(t, "25.00", app.sv.PlayerCurrency().Format(bet.PrivateStake))
+	assert.Equal(t, "settle "+bet.BetID+": settled as WIN", app.status)
+
+	app.draw()
+
+	content := contents(screen)
+	assert.Contains(t, content, "Bets: all (1)")
+	assert.Contains(t, content, bet.BetID)
+	assert.Contains(t, content, "Callbacks: 3 (0 failed)")
+	assert.Contains(t, content, "settled as WIN")
+
+	lines := strings.Join(app.log.Lines(), "\n")
+	assert.Contains(t, lines, "settle "+bet.BetID+" → 204")
+	assert.Contains(t, lines, `  "bet_id": "`+bet.BetID+`"`)
+}
+
+func TestApp_DeclineBet(t *testing.T) {
+	app, _ := newTestApp(t)
+
+	app.press(runes("1d")...)
+	require.NotNil(t, app.picker)
+
+	types := app.sv.Restrictions().Types()
+	app.press(tcell.KeyDown, tcell.KeyEnter)
+
+	require.Nil(t, app.picker)
+
+	bet := app.sv.Bets()[0].Value
+	assert.Equal(t, callback.BetDeclineRequestType, bet.RequestType)
+	require.Len(t, bet.Restrictions, 1)
+	assert.Equal(t, types[1], bet.Restrictions[0].Type)
+}
+
+func TestApp_CashOut(t *testing.T) {
+	app, _ := newTestApp(t)
+
+	app.press(runes("2a")...)
+	app.press('c', tcell.KeyEnter)
+	app.press('o', tcell.KeyEnter)
+
+	bet := app.sv.Bets()[0].Value
+	assert.Equal(t, callback.BetCashOutOrdersAcceptedRequestType, bet.RequestType)
+
+	app.press('o')
+	assert.Equal(t, "bet "+bet.BetID+" has no created cash-out orders", app.status)
+	assert.True(t, app.failed)
+}
+
+func TestApp_NoBet(t *testing.T) {
+	app, _ := newTestApp(t)
+
+	app.press('a')
+	assert.Equal(t, errNoBet.Error(), app.status)
+	assert.True(t, app.failed)
+	assert.Empty(t, app.sv.Exchanges())
+}
+
+func TestApp_Filter(t *testing.T) {
+	app, _ := newTestApp(t)
+
+	app.press(runes("11a")...)
+
+	app.press('f')
+	assert.Len(t, app.bets(), 1, "placed")
+
+	app.press('f')
+	assert.Len(t, app.bets(), 1, "open")
+
+	app.press('f')
+	assert.Empty(t, app.bets(), "settled")
+}
+
+func TestLog_Write(t *testing.T) {
+	log := NewLog(2, nil)
+
+	_, err := log.Write([]byte("first\nsec"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first"}, log.Lines())
+
+	_, err = log.Write([]byte("ond\tline\nthird\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"second  line", "third"}, log.Lines())
+}
+
+func TestLog_Terminal(t *testing.T) {
+	var terminal bytes.Buffer
+
+	log := NewLog(LogLimit, &terminal)
+
+	_, err := log.Write([]byte("startup\n"))
+	require.NoError(t, err)
+
+	log.setShown(true)
+
+	_, err = log.Write([]byte("shown\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "startup\n", terminal.String())
+	assert.Equal(t, []string{"startup", "shown"}, log.Lines())
+}
+
+type testApp struct {
+	*App
+}
+
+func newTestApp(t *testing.T) (testApp, tcell.SimulationScreen) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	require.NoError(t, screen.Init())
+	t.Cleanup(screen.Fini)
+
+	screen.SetSize(140, 40)
+
+	sv := servicetest.NewService(t, servicetest.NewCallbackServer(t))
+
+	return testApp{App: New(sv, NewLog(LogLimit, nil), screen, "10")}, screen
+}
+
+// press handles keys one by one, every started action is finished before the next key
+func (a testApp) press(keys ...any) {
+	for _, key := range keys {
+		switch k := key.(type) {
+		case rune:
+			a.handleKey(context.Background(), tcell.NewEventKey(tcell.KeyRune, k, tcell.ModNone))
+		case tcell.Key:
+			a.handleKey(context.Background(), tcell.NewEventKey(k, 0, tcell.ModNone))
+		}
+
+		a.running.Wait()
+	}
+}
+
+func runes(s string) []any {
+	keys := make([]any, 0, len(s))
+	for _, r := range s {
+		keys = append(keys, r)
+	}
+
+	return keys
+}
+
+// contents returns lines of the screen
+func contents(screen tcell.SimulationScreen) string {
+	cells, width, _ := screen.GetContents()
+
+	var b strings.Builder
+
+	for i, cell := range cells {
+		if len(cell.Runes) > 0 {
+			b.WriteRune(cell.Runes[0])
+		}
+
+		if (i+1)%width == 0 {
+			b.WriteByte('\n')
+		}
+	}
+
+	return b.String()
+}